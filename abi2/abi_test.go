@@ -1144,3 +1144,57 @@ func TestUnpackRevert(t *testing.T) {
 		})
 	}
 }
+
+func TestCustomError(t *testing.T) {
+	const definition = `[
+	{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"","type":"uint256"}]},
+	{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}]}
+	]`
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, ok := abi.Errors["InsufficientBalance"]
+	if !ok {
+		t.Fatal("expected error InsufficientBalance")
+	}
+	if e.Sig != "InsufficientBalance(uint256,uint256)" {
+		t.Errorf("unexpected signature %s", e.Sig)
+	}
+	if e.String() != "error InsufficientBalance(uint256 available, uint256 arg1)" {
+		t.Errorf("unexpected string %s", e.String())
+	}
+	hashResult, _ := hash.NewHasher(hash.KECCAK_256).Hash([]byte(e.Sig))
+	if !bytes.Equal(e.Selector(), hashResult[:4]) {
+		t.Errorf("unexpected selector %x", e.Selector())
+	}
+
+	data, err := e.Inputs.Pack(big.NewInt(1), big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(e.Selector(), data...)
+	found, err := abi.ErrorByID(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.Name != e.Name {
+		t.Errorf("unexpected error %s", found.Name)
+	}
+	var out struct {
+		Available *big.Int
+		Arg1      *big.Int
+	}
+	if err := e.Unpack(&out, data); err != nil {
+		t.Fatal(err)
+	}
+	if out.Available.Int64() != 1 || out.Arg1.Int64() != 2 {
+		t.Errorf("unexpected unpacked %v, %v", out.Available, out.Arg1)
+	}
+	if err := e.Unpack(&out, abi.Methods["transfer"].ID); err == nil {
+		t.Error("expected error unpacking the data of another selector")
+	}
+	if _, err := abi.ErrorByID([]byte{1, 2, 3, 4}); err == nil {
+		t.Error("expected error looking up an unknown selector")
+	}
+}
@@ -0,0 +1,209 @@
+package bind
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/meshplus/gosdk/common/hexutil"
+	"github.com/meshplus/gosdk/rpc"
+)
+
+// ErrLogsNotSupported is returned by the log methods of a backend which can not query the logs,
+// e.g. the gRPC backend without WithLogs
+var ErrLogsNotSupported = errors.New("bind: backend does not support logs")
+
+// ContractBackend is the connection to the chain which the bound contracts work with,
+// see NewRPCBackend and NewGRPCBackend
+type ContractBackend interface {
+	// DeployContract sign the deploy transaction by key and return the receipt
+	DeployContract(ctx context.Context, tx *rpc.Transaction, key interface{}) (*rpc.TxReceipt, error)
+	// InvokeContract sign the invoke transaction by key and return the receipt,
+	// the calls are simulated transactions
+	InvokeContract(ctx context.Context, tx *rpc.Transaction, key interface{}) (*rpc.TxReceipt, error)
+	// FilterLogs query the logs which match the filter
+	FilterLogs(ctx context.Context, filter *rpc.LogsFilter) ([]rpc.TxLog, error)
+	// WatchLogs deliver the logs which match the filter from the block number to handler,
+	// FromBlock and ToBlock of filter are ignored
+	WatchLogs(nodeIndex int, from uint64, filter *rpc.LogsFilter, handler func(blockNumber uint64, logs []rpc.TxLog) error) (Subscription, error)
+}
+
+// Subscription is the delivering of the logs started by WatchLogs
+type Subscription interface {
+	// Close stop delivering
+	Close()
+	// Done is closed once the subscription is stopped by Close or the error of handler
+	Done() <-chan struct{}
+	// Err return the error of handler which stops the subscription
+	Err() error
+}
+
+type rpcBackend struct {
+	rpc *rpc.RPC
+}
+
+// NewRPCBackend return a ContractBackend working with the json rpc of r,
+// the logs are watched by rpc.LogStream
+func NewRPCBackend(r *rpc.RPC) ContractBackend {
+	return &rpcBackend{rpc: r}
+}
+
+func (b *rpcBackend) withContext(ctx context.Context) *rpc.RPC {
+	if ctx == nil {
+		return b.rpc
+	}
+	return b.rpc.WithContext(ctx)
+}
+
+func (b *rpcBackend) DeployContract(ctx context.Context, tx *rpc.Transaction, key interface{}) (*rpc.TxReceipt, error) {
+	receipt, err := b.withContext(ctx).SignAndDeployContract(tx, key)
+	if err != nil {
+		return failedReceipt(err)
+	}
+	return receipt, nil
+}
+
+func (b *rpcBackend) InvokeContract(ctx context.Context, tx *rpc.Transaction, key interface{}) (*rpc.TxReceipt, error) {
+	receipt, err := b.withContext(ctx).SignAndInvokeContract(tx, key)
+	if err != nil {
+		return failedReceipt(err)
+	}
+	return receipt, nil
+}
+
+// failedReceipt return the invalid receipt of a failed transaction if the json rpc error carries the revert data,
+// which is the hex string or the receipt with ret, so that the revert is decoded as the one of gRPC. Otherwise err is returned
+func failedReceipt(err rpc.StdError) (*rpc.TxReceipt, error) {
+	var re *rpc.RetError
+	if !errors.As(err, &re) || len(re.Data()) == 0 {
+		return nil, err
+	}
+	var receipt struct {
+		TxHash string `json:"txHash"`
+		Ret    string `json:"ret"`
+	}
+	if json.Unmarshal(re.Data(), &receipt.Ret) != nil {
+		if json.Unmarshal(re.Data(), &receipt) != nil {
+			return nil, err
+		}
+	}
+	if _, decodeErr := hexutil.Decode(receipt.Ret); decodeErr != nil {
+		return nil, err
+	}
+	msg := re.Error()
+	if msg == "" {
+		msg = "execution reverted"
+	}
+	return &rpc.TxReceipt{TxHash: receipt.TxHash, Ret: receipt.Ret, ErrorMsg: msg}, nil
+}
+
+func (b *rpcBackend) FilterLogs(ctx context.Context, filter *rpc.LogsFilter) ([]rpc.TxLog, error) {
+	logs, err := b.withContext(ctx).GetLogs(filter)
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+func (b *rpcBackend) WatchLogs(nodeIndex int, from uint64, filter *rpc.LogsFilter, handler func(blockNumber uint64, logs []rpc.TxLog) error) (Subscription, error) {
+	stream := b.rpc.NewLogStream(nodeIndex, from, filter, handler)
+	if err := stream.Start(); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// GRPCBackend is a ContractBackend sending the transactions by the contract streams of gRPC,
+// the logs are queried by the json rpc set by WithLogs
+type GRPCBackend struct {
+	grpc *rpc.GRPC
+	opt  rpc.ClientOption
+	logs ContractBackend
+
+	once     sync.Once
+	contract *rpc.ContractGrpc
+	err      error
+}
+
+// NewGRPCBackend return a GRPCBackend of g, the streams are created by opt on the first transaction
+func NewGRPCBackend(g *rpc.GRPC, opt rpc.ClientOption) *GRPCBackend {
+	return &GRPCBackend{grpc: g, opt: opt}
+}
+
+// WithLogs query and watch the logs by the json rpc of r
+func (b *GRPCBackend) WithLogs(r *rpc.RPC) *GRPCBackend {
+	b.logs = NewRPCBackend(r)
+	return b
+}
+
+// Close close the contract streams, the backend can not be used after closed
+func (b *GRPCBackend) Close() error {
+	b.once.Do(func() {
+		b.err = errors.New("bind: backend closed")
+	})
+	if b.contract != nil {
+		return b.contract.Close()
+	}
+	return nil
+}
+
+func (b *GRPCBackend) contractGrpc() (*rpc.ContractGrpc, error) {
+	b.once.Do(func() {
+		b.contract, b.err = b.grpc.NewContractGrpc(b.opt)
+	})
+	return b.contract, b.err
+}
+
+func (b *GRPCBackend) DeployContract(ctx context.Context, tx *rpc.Transaction, key interface{}) (*rpc.TxReceipt, error) {
+	c, err := b.prepare(ctx, tx, key)
+	if err != nil {
+		return nil, err
+	}
+	receipt, stdErr := c.DeployContractReturnReceipt(tx)
+	if stdErr != nil {
+		return nil, stdErr
+	}
+	return receipt, nil
+}
+
+func (b *GRPCBackend) InvokeContract(ctx context.Context, tx *rpc.Transaction, key interface{}) (*rpc.TxReceipt, error) {
+	c, err := b.prepare(ctx, tx, key)
+	if err != nil {
+		return nil, err
+	}
+	receipt, stdErr := c.InvokeContractReturnReceipt(tx)
+	if stdErr != nil {
+		return nil, stdErr
+	}
+	return receipt, nil
+}
+
+// prepare sign the transaction, the streams do not take a context so that ctx is only checked before sending
+func (b *GRPCBackend) prepare(ctx context.Context, tx *rpc.Transaction, key interface{}) (*rpc.ContractGrpc, error) {
+	if ctx != nil {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	c, err := b.contractGrpc()
+	if err != nil {
+		return nil, err
+	}
+	tx.Sign(key)
+	return c, nil
+}
+
+func (b *GRPCBackend) FilterLogs(ctx context.Context, filter *rpc.LogsFilter) ([]rpc.TxLog, error) {
+	if b.logs == nil {
+		return nil, ErrLogsNotSupported
+	}
+	return b.logs.FilterLogs(ctx, filter)
+}
+
+func (b *GRPCBackend) WatchLogs(nodeIndex int, from uint64, filter *rpc.LogsFilter, handler func(blockNumber uint64, logs []rpc.TxLog) error) (Subscription, error) {
+	if b.logs == nil {
+		return nil, ErrLogsNotSupported
+	}
+	return b.logs.WatchLogs(nodeIndex, from, filter, handler)
+}
@@ -0,0 +1,333 @@
+package bind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/meshplus/gosdk/abi2"
+	"github.com/meshplus/gosdk/account"
+	"github.com/meshplus/gosdk/common"
+	"github.com/meshplus/gosdk/rpc"
+)
+
+// ErrNoKey is returned if the opts do not carry the key to sign the transaction
+var ErrNoKey = errors.New("bind: no key to sign the transaction")
+
+// CallOpts is the collection of options to fine tune a contract call request,
+// the calls are sent as simulated transactions so that they are signed as well
+type CallOpts struct {
+	Key     interface{}     // account.Key or account.Signer to sign the simulated transaction
+	From    string          // Optional the sender address, the address of Key is used if empty
+	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// TransactOpts is the collection of options to create a valid transaction
+type TransactOpts struct {
+	Key      interface{}     // account.Key or account.Signer to sign the transaction
+	From     string          // Optional the sender address, the address of Key is used if empty
+	Simulate bool            // Simulate the transaction without committing it
+	Context  context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// FilterOpts is the collection of options to fine tune filtering for events within a bound contract
+type FilterOpts struct {
+	Start   uint64          // Start of the queried range
+	End     *uint64         // End of the range (nil = latest)
+	Context context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// WatchOpts is the collection of options to fine tune subscribing for events within a bound contract
+type WatchOpts struct {
+	NodeIndex int    // The node to subscribe, start from 1
+	Start     uint64 // Start of the delivered blocks
+}
+
+// BoundContract is the base wrapper object that reflects a contract on the chain,
+// it contains a collection of methods used by the generated bindings
+type BoundContract struct {
+	address common.Address
+	abi     abi2.ABI
+	backend ContractBackend
+	errors  map[string]func() error
+}
+
+// NewBoundContract creates a low level contract interface through which calls and transactions may be made through
+func NewBoundContract(address common.Address, abi abi2.ABI, backend ContractBackend) *BoundContract {
+	return &BoundContract{
+		address: address,
+		abi:     abi,
+		backend: backend,
+		errors:  make(map[string]func() error),
+	}
+}
+
+// DeployContract deploys a contract onto the chain and binds the deployment address with a Go wrapper
+func DeployContract(opts *TransactOpts, abi abi2.ABI, bytecode []byte, backend ContractBackend, params ...interface{}) (common.Address, *rpc.TxReceipt, *BoundContract, error) {
+	c := NewBoundContract(common.Address{}, abi, backend)
+	receipt, err := c.Deploy(opts, bytecode, params...)
+	if err != nil {
+		return common.Address{}, receipt, nil, err
+	}
+	return c.address, receipt, c, nil
+}
+
+// Address return the address of the contract
+func (c *BoundContract) Address() common.Address {
+	return c.address
+}
+
+// ABI return the abi of the contract
+func (c *BoundContract) ABI() abi2.ABI {
+	return c.abi
+}
+
+// RegisterError register the typed error of the custom error named name, newErr return a pointer
+// to the struct with the fields named by the inputs of the error, which is unpacked from the revert data
+func (c *BoundContract) RegisterError(name string, newErr func() error) {
+	c.errors[name] = newErr
+}
+
+// Deploy deploys the contract with the constructor params, the address of c is set by the receipt
+func (c *BoundContract) Deploy(opts *TransactOpts, bytecode []byte, params ...interface{}) (*rpc.TxReceipt, error) {
+	from, err := opts.Sender()
+	if err != nil {
+		return nil, err
+	}
+	input, err := c.abi.Pack("", params...)
+	if err != nil {
+		return nil, err
+	}
+	tx := rpc.NewTransaction(from).DeployWithArgs(bytecode, input).Simulate(opts.Simulate)
+	receipt, err := c.backend.DeployContract(opts.Context, tx, opts.Key)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkReceipt(receipt); err != nil {
+		return receipt, err
+	}
+	c.address = common.HexToAddress(receipt.ContractAddress)
+	return receipt, nil
+}
+
+// Call invokes the (constant) contract method with params as input values and return the output values
+func (c *BoundContract) Call(opts *CallOpts, method string, params ...interface{}) ([]interface{}, error) {
+	if opts == nil {
+		opts = new(CallOpts)
+	}
+	from, err := keyAddress(opts.From, opts.Key)
+	if err != nil {
+		return nil, err
+	}
+	input, err := c.abi.Pack(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	tx := rpc.NewTransaction(from).Invoke(c.address.Hex(), input).Simulate(true)
+	receipt, err := c.backend.InvokeContract(opts.Context, tx, opts.Key)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkReceipt(receipt); err != nil {
+		return nil, err
+	}
+	return c.abi.Unpack(method, common.FromHex(receipt.Ret))
+}
+
+// Transact invokes the (paid) contract method with params as input values and return the receipt
+func (c *BoundContract) Transact(opts *TransactOpts, method string, params ...interface{}) (*rpc.TxReceipt, error) {
+	from, err := opts.Sender()
+	if err != nil {
+		return nil, err
+	}
+	input, err := c.abi.Pack(method, params...)
+	if err != nil {
+		return nil, err
+	}
+	tx := rpc.NewTransaction(from).Invoke(c.address.Hex(), input).Simulate(opts.Simulate)
+	receipt, err := c.backend.InvokeContract(opts.Context, tx, opts.Key)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkReceipt(receipt); err != nil {
+		return receipt, err
+	}
+	return receipt, nil
+}
+
+// FilterLogs filters the logs of the event named name in the block range of opts, query is the
+// values of the indexed inputs in order, nil matches any value of the input
+func (c *BoundContract) FilterLogs(opts *FilterOpts, name string, query ...[]interface{}) ([]rpc.TxLog, error) {
+	if opts == nil {
+		opts = new(FilterOpts)
+	}
+	filter, err := c.logsFilter(name, query)
+	if err != nil {
+		return nil, err
+	}
+	filter.SetFromBlock(opts.Start)
+	if opts.End != nil {
+		filter.SetToBlock(*opts.End)
+	}
+	return c.backend.FilterLogs(opts.Context, filter)
+}
+
+// WatchLogs delivers the logs of the event named name to handler one by one, see FilterLogs for query
+func (c *BoundContract) WatchLogs(opts *WatchOpts, name string, handler func(log rpc.TxLog) error, query ...[]interface{}) (Subscription, error) {
+	if opts == nil {
+		opts = new(WatchOpts)
+	}
+	filter, err := c.logsFilter(name, query)
+	if err != nil {
+		return nil, err
+	}
+	return c.backend.WatchLogs(opts.NodeIndex, opts.Start, filter, func(_ uint64, logs []rpc.TxLog) error {
+		for _, log := range logs {
+			if err := handler(log); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// logsFilter return the filter of the contract address and the topics of the event
+func (c *BoundContract) logsFilter(name string, query [][]interface{}) (*rpc.LogsFilter, error) {
+	event, ok := c.abi.Events[name]
+	if !ok {
+		return nil, fmt.Errorf("bind: event %s not found", name)
+	}
+	if !event.Anonymous {
+		query = append([][]interface{}{{event.ID}}, query...)
+	}
+	if len(query) > 4 {
+		return nil, fmt.Errorf("bind: too many topics of event %s", name)
+	}
+	topics, err := abi2.MakeTopics(query...)
+	if err != nil {
+		return nil, err
+	}
+	filter := rpc.NewLogsFilter().AddAddress(c.address.Hex())
+	for i, topic := range topics {
+		filter.SetTopic(i, topic...)
+	}
+	return filter, nil
+}
+
+// UnpackLog unpacks the log of the event named name into out, which is a pointer to a struct
+// with the fields named by the inputs of the event
+func (c *BoundContract) UnpackLog(out interface{}, name string, log rpc.TxLog) error {
+	event, ok := c.abi.Events[name]
+	if !ok {
+		return fmt.Errorf("bind: event %s not found", name)
+	}
+	topics := make([]common.Hash, len(log.Topics))
+	for i, topic := range log.Topics {
+		topics[i] = common.HexToHash(topic)
+	}
+	if !event.Anonymous {
+		if len(topics) == 0 || topics[0] != event.ID {
+			return fmt.Errorf("bind: log is not of event %s", name)
+		}
+		topics = topics[1:]
+	}
+	if data := common.FromHex(log.Data); len(data) > 0 {
+		values, err := event.Inputs.Unpack(data)
+		if err != nil {
+			return err
+		}
+		// the non-indexed inputs are copied by names if there are indexed ones
+		if err := event.Inputs.Copy(out, values); err != nil {
+			return err
+		}
+	}
+	var indexed abi2.Arguments
+	for _, arg := range event.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	return abi2.ParseTopics(out, indexed, topics)
+}
+
+// checkReceipt return a RevertError if the transaction failed
+func (c *BoundContract) checkReceipt(receipt *rpc.TxReceipt) error {
+	if receipt == nil || receipt.Valid || receipt.ErrorMsg == "" {
+		return nil
+	}
+	return c.revertError(receipt)
+}
+
+// revertError unpack the revert reason or the registered custom error from the ret of the receipt
+func (c *BoundContract) revertError(receipt *rpc.TxReceipt) *RevertError {
+	err := &RevertError{Receipt: receipt, Data: common.FromHex(receipt.Ret)}
+	if reason, unpackErr := abi2.UnpackRevert(err.Data); unpackErr == nil {
+		err.Reason = reason
+		return err
+	}
+	if e, lookupErr := c.abi.ErrorByID(err.Data); lookupErr == nil {
+		err.Reason = e.Name
+		if newErr, ok := c.errors[e.Name]; ok {
+			custom := newErr()
+			if e.Unpack(custom, err.Data) == nil {
+				err.Custom = custom
+			}
+		}
+	}
+	return err
+}
+
+// RevertError is the error of a failed transaction, use errors.As to get the typed custom error, e.g.
+//
+//	var insufficient *token.TokenInsufficientBalance
+//	if errors.As(err, &insufficient) { ... }
+type RevertError struct {
+	Receipt *rpc.TxReceipt
+	// Data is the revert data in ret of the receipt
+	Data []byte
+	// Reason is the revert reason of require or revert, or the name of the custom error
+	Reason string
+	// Custom is the registered typed error unpacked from Data
+	Custom error
+}
+
+func (e *RevertError) Error() string {
+	if e.Custom != nil {
+		return "execution reverted: " + e.Custom.Error()
+	}
+	if e.Reason != "" {
+		return "execution reverted: " + e.Reason
+	}
+	return "transaction failed: " + e.Receipt.ErrorMsg
+}
+
+// Unwrap return the typed custom error
+func (e *RevertError) Unwrap() error {
+	return e.Custom
+}
+
+// Sender return the sender address of the transaction, From or the address of Key,
+// the wrappers of other contracts built on TransactOpts use it to fill the transaction
+func (opts *TransactOpts) Sender() (string, error) {
+	if opts == nil {
+		return "", ErrNoKey
+	}
+	return keyAddress(opts.From, opts.Key)
+}
+
+// keyAddress return from if not empty, or the address of key
+func keyAddress(from string, key interface{}) (string, error) {
+	if key == nil {
+		return "", ErrNoKey
+	}
+	if from != "" {
+		return from, nil
+	}
+	switch k := key.(type) {
+	case *account.DIDKey:
+		return k.GetAddress(), nil
+	case interface{ GetAddress() common.Address }:
+		return k.GetAddress().Hex(), nil
+	}
+	return "", fmt.Errorf("bind: can not get the address of key %T, set From of the opts", key)
+}
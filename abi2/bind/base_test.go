@@ -0,0 +1,219 @@
+package bind_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/meshplus/gosdk/abi2"
+	"github.com/meshplus/gosdk/abi2/bind"
+	"github.com/meshplus/gosdk/abi2/bind/bindtest"
+	"github.com/meshplus/gosdk/account"
+	"github.com/meshplus/gosdk/common"
+	"github.com/meshplus/gosdk/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+const tokenABI = `[
+	{"type":"constructor","inputs":[{"name":"supply","type":"uint256"}]},
+	{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"transfer","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"amount","type":"uint256","indexed":false}]},
+	{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}]}
+]`
+
+type insufficientBalance struct {
+	Available *big.Int
+	Required  *big.Int
+}
+
+func (e *insufficientBalance) Error() string {
+	return fmt.Sprintf("InsufficientBalance(%v, %v)", e.Available, e.Required)
+}
+
+func newTestContract(t *testing.T) (*bind.BoundContract, *bindtest.Backend, *account.ECDSAKey) {
+	key, err := account.NewAccountFromPriv("a1fd6ed6225e76aac3884b5420c8cdbb4fde1db01e9ef773415b8f2b5a9b77d4")
+	assert.Nil(t, err)
+	backend := &bindtest.Backend{}
+	return newTokenContract(t, backend), backend, key
+}
+
+func newTokenContract(t *testing.T, backend bind.ContractBackend) *bind.BoundContract {
+	parsed, err := abi2.JSON(strings.NewReader(tokenABI))
+	assert.Nil(t, err)
+	c := bind.NewBoundContract(common.HexToAddress("0x1234"), parsed, backend)
+	c.RegisterError("InsufficientBalance", func() error { return new(insufficientBalance) })
+	return c
+}
+
+func TestBoundContract_Deploy(t *testing.T) {
+	c, backend, key := newTestContract(t)
+	backend.Receipt = &rpc.TxReceipt{Valid: true, ContractAddress: "0x000000000000000000000000000000000000abcd"}
+	address, receipt, bound, err := bind.DeployContract(&bind.TransactOpts{Key: key}, c.ABI(), []byte{0x60, 0x80}, backend, big.NewInt(100))
+	assert.Nil(t, err)
+	assert.Equal(t, backend.Receipt, receipt)
+	assert.Equal(t, common.HexToAddress("0xabcd"), address)
+	assert.Equal(t, address, bound.Address())
+
+	args, _ := c.ABI().Constructor.Inputs.Pack(big.NewInt(100))
+	assert.Equal(t, "0x6080"+common.Bytes2Hex(args), backend.Txs[0].GetPayload())
+	assert.Equal(t, key.GetAddress().Hex(), common.HexToAddress(backend.Txs[0].GetFrom()).Hex())
+
+	_, _, _, err = bind.DeployContract(&bind.TransactOpts{}, c.ABI(), []byte{0x60, 0x80}, backend)
+	assert.Equal(t, bind.ErrNoKey, err)
+}
+
+func TestTransactOpts_Sender(t *testing.T) {
+	_, _, key := newTestContract(t)
+	from, err := (&bind.TransactOpts{Key: key}).Sender()
+	assert.Nil(t, err)
+	assert.Equal(t, key.GetAddress().Hex(), common.HexToAddress(from).Hex())
+	from, err = (&bind.TransactOpts{Key: key, From: "0x01"}).Sender()
+	assert.Nil(t, err)
+	assert.Equal(t, "0x01", from)
+
+	_, err = (&bind.TransactOpts{From: "0x01"}).Sender()
+	assert.Equal(t, bind.ErrNoKey, err)
+	var opts *bind.TransactOpts
+	_, err = opts.Sender()
+	assert.Equal(t, bind.ErrNoKey, err)
+}
+
+func TestBoundContract_Call(t *testing.T) {
+	c, backend, key := newTestContract(t)
+	ret, _ := c.ABI().Methods["balanceOf"].Outputs.Pack(big.NewInt(42))
+	backend.Receipt = &rpc.TxReceipt{Valid: true, Ret: common.ToHex(ret)}
+
+	out, err := c.Call(&bind.CallOpts{Key: key}, "balanceOf", common.HexToAddress("0x01"))
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(42), out[0])
+	assert.Equal(t, c.Address().Hex(), common.HexToAddress(backend.Txs[0].GetTo()).Hex())
+
+	_, err = c.Call(nil, "balanceOf", common.HexToAddress("0x01"))
+	assert.Equal(t, bind.ErrNoKey, err)
+}
+
+func TestBoundContract_Revert(t *testing.T) {
+	c, backend, key := newTestContract(t)
+	e := c.ABI().Errors["InsufficientBalance"]
+	args, _ := e.Inputs.Pack(big.NewInt(1), big.NewInt(2))
+	backend.Receipt = &rpc.TxReceipt{ErrorMsg: "reverted", Ret: common.ToHex(append(append([]byte{}, e.Selector()...), args...))}
+
+	receipt, err := c.Transact(&bind.TransactOpts{Key: key}, "transfer", common.HexToAddress("0x02"), big.NewInt(2))
+	assert.Equal(t, backend.Receipt, receipt)
+	var revert *bind.RevertError
+	assert.True(t, errors.As(err, &revert))
+	assert.Equal(t, "InsufficientBalance", revert.Reason)
+	var custom *insufficientBalance
+	assert.True(t, errors.As(err, &custom))
+	assert.Equal(t, big.NewInt(1), custom.Available)
+	assert.Equal(t, big.NewInt(2), custom.Required)
+	assert.Equal(t, "execution reverted: InsufficientBalance(1, 2)", err.Error())
+
+	// Error(string) of require
+	reason, _ := abi2.NewType("string", "", nil)
+	args, _ = abi2.Arguments{{Type: reason}}.Pack("not owner")
+	backend.Receipt.Ret = common.ToHex(append(common.FromHex("0x08c379a0"), args...))
+	_, err = c.Call(&bind.CallOpts{Key: key}, "balanceOf", common.HexToAddress("0x01"))
+	assert.Equal(t, "execution reverted: not owner", err.Error())
+
+	backend.Receipt.Ret = ""
+	_, err = c.Transact(&bind.TransactOpts{Key: key}, "transfer", common.HexToAddress("0x02"), big.NewInt(2))
+	assert.Equal(t, "transaction failed: reverted", err.Error())
+}
+
+func TestBoundContract_Logs(t *testing.T) {
+	c, backend, _ := newTestContract(t)
+	event := c.ABI().Events["Transfer"]
+	from, to := common.HexToAddress("0x01"), common.HexToAddress("0x02")
+	data, _ := event.Inputs.NonIndexed().Pack(big.NewInt(7))
+	backend.Logs = []rpc.TxLog{{
+		Address:     c.Address().Hex(),
+		Topics:      []string{event.ID.Hex(), common.BytesToHash(from[:]).Hex(), common.BytesToHash(to[:]).Hex()},
+		Data:        common.Bytes2Hex(data),
+		BlockNumber: 3,
+	}}
+
+	end := uint64(10)
+	logs, err := c.FilterLogs(&bind.FilterOpts{Start: 1, End: &end}, "Transfer", []interface{}{from})
+	assert.Nil(t, err)
+	assert.Len(t, logs, 1)
+	filter := backend.Filters[0]
+	assert.Equal(t, uint64(1), filter.FromBlock)
+	assert.Equal(t, uint64(10), filter.ToBlock)
+	assert.Equal(t, []string{c.Address().Hex()}, filter.Addresses)
+	assert.Equal(t, []common.Hash{event.ID}, filter.Topics[0])
+	assert.Equal(t, []common.Hash{common.BytesToHash(from[:])}, filter.Topics[1])
+	assert.Nil(t, filter.Topics[2])
+
+	var transfer struct {
+		From   common.Address
+		To     common.Address
+		Amount *big.Int
+	}
+	assert.Nil(t, c.UnpackLog(&transfer, "Transfer", logs[0]))
+	assert.Equal(t, from, transfer.From)
+	assert.Equal(t, to, transfer.To)
+	assert.Equal(t, big.NewInt(7), transfer.Amount)
+
+	var delivered []rpc.TxLog
+	_, err = c.WatchLogs(&bind.WatchOpts{Start: 3}, "Transfer", func(log rpc.TxLog) error {
+		delivered = append(delivered, log)
+		return nil
+	}, nil, []interface{}{to})
+	assert.Nil(t, err)
+	assert.Equal(t, backend.Logs, delivered)
+	assert.Nil(t, backend.Filters[1].Topics[1])
+	assert.Equal(t, []common.Hash{common.BytesToHash(to[:])}, backend.Filters[1].Topics[2])
+
+	_, err = c.FilterLogs(nil, "Approval")
+	assert.NotNil(t, err)
+	assert.NotNil(t, c.UnpackLog(&transfer, "Transfer", rpc.TxLog{Topics: []string{common.Hash{}.Hex()}}))
+}
+
+func TestGRPCBackend_Logs(t *testing.T) {
+	backend := bind.NewGRPCBackend(nil, rpc.ClientOption{})
+	_, err := backend.FilterLogs(context.Background(), rpc.NewLogsFilter())
+	assert.Equal(t, bind.ErrLogsNotSupported, err)
+	_, err = backend.WatchLogs(1, 0, rpc.NewLogsFilter(), nil)
+	assert.Equal(t, bind.ErrLogsNotSupported, err)
+	assert.Nil(t, backend.Close())
+	_, err = backend.InvokeContract(nil, rpc.NewTransaction("0x01"), nil)
+	assert.NotNil(t, err)
+}
+
+func TestRPCBackend_Revert(t *testing.T) {
+	c, _, key := newTestContract(t)
+	e := c.ABI().Errors["InsufficientBalance"]
+	args, _ := e.Inputs.Pack(big.NewInt(1), big.NewInt(2))
+	data, _ := json.Marshal(common.ToHex(append(append([]byte{}, e.Selector()...), args...)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpc.JSONRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch req.Method {
+		case "contract_invokeContract":
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"message":"SUCCESS","result":"0x02"}`))
+		default:
+			// the failed transaction is responded as an error with the revert data
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":-32005,"message":"execution reverted","data":` + string(data) + `}`))
+		}
+	}))
+	defer server.Close()
+	host, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	c = newTokenContract(t, bind.NewRPCBackend(rpc.DefaultRPC(rpc.NewNode(host, port, port))))
+
+	receipt, err := c.Transact(&bind.TransactOpts{Key: key}, "transfer", common.HexToAddress("0x02"), big.NewInt(2))
+	assert.NotNil(t, receipt)
+	assert.False(t, receipt.Valid)
+	var custom *insufficientBalance
+	assert.True(t, errors.As(err, &custom))
+	assert.Equal(t, big.NewInt(2), custom.Required)
+	assert.Equal(t, "execution reverted: InsufficientBalance(1, 2)", err.Error())
+}
@@ -0,0 +1,263 @@
+package bind
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/meshplus/gosdk/abi2"
+	"github.com/meshplus/gosdk/common/compiler"
+)
+
+// Bind generates the Go package named pkg of the typed bindings of the contracts, types, abis
+// and bytecodes are the names, the json abis and the hex bytecodes of the contracts in order,
+// the bytecode may be empty if the contract is not deployed by the bindings
+func Bind(types []string, abis []string, bytecodes []string, pkg string) (string, error) {
+	if len(types) != len(abis) || len(bytecodes) != 0 && len(bytecodes) != len(types) {
+		return "", fmt.Errorf("bind: %d types, %d abis and %d bytecodes are given", len(types), len(abis), len(bytecodes))
+	}
+	data := &tmplData{Package: pkg}
+	structs := make(map[string]*tmplStruct)
+	for i, typ := range types {
+		evmABI, err := abi2.JSON(strings.NewReader(abis[i]))
+		if err != nil {
+			return "", fmt.Errorf("bind: abi of %s: %v", typ, err)
+		}
+		compacted := new(bytes.Buffer)
+		if err := json.Compact(compacted, []byte(abis[i])); err != nil {
+			return "", fmt.Errorf("bind: abi of %s: %v", typ, err)
+		}
+		contract := &tmplContract{
+			Type:        capitalise(typ),
+			InputABI:    compacted.String(),
+			Constructor: evmABI.Constructor,
+		}
+		if len(bytecodes) > 0 && bytecodes[i] != "" {
+			contract.InputBin = "0x" + strings.TrimPrefix(strings.TrimSpace(bytecodes[i]), "0x")
+		}
+		if contract.Inputs, err = bindArguments(evmABI.Constructor.Inputs, structs); err != nil {
+			return "", fmt.Errorf("bind: constructor of %s: %v", typ, err)
+		}
+
+		for _, name := range sortedKeys(evmABI.Methods) {
+			original := evmABI.Methods[name]
+			method := &tmplMethod{Original: original, Normalized: capitalise(original.Name), ID: fmt.Sprintf("%x", original.ID)}
+			// the methods of the binding itself
+			if method.Normalized == "Address" || method.Normalized == "Contract" {
+				method.Normalized += "_"
+			}
+			if method.Inputs, err = bindArguments(original.Inputs, structs); err != nil {
+				return "", fmt.Errorf("bind: method %s of %s: %v", name, typ, err)
+			}
+			if method.Outputs, err = bindArguments(original.Outputs, structs); err != nil {
+				return "", fmt.Errorf("bind: method %s of %s: %v", name, typ, err)
+			}
+			if original.IsConstant() {
+				contract.Calls = append(contract.Calls, method)
+			} else {
+				contract.Transacts = append(contract.Transacts, method)
+			}
+		}
+		for _, name := range sortedKeys(evmABI.Events) {
+			original := evmABI.Events[name]
+			event := &tmplEvent{Original: original, Normalized: capitalise(original.Name)}
+			if event.Fields, err = bindFields(original.Inputs, structs, true); err != nil {
+				return "", fmt.Errorf("bind: event %s of %s: %v", name, typ, err)
+			}
+			if event.Inputs, err = bindArguments(original.Inputs, structs); err != nil {
+				return "", fmt.Errorf("bind: event %s of %s: %v", name, typ, err)
+			}
+			contract.Events = append(contract.Events, event)
+		}
+		for _, name := range sortedKeys(evmABI.Errors) {
+			original := evmABI.Errors[name]
+			e := &tmplError{Original: original, Normalized: capitalise(original.Name)}
+			if e.Fields, err = bindFields(original.Inputs, structs, false); err != nil {
+				return "", fmt.Errorf("bind: error %s of %s: %v", name, typ, err)
+			}
+			contract.Errors = append(contract.Errors, e)
+		}
+		data.Contracts = append(data.Contracts, contract)
+	}
+	for _, key := range sortedKeys(structs) {
+		data.Structs = append(data.Structs, structs[key])
+	}
+
+	buffer := new(bytes.Buffer)
+	tmpl := template.Must(template.New("").Parse(tmplSource))
+	if err := tmpl.Execute(buffer, data); err != nil {
+		return "", err
+	}
+	code, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("bind: %v\n%s", err, buffer)
+	}
+	return string(code), nil
+}
+
+// BindContracts generates the bindings of the contracts compiled by compiler.Solidity.Compile,
+// the contracts are bound in the order of the names
+func BindContracts(contracts map[string]*compiler.Contract, pkg string) (string, error) {
+	var types, abis, bytecodes []string
+	for _, name := range sortedKeys(contracts) {
+		contract := contracts[name]
+		abi, err := json.Marshal(contract.Info.AbiDefinition)
+		if err != nil {
+			return "", err
+		}
+		// the names may be prefixed by the source, e.g. <stdin>:Token
+		if i := strings.LastIndex(name, ":"); i >= 0 {
+			name = name[i+1:]
+		}
+		types = append(types, name)
+		abis = append(abis, string(abi))
+		bytecodes = append(bytecodes, contract.Code)
+	}
+	return Bind(types, abis, bytecodes, pkg)
+}
+
+// bindArguments return the go parameters of the arguments, the unnamed ones are named by the position
+func bindArguments(args abi2.Arguments, structs map[string]*tmplStruct) ([]tmplArg, error) {
+	bound := make([]tmplArg, len(args))
+	for i, arg := range args {
+		typ, err := bindType(arg.Type, structs)
+		if err != nil {
+			return nil, err
+		}
+		name := decapitalise(arg.Name)
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		if token.Lookup(name).IsKeyword() || name == "opts" || name == "handler" {
+			name += "_"
+		}
+		bound[i] = tmplArg{Name: name, Type: typ, Indexed: arg.Indexed}
+	}
+	return bound, nil
+}
+
+// bindFields return the struct fields of the arguments named as abi2.Arguments.Copy,
+// the indexed dynamic inputs of the events are the hashes of the values
+func bindFields(args abi2.Arguments, structs map[string]*tmplStruct, event bool) ([]tmplField, error) {
+	fields := make([]tmplField, len(args))
+	for i, arg := range args {
+		typ, err := bindType(arg.Type, structs)
+		if err != nil {
+			return nil, err
+		}
+		if event && arg.Indexed && isDynamicTopic(arg.Type) {
+			typ = "common.Hash"
+		}
+		fields[i] = tmplField{Name: abi2.ToCamelCase(arg.Name), Type: typ}
+	}
+	return fields, nil
+}
+
+// isDynamicTopic report whether the indexed input is stored as the hash of the value
+func isDynamicTopic(t abi2.Type) bool {
+	switch t.T {
+	case abi2.StringTy, abi2.BytesTy, abi2.SliceTy, abi2.ArrayTy, abi2.TupleTy:
+		return true
+	}
+	return false
+}
+
+// bindType return the go type of the abi type, which is the same as the one unpacked by abi2
+func bindType(t abi2.Type, structs map[string]*tmplStruct) (string, error) {
+	switch t.T {
+	case abi2.IntTy, abi2.UintTy:
+		switch t.Size {
+		case 8, 16, 32, 64:
+			if t.T == abi2.UintTy {
+				return fmt.Sprintf("uint%d", t.Size), nil
+			}
+			return fmt.Sprintf("int%d", t.Size), nil
+		}
+		return "*big.Int", nil
+	case abi2.BoolTy:
+		return "bool", nil
+	case abi2.StringTy:
+		return "string", nil
+	case abi2.AddressTy:
+		return "common.Address", nil
+	case abi2.BytesTy:
+		return "[]byte", nil
+	case abi2.FixedBytesTy:
+		return fmt.Sprintf("[%d]byte", t.Size), nil
+	case abi2.HashTy:
+		return "common.Hash", nil
+	case abi2.FunctionTy:
+		return "[24]byte", nil
+	case abi2.SliceTy:
+		elem, err := bindType(*t.Elem, structs)
+		return "[]" + elem, err
+	case abi2.ArrayTy:
+		elem, err := bindType(*t.Elem, structs)
+		return fmt.Sprintf("[%d]", t.Size) + elem, err
+	case abi2.TupleTy:
+		return bindStruct(t, structs)
+	}
+	return "", fmt.Errorf("unsupported abi type %s", t)
+}
+
+// bindStruct return the name of the struct of the tuple, the structs of the same tuple are generated once
+func bindStruct(t abi2.Type, structs map[string]*tmplStruct) (string, error) {
+	id := t.String()
+	if s, ok := structs[id]; ok {
+		return s.Name, nil
+	}
+	name := t.TupleRawName
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	if name == "" {
+		name = fmt.Sprintf("Struct%d", len(structs))
+	}
+	name = capitalise(name)
+	s := &tmplStruct{Name: name}
+	// reserve the name before binding the fields
+	structs[id] = s
+	for i, elem := range t.TupleElems {
+		typ, err := bindType(*elem, structs)
+		if err != nil {
+			return "", err
+		}
+		s.Fields = append(s.Fields, tmplField{Name: t.TupleType.Field(i).Name, Type: typ})
+	}
+	return name, nil
+}
+
+// capitalise make the name an exported go identifier
+func capitalise(name string) string {
+	name = abi2.ToCamelCase(strings.TrimLeft(name, "_"))
+	if name == "" {
+		return name
+	}
+	return string(unicode.ToUpper(rune(name[0]))) + name[1:]
+}
+
+// decapitalise make the name an unexported go identifier
+func decapitalise(name string) string {
+	if name == "" {
+		return name
+	}
+	return string(unicode.ToLower(rune(name[0]))) + name[1:]
+}
+
+// sortedKeys return the sorted keys of the map keyed by strings
+func sortedKeys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
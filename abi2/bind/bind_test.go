@@ -0,0 +1,65 @@
+package bind_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/meshplus/gosdk/abi2/bind"
+	"github.com/meshplus/gosdk/abi2/bind/bindtest"
+	"github.com/meshplus/gosdk/common/compiler"
+	"github.com/stretchr/testify/assert"
+)
+
+const structABI = `[
+	{"type":"function","name":"getOrder","stateMutability":"view","inputs":[{"name":"id","type":"uint64"}],"outputs":[{"components":[{"name":"id","type":"uint64"},{"name":"items","type":"tuple[]","internalType":"struct Shop.Item[]","components":[{"name":"sku","type":"bytes32"},{"name":"price","type":"uint256"}]},{"name":"buyer","type":"address"}],"name":"","type":"tuple","internalType":"struct Shop.Order"},{"name":"paid","type":"bool"}]},
+	{"type":"function","name":"place","stateMutability":"nonpayable","inputs":[{"name":"items","type":"tuple[]","internalType":"struct Shop.Item[]","components":[{"name":"sku","type":"bytes32"},{"name":"price","type":"uint256"}]},{"name":"type","type":"string"}],"outputs":[]},
+	{"type":"function","name":"address","stateMutability":"pure","inputs":[],"outputs":[{"name":"","type":"address"}]},
+	{"type":"event","name":"Placed","inputs":[{"name":"buyer","type":"address","indexed":true},{"name":"note","type":"string","indexed":true},{"name":"","type":"uint64","indexed":false}]}
+]`
+
+func TestBind(t *testing.T) {
+	code, err := bind.Bind([]string{"Token", "Shop"}, []string{tokenABI, structABI}, []string{"0x6080", ""}, "contracts")
+	assert.Nil(t, err)
+
+	for _, want := range []string{
+		"func DeployToken(opts *bind.TransactOpts, backend bind.ContractBackend, supply *big.Int) (common.Address, *rpc.TxReceipt, *Token, error) {",
+		"func (_Token *Token) BalanceOf(opts *bind.CallOpts, owner common.Address) (*big.Int, error) {",
+		"func (_Token *Token) Transfer(opts *bind.TransactOpts, to common.Address, amount *big.Int) (*rpc.TxReceipt, error) {",
+		"func (_Token *Token) FilterTransfer(opts *bind.FilterOpts, from []common.Address, to []common.Address) ([]*TokenTransfer, error) {",
+		"func (_Token *Token) WatchTransfer(opts *bind.WatchOpts, handler func(*TokenTransfer) error, from []common.Address, to []common.Address) (bind.Subscription, error) {",
+		"func (_Token *Token) ParseTransfer(log rpc.TxLog) (*TokenTransfer, error) {",
+		"type TokenInsufficientBalance struct {",
+		`contract.RegisterError("InsufficientBalance", func() error { return new(TokenInsufficientBalance) })`,
+		"type ShopOrder struct {",
+		"Items []ShopItem",
+		"func (_Shop *Shop) GetOrder(opts *bind.CallOpts, id uint64) (ShopOrder, bool, error) {",
+		"func (_Shop *Shop) Place(opts *bind.TransactOpts, items []ShopItem, type_ string) (*rpc.TxReceipt, error) {",
+		"func (_Shop *Shop) Address_(opts *bind.CallOpts) (common.Address, error) {",
+		"Note  common.Hash",
+	} {
+		assert.Contains(t, code, want)
+	}
+	assert.NotContains(t, code, "func DeployShop")
+
+	_, err = bind.Bind([]string{"Token"}, []string{tokenABI, structABI}, nil, "contracts")
+	assert.NotNil(t, err)
+	_, err = bind.Bind([]string{"Token"}, []string{"["}, nil, "contracts")
+	assert.NotNil(t, err)
+}
+
+// TestBindBuild builds the generated package with the go tool
+func TestBindBuild(t *testing.T) {
+	code, err := bind.BindContracts(map[string]*compiler.Contract{
+		"<stdin>:Token": {Code: "0x6080", Info: compiler.ContractInfo{AbiDefinition: rawJSON(t, tokenABI)}},
+		"<stdin>:Shop":  {Code: "0x6080", Info: compiler.ContractInfo{AbiDefinition: rawJSON(t, structABI)}},
+	}, "contracts")
+	assert.Nil(t, err)
+
+	bindtest.Build(t, code)
+}
+
+func rawJSON(t *testing.T, s string) interface{} {
+	var v interface{}
+	assert.Nil(t, json.Unmarshal([]byte(s), &v))
+	return v
+}
@@ -0,0 +1,83 @@
+// Package bindtest is the test helpers shared by the bindings of the solidity and the hvm contracts
+package bindtest
+
+import (
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/meshplus/gosdk/abi2/bind"
+	"github.com/meshplus/gosdk/rpc"
+)
+
+// Backend is a bind.ContractBackend which records the transactions and the filters,
+// every transaction returns Receipt and every filter returns Logs
+type Backend struct {
+	Txs     []*rpc.Transaction
+	Receipt *rpc.TxReceipt
+	Filters []*rpc.LogsFilter
+	Logs    []rpc.TxLog
+}
+
+// DeployContract implements bind.ContractBackend
+func (b *Backend) DeployContract(ctx context.Context, tx *rpc.Transaction, key interface{}) (*rpc.TxReceipt, error) {
+	b.Txs = append(b.Txs, tx)
+	return b.Receipt, nil
+}
+
+// InvokeContract implements bind.ContractBackend
+func (b *Backend) InvokeContract(ctx context.Context, tx *rpc.Transaction, key interface{}) (*rpc.TxReceipt, error) {
+	b.Txs = append(b.Txs, tx)
+	return b.Receipt, nil
+}
+
+// FilterLogs implements bind.ContractBackend
+func (b *Backend) FilterLogs(ctx context.Context, filter *rpc.LogsFilter) ([]rpc.TxLog, error) {
+	b.Filters = append(b.Filters, filter)
+	return b.Logs, nil
+}
+
+// WatchLogs implements bind.ContractBackend, Logs are delivered to handler before it returns
+func (b *Backend) WatchLogs(nodeIndex int, from uint64, filter *rpc.LogsFilter, handler func(blockNumber uint64, logs []rpc.TxLog) error) (bind.Subscription, error) {
+	b.Filters = append(b.Filters, filter)
+	return nil, handler(from, b.Logs)
+}
+
+// Build vet the generated bindings as a package of a temporary module, which requires this module by replace
+func Build(t *testing.T, code string) {
+	t.Helper()
+	gocmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+	out, err := exec.Command(gocmd, "env", "GOMOD").Output()
+	gomod := strings.TrimSpace(string(out))
+	if err != nil || gomod == "" || gomod == "/dev/null" {
+		t.Skip("the module of gosdk not found")
+	}
+	root := filepath.Dir(gomod)
+	sum, err := ioutil.ReadFile(filepath.Join(root, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	mod := "module bindtest\n\ngo 1.13\n\nrequire github.com/meshplus/gosdk v0.0.0\n\nreplace github.com/meshplus/gosdk => " + root + "\n"
+	for name, content := range map[string][]byte{
+		"go.mod":       []byte(mod),
+		"go.sum":       sum,
+		"contracts.go": []byte(code),
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command(gocmd, "vet", "./...")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build the bindings: %v\n%s\n%s", err, out, code)
+	}
+}
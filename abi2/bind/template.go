@@ -0,0 +1,264 @@
+package bind
+
+import "github.com/meshplus/gosdk/abi2"
+
+// tmplData is the data structure required to fill the binding template
+type tmplData struct {
+	Package   string
+	Contracts []*tmplContract
+	Structs   []*tmplStruct
+}
+
+// tmplContract contains the data needed to generate an individual contract binding
+type tmplContract struct {
+	Type        string
+	InputABI    string
+	InputBin    string
+	Constructor abi2.Method
+	Inputs      []tmplArg
+	Calls       []*tmplMethod
+	Transacts   []*tmplMethod
+	Events      []*tmplEvent
+	Errors      []*tmplError
+}
+
+// tmplMethod is a wrapper around an abi2.Method that contains a few preprocessed
+// and cached data fields
+type tmplMethod struct {
+	Original   abi2.Method
+	Normalized string
+	ID         string
+	Inputs     []tmplArg
+	Outputs    []tmplArg
+}
+
+// tmplEvent is a wrapper around an abi2.Event that contains a few preprocessed
+// and cached data fields
+type tmplEvent struct {
+	Original   abi2.Event
+	Normalized string
+	Inputs     []tmplArg
+	Fields     []tmplField
+}
+
+// tmplError is a wrapper around an abi2.Error that contains a few preprocessed
+// and cached data fields
+type tmplError struct {
+	Original   abi2.Error
+	Normalized string
+	Fields     []tmplField
+}
+
+// tmplArg is a go parameter of the inputs and outputs
+type tmplArg struct {
+	Name    string
+	Type    string
+	Indexed bool
+}
+
+// tmplField is a field of the generated structs
+type tmplField struct {
+	Name string
+	Type string
+}
+
+// tmplStruct is the go struct of a solidity tuple
+type tmplStruct struct {
+	Name   string
+	Fields []tmplField
+}
+
+// tmplSource is the Go source template that the generated Go contract binding is based on
+const tmplSource = `// Code generated by abigen - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package {{.Package}}
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/meshplus/gosdk/abi2"
+	"github.com/meshplus/gosdk/abi2/bind"
+	"github.com/meshplus/gosdk/common"
+	"github.com/meshplus/gosdk/rpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = errors.New
+	_ = fmt.Sprintf
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = abi2.ConvertType
+	_ = bind.NewBoundContract
+	_ = common.HexToAddress
+	_ = rpc.NewTransaction
+)
+{{range $struct := .Structs}}
+// {{.Name}} is an auto generated low-level Go binding around an user-defined struct.
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+{{end}}
+{{- range $contract := .Contracts}}
+// {{.Type}}ABI is the input ABI used to generate the binding from.
+const {{.Type}}ABI = {{printf "%q" .InputABI}}
+{{if .InputBin}}
+// {{.Type}}Bin is the compiled bytecode used for deploying new contracts.
+const {{.Type}}Bin = {{printf "%q" .InputBin}}
+
+// Deploy{{.Type}} deploys a new contract, binding an instance of {{.Type}} to it.
+func Deploy{{.Type}}(opts *bind.TransactOpts, backend bind.ContractBackend{{range .Inputs}}, {{.Name}} {{.Type}}{{end}}) (common.Address, *rpc.TxReceipt, *{{.Type}}, error) {
+	contract, err := new{{.Type}}(common.Address{}, backend)
+	if err != nil {
+		return common.Address{}, nil, nil, err
+	}
+	receipt, err := contract.contract.Deploy(opts, common.FromHex({{.Type}}Bin){{range .Inputs}}, {{.Name}}{{end}})
+	if err != nil {
+		return common.Address{}, receipt, nil, err
+	}
+	return contract.Address(), receipt, contract, nil
+}
+{{end}}
+// {{.Type}} is an auto generated Go binding around a contract.
+type {{.Type}} struct {
+	contract *bind.BoundContract
+}
+
+// New{{.Type}} creates a new instance of {{.Type}}, bound to a specific deployed contract.
+func New{{.Type}}(address common.Address, backend bind.ContractBackend) (*{{.Type}}, error) {
+	return new{{.Type}}(address, backend)
+}
+
+func new{{.Type}}(address common.Address, backend bind.ContractBackend) (*{{.Type}}, error) {
+	parsed, err := abi2.JSON(strings.NewReader({{.Type}}ABI))
+	if err != nil {
+		return nil, err
+	}
+	contract := bind.NewBoundContract(address, parsed, backend)
+	{{- range .Errors}}
+	contract.RegisterError("{{.Original.Name}}", func() error { return new({{$contract.Type}}{{.Normalized}}) })
+	{{- end}}
+	return &{{.Type}}{contract: contract}, nil
+}
+
+// Address returns the address of the contract.
+func (_{{$contract.Type}} *{{$contract.Type}}) Address() common.Address {
+	return _{{$contract.Type}}.contract.Address()
+}
+
+// Contract returns the generic binding of the contract.
+func (_{{$contract.Type}} *{{$contract.Type}}) Contract() *bind.BoundContract {
+	return _{{$contract.Type}}.contract
+}
+{{range .Calls}}
+// {{.Normalized}} is a free data retrieval call binding the contract method 0x{{.ID}}.
+//
+// Solidity: {{.Original.String}}
+func (_{{$contract.Type}} *{{$contract.Type}}) {{.Normalized}}(opts *bind.CallOpts{{range .Inputs}}, {{.Name}} {{.Type}}{{end}}) ({{range .Outputs}}{{.Type}}, {{end}}error) {
+	{{if .Outputs}}out{{else}}_{{end}}, err := _{{$contract.Type}}.contract.Call(opts, "{{.Original.Name}}"{{range .Inputs}}, {{.Name}}{{end}})
+	if err != nil {
+		return {{range .Outputs}}*new({{.Type}}), {{end}}err
+	}
+	{{- range $i, $out := .Outputs}}
+	out{{$i}} := *abi2.ConvertType(out[{{$i}}], new({{$out.Type}})).(*{{$out.Type}})
+	{{- end}}
+	return {{range $i, $out := .Outputs}}out{{$i}}, {{end}}nil
+}
+{{end}}
+{{- range .Transacts}}
+// {{.Normalized}} is a paid mutator transaction binding the contract method 0x{{.ID}}.
+//
+// Solidity: {{.Original.String}}
+func (_{{$contract.Type}} *{{$contract.Type}}) {{.Normalized}}(opts *bind.TransactOpts{{range .Inputs}}, {{.Name}} {{.Type}}{{end}}) (*rpc.TxReceipt, error) {
+	return _{{$contract.Type}}.contract.Transact(opts, "{{.Original.Name}}"{{range .Inputs}}, {{.Name}}{{end}})
+}
+{{end}}
+{{- range .Events}}
+// {{$contract.Type}}{{.Normalized}} represents a {{.Original.Name}} event raised by the {{$contract.Type}} contract.
+type {{$contract.Type}}{{.Normalized}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+	Raw rpc.TxLog // Blockchain specific contextual infos
+}
+
+// Filter{{.Normalized}} is a free log retrieval operation binding the contract event 0x{{printf "%x" .Original.ID}}.
+//
+// Solidity: {{.Original.String}}
+func (_{{$contract.Type}} *{{$contract.Type}}) Filter{{.Normalized}}(opts *bind.FilterOpts{{range .Inputs}}{{if .Indexed}}, {{.Name}} []{{.Type}}{{end}}{{end}}) ([]*{{$contract.Type}}{{.Normalized}}, error) {
+	{{- range .Inputs}}{{if .Indexed}}
+	var {{.Name}}Rule []interface{}
+	for _, {{.Name}}Item := range {{.Name}} {
+		{{.Name}}Rule = append({{.Name}}Rule, {{.Name}}Item)
+	}
+	{{- end}}{{end}}
+	logs, err := _{{$contract.Type}}.contract.FilterLogs(opts, "{{.Original.Name}}"{{range .Inputs}}{{if .Indexed}}, {{.Name}}Rule{{end}}{{end}})
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*{{$contract.Type}}{{.Normalized}}, 0, len(logs))
+	for _, log := range logs {
+		event, err := _{{$contract.Type}}.Parse{{.Normalized}}(log)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// Watch{{.Normalized}} is a free log subscription operation binding the contract event 0x{{printf "%x" .Original.ID}}.
+//
+// Solidity: {{.Original.String}}
+func (_{{$contract.Type}} *{{$contract.Type}}) Watch{{.Normalized}}(opts *bind.WatchOpts, handler func(*{{$contract.Type}}{{.Normalized}}) error{{range .Inputs}}{{if .Indexed}}, {{.Name}} []{{.Type}}{{end}}{{end}}) (bind.Subscription, error) {
+	{{- range .Inputs}}{{if .Indexed}}
+	var {{.Name}}Rule []interface{}
+	for _, {{.Name}}Item := range {{.Name}} {
+		{{.Name}}Rule = append({{.Name}}Rule, {{.Name}}Item)
+	}
+	{{- end}}{{end}}
+	return _{{$contract.Type}}.contract.WatchLogs(opts, "{{.Original.Name}}", func(log rpc.TxLog) error {
+		event, err := _{{$contract.Type}}.Parse{{.Normalized}}(log)
+		if err != nil {
+			return err
+		}
+		return handler(event)
+	}{{range .Inputs}}{{if .Indexed}}, {{.Name}}Rule{{end}}{{end}})
+}
+
+// Parse{{.Normalized}} is a log parse operation binding the contract event 0x{{printf "%x" .Original.ID}}.
+//
+// Solidity: {{.Original.String}}
+func (_{{$contract.Type}} *{{$contract.Type}}) Parse{{.Normalized}}(log rpc.TxLog) (*{{$contract.Type}}{{.Normalized}}, error) {
+	event := new({{$contract.Type}}{{.Normalized}})
+	if err := _{{$contract.Type}}.contract.UnpackLog(event, "{{.Original.Name}}", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+{{end}}
+{{- range .Errors}}
+// {{$contract.Type}}{{.Normalized}} represents a {{.Original.Name}} custom error of the {{$contract.Type}} contract,
+// which is unwrapped from the bind.RevertError.
+//
+// Solidity: {{.Original.String}}
+type {{$contract.Type}}{{.Normalized}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+
+// Error implements the error interface.
+func (e *{{$contract.Type}}{{.Normalized}}) Error() string {
+	return fmt.Sprintf("{{.Original.Name}}({{range $i, $f := .Fields}}{{if $i}}, {{end}}%v{{end}})"{{range .Fields}}, e.{{.Name}}{{end}})
+}
+{{end}}
+{{- end}}
+`
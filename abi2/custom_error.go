@@ -0,0 +1,89 @@
+package abi2
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/meshplus/crypto-standard/hash"
+	"github.com/meshplus/gosdk/common"
+)
+
+// Error is a custom error of solidity v0.8.4, which is reverted with the abi-encoded
+// arguments prefixed by the 4 bytes selector like a function call.
+type Error struct {
+	Name   string
+	Inputs Arguments
+	str    string
+	// Sig contains the string signature according to the ABI spec.
+	// e.g.	 error foo(uint32 a, int b) = "foo(uint32,int256)"
+	Sig string
+	// ID returns the canonical representation of the error's signature used by the
+	// abi definition to identify the error, the first 4 bytes are the selector.
+	ID common.Hash
+}
+
+// NewError creates a new Error, it names the unnamed inputs as NewEvent.
+func NewError(name string, inputs Arguments) Error {
+	names := make([]string, len(inputs))
+	types := make([]string, len(inputs))
+	for i, input := range inputs {
+		if input.Name == "" {
+			inputs[i] = Argument{
+				Name: fmt.Sprintf("arg%d", i),
+				Type: input.Type,
+			}
+		} else {
+			inputs[i] = input
+		}
+		names[i] = fmt.Sprintf("%v %v", input.Type, inputs[i].Name)
+		types[i] = input.Type.String()
+	}
+
+	str := fmt.Sprintf("error %v(%v)", name, strings.Join(names, ", "))
+	sig := fmt.Sprintf("%v(%v)", name, strings.Join(types, ","))
+	hashResult, _ := hash.NewHasher(hash.KECCAK_256).Hash([]byte(sig))
+	return Error{
+		Name:   name,
+		Inputs: inputs,
+		str:    str,
+		Sig:    sig,
+		ID:     common.BytesToHash(hashResult),
+	}
+}
+
+func (e Error) String() string {
+	return e.str
+}
+
+// Selector returns the first 4 bytes of the ID which prefix the revert data.
+func (e Error) Selector() []byte {
+	return e.ID[:4]
+}
+
+// Unpack unpacks the revert data of the error into v, which is a pointer to a struct
+// with the fields named by the inputs like the events.
+func (e Error) Unpack(v interface{}, data []byte) error {
+	if len(data) < 4 || !bytes.Equal(data[:4], e.Selector()) {
+		return errors.New("abi: revert data is not of the error " + e.Name)
+	}
+	values, err := e.Inputs.Unpack(data[4:])
+	if err != nil {
+		return err
+	}
+	return e.Inputs.Copy(v, values)
+}
+
+// ErrorByID looks up an error by the 4 bytes selector of the revert data.
+func (abi *ABI) ErrorByID(sigdata []byte) (*Error, error) {
+	if len(sigdata) < 4 {
+		return nil, fmt.Errorf("data too short (%d bytes) for abi error lookup", len(sigdata))
+	}
+	for _, e := range abi.Errors {
+		if bytes.Equal(e.Selector(), sigdata[:4]) {
+			return &e, nil
+		}
+	}
+	return nil, fmt.Errorf("no error with id: %#x", sigdata[:4])
+}
@@ -598,6 +598,26 @@ func decryptPriv(encrypted, algo, password string) (priv []byte, err error) {
 	return priv, nil
 }
 
+// encryptPriv encrypt the private key by algo, the inverse of decryptPriv, only raw, aes and sm4 are supported
+func encryptPriv(priv []byte, algo, password string) ([]byte, error) {
+	switch algo {
+	case ECRAW, ECRAWR1, SMRAW, ED25519RAW:
+		return priv, nil
+	case ECAES, ECAESR1, SMAES, ED25519AES:
+		aes := new(inter.AES)
+		reader := bytes.NewReader(AtPadding([]byte(password), 32)[:16])
+		encrypted, err := aes.Encrypt(AtPadding([]byte(password), 32), priv, reader)
+		if err != nil {
+			return nil, err
+		}
+		return encrypted[16:], nil
+	case SMSM4:
+		return gm.Sm4EncryptCBC(AtPadding([]byte(password), 16), priv, rand.Reader)
+	default:
+		return nil, errors.New("not support crypt type " + algo)
+	}
+}
+
 // NewAccount create account using ecdsa
 // if password is empty, the encrypted field will be private key.
 // if want to create did account , use NewAccountDID instead.
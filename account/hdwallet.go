@@ -0,0 +1,358 @@
+package account
+
+import (
+	stded25519 "crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	gm "github.com/meshplus/crypto-gm"
+	"github.com/meshplus/crypto-standard/asym/secp256k1"
+	"github.com/meshplus/gosdk/common"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// HardenedOffset is the first hardened child index
+const HardenedOffset uint32 = 0x80000000
+
+var (
+	// ErrInvalidMnemonic the mnemonic has unknown words, wrong length or wrong checksum
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+	wordIndex = func() map[string]int {
+		m := make(map[string]int, len(englishWordlist))
+		for i, w := range englishWordlist {
+			m[w] = i
+		}
+		return m
+	}()
+)
+
+// NewMnemonic generate a bip39 mnemonic of bits entropy, bits is 128, 160, 192, 224 or 256
+func NewMnemonic(bits int) (string, error) {
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return "", fmt.Errorf("invalid entropy bits %d", bits)
+	}
+	entropy := make([]byte, bits/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
+	}
+	return NewMnemonicFromEntropy(entropy)
+}
+
+// NewMnemonicFromEntropy encode the entropy as bip39 mnemonic
+func NewMnemonicFromEntropy(entropy []byte) (string, error) {
+	bits := len(entropy) * 8
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return "", fmt.Errorf("invalid entropy bits %d", bits)
+	}
+	checksum := sha256.Sum256(entropy)
+	// append the first bits/32 bits of the checksum
+	data := new(big.Int).SetBytes(entropy)
+	data.Lsh(data, uint(bits/32))
+	data.Or(data, big.NewInt(int64(checksum[0]>>(8-uint(bits/32)))))
+
+	n := (bits + bits/32) / 11
+	words := make([]string, n)
+	mask := big.NewInt(2047)
+	for i := n - 1; i >= 0; i-- {
+		words[i] = englishWordlist[new(big.Int).And(data, mask).Int64()]
+		data.Rsh(data, 11)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy decode the bip39 mnemonic and check the checksum
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, ErrInvalidMnemonic
+	}
+	data := new(big.Int)
+	for _, w := range words {
+		i, ok := wordIndex[w]
+		if !ok {
+			return nil, ErrInvalidMnemonic
+		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(i)))
+	}
+	csBits := len(words) / 3
+	checksum := new(big.Int).And(data, big.NewInt(int64(1<<uint(csBits)-1))).Int64()
+	data.Rsh(data, uint(csBits))
+
+	entropy := common.LeftPadBytes(data.Bytes(), csBits*4)
+	expected := sha256.Sum256(entropy)
+	if int64(expected[0]>>(8-uint(csBits))) != checksum {
+		return nil, ErrInvalidMnemonic
+	}
+	return entropy, nil
+}
+
+// ValidateMnemonic check the words and the checksum of the bip39 mnemonic
+func ValidateMnemonic(mnemonic string) error {
+	_, err := MnemonicToEntropy(mnemonic)
+	return err
+}
+
+// NewSeed derive the bip39 seed of the mnemonic and passphrase
+func NewSeed(mnemonic, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	mnemonic = norm.NFKD.String(strings.Join(strings.Fields(mnemonic), " "))
+	salt := norm.NFKD.String("mnemonic" + passphrase)
+	return pbkdf2.Key([]byte(mnemonic), []byte(salt), 2048, 64, sha512.New), nil
+}
+
+// DerivationPath is a bip32 path, the hardened indexes are offset by HardenedOffset
+type DerivationPath []uint32
+
+// ParseDerivationPath parse the path such as m/44'/60'/0'/0/0, h and H mark hardened indexes as well
+func ParseDerivationPath(path string) (DerivationPath, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, fmt.Errorf("derivation path %s should start with m", path)
+	}
+	result := make(DerivationPath, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		var offset uint32
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") || strings.HasSuffix(part, "H") {
+			offset = HardenedOffset
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(index) >= HardenedOffset {
+			return nil, fmt.Errorf("invalid index %s of derivation path %s", part, path)
+		}
+		result = append(result, uint32(index)+offset)
+	}
+	return result, nil
+}
+
+// String return the path in the m/44'/60'/0'/0/0 form
+func (p DerivationPath) String() string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, index := range p {
+		b.WriteString("/")
+		if index >= HardenedOffset {
+			b.WriteString(strconv.FormatUint(uint64(index-HardenedOffset), 10) + "'")
+		} else {
+			b.WriteString(strconv.FormatUint(uint64(index), 10))
+		}
+	}
+	return b.String()
+}
+
+// HDPath return the bip44 path of the index-th account of the key type, m/44'/60'/0'/0/index,
+// every level of ed25519 is hardened since slip10 ed25519 only supports hardened derivation
+func HDPath(keyType KeyType, index uint32) string {
+	if keyType == KeyTypeED25519 {
+		return fmt.Sprintf("m/44'/60'/0'/0'/%d'", index)
+	}
+	return fmt.Sprintf("m/44'/60'/0'/0/%d", index)
+}
+
+// hdCurve derive the keys by slip10, curve is nil for ed25519
+type hdCurve struct {
+	seedKey []byte
+	curve   elliptic.Curve
+}
+
+var (
+	hdSecp256k1 = &hdCurve{seedKey: []byte("Bitcoin seed"), curve: secp256k1.S256()}
+	hdNist256p1 = &hdCurve{seedKey: []byte("Nist256p1 seed"), curve: elliptic.P256()}
+	// slip10 doesn't define sm2, it's derived the same as the other weierstrass curves with its own seed key
+	hdSM2     = &hdCurve{seedKey: []byte("SM2 seed"), curve: gm.GetSm2Curve()}
+	hdEd25519 = &hdCurve{seedKey: []byte("ed25519 seed")}
+)
+
+func (c *hdCurve) derive(seed []byte, path DerivationPath) ([]byte, error) {
+	key, chain := c.master(seed)
+	for _, index := range path {
+		var err error
+		if key, chain, err = c.child(key, chain, index); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+func (c *hdCurve) master(seed []byte) ([]byte, []byte) {
+	I := hmacSHA512(c.seedKey, seed)
+	for c.curve != nil && !c.validKey(I[:32]) {
+		I = hmacSHA512(c.seedKey, I)
+	}
+	return I[:32], I[32:]
+}
+
+func (c *hdCurve) child(key, chain []byte, index uint32) ([]byte, []byte, error) {
+	var data []byte
+	if index >= HardenedOffset {
+		data = append([]byte{0x00}, key...)
+	} else if c.curve == nil {
+		return nil, nil, errors.New("ed25519 only supports hardened derivation")
+	} else {
+		data = c.compressedPublic(key)
+	}
+	data = append(data, ser32(index)...)
+
+	I := hmacSHA512(chain, data)
+	if c.curve == nil {
+		return I[:32], I[32:], nil
+	}
+	n := c.curve.Params().N
+	for {
+		IL := new(big.Int).SetBytes(I[:32])
+		child := new(big.Int).Add(IL, new(big.Int).SetBytes(key))
+		child.Mod(child, n)
+		if IL.Cmp(n) < 0 && child.Sign() != 0 {
+			return common.LeftPadBytes(child.Bytes(), 32), I[32:], nil
+		}
+		I = hmacSHA512(chain, append(append([]byte{0x01}, I[32:]...), ser32(index)...))
+	}
+}
+
+func (c *hdCurve) validKey(key []byte) bool {
+	k := new(big.Int).SetBytes(key)
+	return k.Sign() != 0 && k.Cmp(c.curve.Params().N) < 0
+}
+
+func (c *hdCurve) compressedPublic(key []byte) []byte {
+	x, y := c.curve.ScalarBaseMult(key)
+	return append([]byte{0x02 + byte(y.Bit(0))}, common.LeftPadBytes(x.Bytes(), 32)...)
+}
+
+func hmacSHA512(key, data []byte) []byte {
+	h := hmac.New(sha512.New, key)
+	_, _ = h.Write(data)
+	return h.Sum(nil)
+}
+
+func ser32(i uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, i)
+	return b
+}
+
+// HDWallet derive the ecdsa, sm2 and ed25519 keys from one bip39 seed, secp256k1 follows bip32 while
+// r1 and ed25519 follow slip10
+type HDWallet struct {
+	seed []byte
+}
+
+// NewHDWallet create the wallet of the mnemonic and passphrase
+func NewHDWallet(mnemonic, passphrase string) (*HDWallet, error) {
+	seed, err := NewSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewHDWalletFromSeed(seed)
+}
+
+// NewHDWalletFromSeed create the wallet of the seed
+func NewHDWalletFromSeed(seed []byte) (*HDWallet, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("invalid seed length %d", len(seed))
+	}
+	return &HDWallet{seed: seed}, nil
+}
+
+// DeriveECDSA derive the secp256k1 key of the path
+func (w *HDWallet) DeriveECDSA(path string) (*ECDSAKey, error) {
+	priv, err := w.derive(hdSecp256k1, path)
+	if err != nil {
+		return nil, err
+	}
+	return NewAccountFromPriv(common.Bytes2Hex(priv))
+}
+
+// DeriveR1 derive the secp256r1 key of the path
+func (w *HDWallet) DeriveR1(path string) (*ECDSAKey, error) {
+	priv, err := w.derive(hdNist256p1, path)
+	if err != nil {
+		return nil, err
+	}
+	return NewAccountR1FromPriv(common.Bytes2Hex(priv))
+}
+
+// DeriveSM2 derive the sm2 key of the path
+func (w *HDWallet) DeriveSM2(path string) (*SM2Key, error) {
+	priv, err := w.derive(hdSM2, path)
+	if err != nil {
+		return nil, err
+	}
+	return NewAccountSm2FromPriv(common.Bytes2Hex(priv))
+}
+
+// DeriveED25519 derive the ed25519 key of the path, every index of the path should be hardened
+func (w *HDWallet) DeriveED25519(path string) (*ED25519Key, error) {
+	priv, err := w.derive(hdEd25519, path)
+	if err != nil {
+		return nil, err
+	}
+	return newAccountED25519FromPriv(common.Bytes2Hex(stded25519.NewKeyFromSeed(priv)))
+}
+
+// DeriveKey derive the key of the type and path
+func (w *HDWallet) DeriveKey(keyType KeyType, path string) (Key, error) {
+	switch keyType {
+	case KeyTypeECDSA:
+		return w.DeriveECDSA(path)
+	case KeyTypeECDSAR1:
+		return w.DeriveR1(path)
+	case KeyTypeSM2:
+		return w.DeriveSM2(path)
+	case KeyTypeED25519:
+		return w.DeriveED25519(path)
+	default:
+		return nil, fmt.Errorf("can't derive key of type %s", keyType)
+	}
+}
+
+// DeriveAccountJson derive the key of the type and path and return its account json, which is
+// encrypted by aes (sm4 for sm2) if password is not empty
+func (w *HDWallet) DeriveAccountJson(keyType KeyType, path, password string) (string, error) {
+	key, err := w.DeriveKey(keyType, path)
+	if err != nil {
+		return "", err
+	}
+	acType, err := exportAlgo(keyType, password != "")
+	if err != nil {
+		return "", err
+	}
+	priv, err := key.PrivateBytes()
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := encryptPriv(priv, acType, password)
+	if err != nil {
+		return "", err
+	}
+	ac := &accountJSON{Algo: acType, Version: V4, PrivateKey: common.Bytes2Hex(encrypted)}
+	ac.Address, ac.PublicKey = getAddressAndPublic(key)
+	jsonBytes, err := json.Marshal(ac)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
+
+func (w *HDWallet) derive(c *hdCurve, path string) ([]byte, error) {
+	p, err := ParseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return c.derive(w.seed, p)
+}
@@ -0,0 +1,116 @@
+package account
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/meshplus/gosdk/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMnemonic(t *testing.T) {
+	vectors := []struct {
+		entropy  string
+		mnemonic string
+	}{
+		{"00000000000000000000000000000000", "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"},
+		{"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f", "legal winner thank year wave sausage worth useful legal winner thank yellow"},
+		{"80808080808080808080808080808080", "letter advice cage absurd amount doctor acoustic avoid letter advice cage above"},
+		{"ffffffffffffffffffffffffffffffff", "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong"},
+		{"0000000000000000000000000000000000000000000000000000000000000000", strings.Repeat("abandon ", 23) + "art"},
+	}
+	for _, v := range vectors {
+		mnemonic, err := NewMnemonicFromEntropy(common.Hex2Bytes(v.entropy))
+		assert.Nil(t, err)
+		assert.Equal(t, v.mnemonic, mnemonic)
+		entropy, err := MnemonicToEntropy(mnemonic)
+		assert.Nil(t, err)
+		assert.Equal(t, v.entropy, common.Bytes2Hex(entropy))
+	}
+
+	mnemonic, err := NewMnemonic(256)
+	assert.Nil(t, err)
+	assert.Len(t, strings.Fields(mnemonic), 24)
+	assert.Nil(t, ValidateMnemonic(mnemonic))
+
+	assert.Equal(t, ErrInvalidMnemonic, ValidateMnemonic(strings.Repeat("abandon ", 12)))
+	assert.Equal(t, ErrInvalidMnemonic, ValidateMnemonic("abandon abandon"))
+	_, err = NewMnemonic(100)
+	assert.NotNil(t, err)
+}
+
+func TestNewSeed(t *testing.T) {
+	seed, err := NewSeed(strings.Repeat("abandon ", 11)+"about", "TREZOR")
+	assert.Nil(t, err)
+	assert.Equal(t, "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04", common.Bytes2Hex(seed))
+}
+
+func TestParseDerivationPath(t *testing.T) {
+	p, err := ParseDerivationPath("m/44'/60'/0h/0/1")
+	assert.Nil(t, err)
+	assert.Equal(t, DerivationPath{44 + HardenedOffset, 60 + HardenedOffset, HardenedOffset, 0, 1}, p)
+	assert.Equal(t, "m/44'/60'/0'/0/1", p.String())
+
+	for _, path := range []string{"44'/0", "m/a", "m/2147483648", "m//0"} {
+		_, err = ParseDerivationPath(path)
+		assert.NotNil(t, err, path)
+	}
+}
+
+func TestHDWallet_Vectors(t *testing.T) {
+	// bip32 and slip10 test vector 1
+	seed := common.Hex2Bytes("000102030405060708090a0b0c0d0e0f")
+	vectors := []struct {
+		curve *hdCurve
+		path  string
+		priv  string
+	}{
+		{hdSecp256k1, "m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+		{hdSecp256k1, "m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{hdSecp256k1, "m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{hdSecp256k1, "m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+		{hdEd25519, "m", "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7"},
+		{hdEd25519, "m/0'", "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3"},
+		{hdNist256p1, "m", "612091aaa12e22dd2abef664f8a01a82cae99ad7441b7ef8110424915c268bc2"},
+	}
+	for _, v := range vectors {
+		p, err := ParseDerivationPath(v.path)
+		assert.Nil(t, err)
+		priv, err := v.curve.derive(seed, p)
+		assert.Nil(t, err)
+		assert.Equal(t, v.priv, common.Bytes2Hex(priv), v.path)
+	}
+
+	_, err := hdEd25519.derive(seed, DerivationPath{0})
+	assert.NotNil(t, err)
+}
+
+func TestHDWallet_Derive(t *testing.T) {
+	wallet, err := NewHDWallet(strings.Repeat("abandon ", 11)+"about", "")
+	assert.Nil(t, err)
+	for _, keyType := range []KeyType{KeyTypeECDSA, KeyTypeECDSAR1, KeyTypeSM2, KeyTypeED25519} {
+		key, err := wallet.DeriveKey(keyType, HDPath(keyType, 0))
+		assert.Nil(t, err)
+		again, err := wallet.DeriveKey(keyType, HDPath(keyType, 0))
+		assert.Nil(t, err)
+		assert.Equal(t, key.GetAddress(), again.GetAddress())
+		other, err := wallet.DeriveKey(keyType, HDPath(keyType, 1))
+		assert.Nil(t, err)
+		assert.NotEqual(t, key.GetAddress(), other.GetAddress())
+
+		// the derived account json restores the same key
+		js, err := wallet.DeriveAccountJson(keyType, HDPath(keyType, 0), "pwd")
+		assert.Nil(t, err)
+		restored, err := GenKeyFromAccountJson(js, "pwd")
+		assert.Nil(t, err)
+		assert.Equal(t, key.GetAddress(), restored.(Key).GetAddress())
+	}
+
+	// the well known first ethereum account of the mnemonic
+	key, err := wallet.DeriveECDSA("m/44'/60'/0'/0/0")
+	assert.Nil(t, err)
+	assert.Equal(t, "0x9858effd232b4033e47d90003d41ec34ecaeda94", key.GetAddress().Hex())
+
+	_, err = wallet.DeriveED25519("m/44'/60'/0'/0/0")
+	assert.NotNil(t, err)
+}
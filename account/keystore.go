@@ -0,0 +1,630 @@
+package account
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/meshplus/gosdk/common"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KeyType is the type of the account stored in the keystore
+type KeyType string
+
+const (
+	// KeyTypeECDSA ecdsa on secp256k1
+	KeyTypeECDSA KeyType = "ecdsa"
+	// KeyTypeECDSAR1 ecdsa on secp256r1
+	KeyTypeECDSAR1 KeyType = "ecdsa-r1"
+	// KeyTypeSM2 sm2
+	KeyTypeSM2 KeyType = "sm2"
+	// KeyTypeED25519 ed25519
+	KeyTypeED25519 KeyType = "ed25519"
+	// KeyTypePKI pfx certificate
+	KeyTypePKI KeyType = "pki"
+
+	// V5 is the version of the keystore file
+	V5 = "5.0"
+
+	// StandardScryptN is the scrypt N parameter of the keystore, takes about 1s and 256MB memory
+	StandardScryptN = 1 << 18
+	// StandardScryptP is the scrypt P parameter of the keystore
+	StandardScryptP = 1
+	// LightScryptN is the scrypt N parameter for the devices with limited resources
+	LightScryptN = 1 << 12
+	// LightScryptP is the scrypt P parameter for the devices with limited resources
+	LightScryptP = 6
+
+	scryptR     = 8
+	scryptDKLen = 32
+	keystoreKDF = "scrypt"
+	keystoreCip = "aes-256-gcm"
+	lockFile    = ".lock"
+)
+
+var (
+	// ErrAccountNotFound the account is not in the keystore
+	ErrAccountNotFound = errors.New("account not found in keystore")
+	// ErrAccountExists the account is in the keystore already
+	ErrAccountExists = errors.New("account exists in keystore")
+	// ErrWrongPassword the password can't decrypt the account
+	ErrWrongPassword = errors.New("could not decrypt key with given password")
+	// ErrAccountLocked the account is not unlocked
+	ErrAccountLocked = errors.New("account is locked")
+)
+
+// KeystoreAccount is an account stored in the keystore
+type KeystoreAccount struct {
+	// Address is the hex address, or the did address of did accounts
+	Address string
+	Type    KeyType
+	IsDID   bool
+	// PublicKey is the hex public key
+	PublicKey string
+	// Path is the file of the account
+	Path string
+}
+
+type keystoreFile struct {
+	Address    string         `json:"address"`
+	DIDAddress string         `json:"didAddress,omitempty"`
+	Type       KeyType        `json:"type"`
+	PublicKey  string         `json:"publicKey"`
+	Crypto     keystoreCrypto `json:"crypto"`
+	Version    string         `json:"version"`
+}
+
+type keystoreCrypto struct {
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       string `json:"salt"`
+	Cipher     string `json:"cipher"`
+	Nonce      string `json:"nonce"`
+	CipherText string `json:"cipherText"`
+}
+
+// keystoreSecret is the encrypted content of the keystore file
+type keystoreSecret struct {
+	PrivateKey  string `json:"privateKey,omitempty"`
+	Pfx         string `json:"pfx,omitempty"`
+	PfxPassword string `json:"pfxPassword,omitempty"`
+}
+
+type unlockedKey struct {
+	key   interface{}
+	timer *time.Timer
+}
+
+// Keystore manages a directory of accounts encrypted by scrypt and aes-256-gcm,
+// the directory is locked during modification so several processes may share it
+type Keystore struct {
+	dir      string
+	scryptN  int
+	scryptP  int
+	mutex    sync.Mutex
+	unlocked map[string]*unlockedKey
+}
+
+// NewKeystore create a keystore of the directory, the directory is created if not exists
+func NewKeystore(dir string) (*Keystore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Keystore{
+		dir:      dir,
+		scryptN:  StandardScryptN,
+		scryptP:  StandardScryptP,
+		unlocked: make(map[string]*unlockedKey),
+	}, nil
+}
+
+// ScryptParams set the scrypt parameters of the accounts written from now on
+func (ks *Keystore) ScryptParams(n, p int) *Keystore {
+	ks.scryptN = n
+	ks.scryptP = p
+	return ks
+}
+
+// Accounts list the accounts in the keystore ordered by address, the files not in keystore format are skipped
+func (ks *Keystore) Accounts() ([]KeystoreAccount, error) {
+	infos, err := ioutil.ReadDir(ks.dir)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]KeystoreAccount, 0, len(infos))
+	for _, info := range infos {
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(ks.dir, info.Name())
+		file, err := readKeystoreFile(path)
+		if err != nil {
+			continue
+		}
+		accounts = append(accounts, file.account(path))
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Address < accounts[j].Address
+	})
+	return accounts, nil
+}
+
+// Find return the account of the address
+func (ks *Keystore) Find(address string) (KeystoreAccount, error) {
+	path := ks.path(address)
+	file, err := readKeystoreFile(path)
+	if err != nil {
+		return KeystoreAccount{}, err
+	}
+	return file.account(path), nil
+}
+
+// NewAccount generate a key of the type and store it encrypted by the password
+func (ks *Keystore) NewAccount(keyType KeyType, password string) (KeystoreAccount, error) {
+	var acType string
+	switch keyType {
+	case KeyTypeECDSA:
+		acType = ECRAW
+	case KeyTypeECDSAR1:
+		acType = ECRAWR1
+	case KeyTypeSM2:
+		acType = SMRAW
+	case KeyTypeED25519:
+		acType = ED25519RAW
+	default:
+		return KeystoreAccount{}, fmt.Errorf("can't generate account of type %s", keyType)
+	}
+	accountJSON, err := NewAccountJson(acType, "")
+	if err != nil {
+		return KeystoreAccount{}, err
+	}
+	return ks.ImportAccountJSON(accountJSON, "", password)
+}
+
+// ImportPriv import the hex private key of the type
+func (ks *Keystore) ImportPriv(keyType KeyType, priv, password string) (KeystoreAccount, error) {
+	key, err := keyFromPriv(keyType, strings.TrimPrefix(priv, "0x"), false)
+	if err != nil {
+		return KeystoreAccount{}, err
+	}
+	return ks.ImportKey(key, password)
+}
+
+// ImportAccountJSON import the account json decrypted by jsonPassword
+func (ks *Keystore) ImportAccountJSON(accountJSON, jsonPassword, password string) (KeystoreAccount, error) {
+	key, err := GenKeyFromAccountJson(accountJSON, jsonPassword)
+	if err != nil {
+		return KeystoreAccount{}, err
+	}
+	return ks.ImportKey(key, password)
+}
+
+// ImportPfx import the pfx certificate decrypted by pfxPassword
+func (ks *Keystore) ImportPfx(pfx []byte, pfxPassword, password string) (KeystoreAccount, error) {
+	key, err := NewAccountFromCert(pfx, pfxPassword)
+	if err != nil {
+		return KeystoreAccount{}, err
+	}
+	pub, err := key.PublicBytes()
+	if err != nil {
+		return KeystoreAccount{}, err
+	}
+	file := &keystoreFile{
+		Address:   key.GetAddress().Hex(),
+		Type:      KeyTypePKI,
+		PublicKey: common.ToHex(pub),
+		Version:   V5,
+	}
+	return ks.store(file, &keystoreSecret{Pfx: base64.StdEncoding.EncodeToString(pfx), PfxPassword: pfxPassword}, password)
+}
+
+// ImportKey import an ecdsa, sm2 or ed25519 key or a *DIDKey, use ImportPfx for the pki accounts
+func (ks *Keystore) ImportKey(key interface{}, password string) (KeystoreAccount, error) {
+	var didAddress string
+	if didKey, ok := key.(*DIDKey); ok {
+		didAddress = didKey.GetAddress()
+		key = didKey.GetNormalKey()
+	}
+	k, ok := key.(Key)
+	if !ok {
+		return KeystoreAccount{}, errors.New("unsupported key type")
+	}
+	keyType, err := keyTypeOf(k)
+	if err != nil {
+		return KeystoreAccount{}, err
+	}
+	priv, err := k.PrivateBytes()
+	if err != nil {
+		return KeystoreAccount{}, err
+	}
+	pub, err := k.PublicBytes()
+	if err != nil {
+		return KeystoreAccount{}, err
+	}
+	file := &keystoreFile{
+		Address:    k.GetAddress().Hex(),
+		DIDAddress: didAddress,
+		Type:       keyType,
+		PublicKey:  common.ToHex(pub),
+		Version:    V5,
+	}
+	return ks.store(file, &keystoreSecret{PrivateKey: common.Bytes2Hex(priv)}, password)
+}
+
+// Export export the account as account json encrypted by exportPassword with aes, or not encrypted if
+// exportPassword is empty, which GenKeyFromAccountJson accepts. The did accounts are exported as
+// {"didAddress": "", "account": {}} which NewDIDFromString accepts, and the pki accounts as the json of
+// NewAccountJsonFromPfx
+func (ks *Keystore) Export(address, password, exportPassword string) (string, error) {
+	file, secret, err := ks.decrypt(address, password)
+	if err != nil {
+		return "", err
+	}
+	if file.Type == KeyTypePKI {
+		pfx, err := base64.StdEncoding.DecodeString(secret.Pfx)
+		if err != nil {
+			return "", err
+		}
+		return NewAccountJsonFromPfx(secret.PfxPassword, pfx)
+	}
+
+	acType, err := exportAlgo(file.Type, exportPassword != "")
+	if err != nil {
+		return "", err
+	}
+	priv, err := encryptPriv(common.Hex2Bytes(secret.PrivateKey), acType, exportPassword)
+	if err != nil {
+		return "", err
+	}
+	ac := &accountJSON{
+		Address:    common.HexToAddress(file.Address),
+		Algo:       acType,
+		Version:    V4,
+		PublicKey:  file.PublicKey,
+		PrivateKey: common.Bytes2Hex(priv),
+	}
+	if file.DIDAddress == "" {
+		jsonBytes, err := json.Marshal(ac)
+		return string(jsonBytes), err
+	}
+	jsonBytes, err := json.Marshal(map[string]interface{}{
+		"didAddress": file.DIDAddress,
+		"account":    ac,
+	})
+	return string(jsonBytes), err
+}
+
+// Delete delete the account after checking the password
+func (ks *Keystore) Delete(address, password string) error {
+	release, err := lockDir(filepath.Join(ks.dir, lockFile))
+	if err != nil {
+		return err
+	}
+	defer release()
+	if _, _, err = ks.decrypt(address, password); err != nil {
+		return err
+	}
+	ks.Lock(address)
+	return os.Remove(ks.path(address))
+}
+
+// ChangePassword re-encrypt the account with the new password
+func (ks *Keystore) ChangePassword(address, password, newPassword string) error {
+	release, err := lockDir(filepath.Join(ks.dir, lockFile))
+	if err != nil {
+		return err
+	}
+	defer release()
+	file, secret, err := ks.decrypt(address, password)
+	if err != nil {
+		return err
+	}
+	if err = ks.encrypt(file, secret, newPassword); err != nil {
+		return err
+	}
+	return ks.write(file)
+}
+
+// Unlock decrypt the account and keep the key in memory until timeout, or until Lock if timeout is 0,
+// unlocking an unlocked account resets the timeout
+func (ks *Keystore) Unlock(address, password string, timeout time.Duration) error {
+	file, secret, err := ks.decrypt(address, password)
+	if err != nil {
+		return err
+	}
+	key, err := secret.key(file)
+	if err != nil {
+		return err
+	}
+
+	address = normalizeAddress(address)
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	if old, ok := ks.unlocked[address]; ok && old.timer != nil {
+		old.timer.Stop()
+	}
+	u := &unlockedKey{key: key}
+	if timeout > 0 {
+		u.timer = time.AfterFunc(timeout, func() {
+			ks.mutex.Lock()
+			defer ks.mutex.Unlock()
+			if ks.unlocked[address] == u {
+				delete(ks.unlocked, address)
+			}
+		})
+	}
+	ks.unlocked[address] = u
+	return nil
+}
+
+// Lock drop the unlocked key of the account from memory
+func (ks *Keystore) Lock(address string) {
+	address = normalizeAddress(address)
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	if u, ok := ks.unlocked[address]; ok {
+		if u.timer != nil {
+			u.timer.Stop()
+		}
+		delete(ks.unlocked, address)
+	}
+}
+
+// GetKey return the key of the unlocked account, it's an account.Key, a *DIDKey or a *PKIKey
+// which Transaction.Sign accepts
+func (ks *Keystore) GetKey(address string) (interface{}, error) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+	u, ok := ks.unlocked[normalizeAddress(address)]
+	if !ok {
+		return nil, ErrAccountLocked
+	}
+	return u.key, nil
+}
+
+// store encrypt and write a new account
+func (ks *Keystore) store(file *keystoreFile, secret *keystoreSecret, password string) (KeystoreAccount, error) {
+	if err := ks.encrypt(file, secret, password); err != nil {
+		return KeystoreAccount{}, err
+	}
+	release, err := lockDir(filepath.Join(ks.dir, lockFile))
+	if err != nil {
+		return KeystoreAccount{}, err
+	}
+	defer release()
+	path := ks.path(file.id())
+	if _, err = os.Stat(path); err == nil {
+		return KeystoreAccount{}, ErrAccountExists
+	}
+	if err = ks.write(file); err != nil {
+		return KeystoreAccount{}, err
+	}
+	return file.account(path), nil
+}
+
+// write the file to a temp file and rename it, so the readers never see a partial file
+func (ks *Keystore) write(file *keystoreFile) error {
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(ks.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), ks.path(file.id()))
+}
+
+func (ks *Keystore) encrypt(file *keystoreFile, secret *keystoreSecret, password string) error {
+	plain, err := json.Marshal(secret)
+	if err != nil {
+		return err
+	}
+	salt := make([]byte, 32)
+	if _, err = io.ReadFull(rand.Reader, salt); err != nil {
+		return err
+	}
+	c := keystoreCrypto{KDF: keystoreKDF, N: ks.scryptN, R: scryptR, P: ks.scryptP, Salt: common.Bytes2Hex(salt), Cipher: keystoreCip}
+	aead, err := c.aead(password)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	c.Nonce = common.Bytes2Hex(nonce)
+	c.CipherText = common.Bytes2Hex(aead.Seal(nil, nonce, plain, []byte(file.id())))
+	file.Crypto = c
+	return nil
+}
+
+func (ks *Keystore) decrypt(address, password string) (*keystoreFile, *keystoreSecret, error) {
+	file, err := readKeystoreFile(ks.path(address))
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := file.Crypto.aead(password)
+	if err != nil {
+		return nil, nil, err
+	}
+	plain, err := aead.Open(nil, common.Hex2Bytes(file.Crypto.Nonce), common.Hex2Bytes(file.Crypto.CipherText), []byte(file.id()))
+	if err != nil {
+		return nil, nil, ErrWrongPassword
+	}
+	secret := new(keystoreSecret)
+	if err = json.Unmarshal(plain, secret); err != nil {
+		return nil, nil, err
+	}
+	return file, secret, nil
+}
+
+func (ks *Keystore) path(address string) string {
+	return filepath.Join(ks.dir, strings.Replace(normalizeAddress(address), ":", "_", -1))
+}
+
+// aead derive the key from the password by scrypt
+func (c *keystoreCrypto) aead(password string) (cipher.AEAD, error) {
+	if c.KDF != keystoreKDF || c.Cipher != keystoreCip {
+		return nil, fmt.Errorf("unsupported kdf %s or cipher %s", c.KDF, c.Cipher)
+	}
+	derived, err := scrypt.Key([]byte(password), common.Hex2Bytes(c.Salt), c.N, c.R, c.P, scryptDKLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// id is the address identifying the account, the did address of did accounts
+func (file *keystoreFile) id() string {
+	if file.DIDAddress != "" {
+		return file.DIDAddress
+	}
+	return normalizeAddress(file.Address)
+}
+
+func (file *keystoreFile) account(path string) KeystoreAccount {
+	return KeystoreAccount{
+		Address:   file.id(),
+		Type:      file.Type,
+		IsDID:     file.DIDAddress != "",
+		PublicKey: file.PublicKey,
+		Path:      path,
+	}
+}
+
+// key rebuild the key of the account
+func (secret *keystoreSecret) key(file *keystoreFile) (interface{}, error) {
+	if file.Type == KeyTypePKI {
+		pfx, err := base64.StdEncoding.DecodeString(secret.Pfx)
+		if err != nil {
+			return nil, err
+		}
+		return NewAccountFromCert(pfx, secret.PfxPassword)
+	}
+	key, err := keyFromPriv(file.Type, secret.PrivateKey, file.DIDAddress != "")
+	if err != nil {
+		return nil, err
+	}
+	if file.DIDAddress != "" {
+		return &DIDKey{Key: key, address: file.DIDAddress}, nil
+	}
+	return key, nil
+}
+
+func readKeystoreFile(path string) (*keystoreFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	file := new(keystoreFile)
+	if err = json.Unmarshal(data, file); err != nil {
+		return nil, err
+	}
+	if file.Version != V5 {
+		return nil, fmt.Errorf("unsupported keystore version %s", file.Version)
+	}
+	return file, nil
+}
+
+func keyFromPriv(keyType KeyType, priv string, isDID bool) (Key, error) {
+	switch keyType {
+	case KeyTypeECDSA:
+		if isDID {
+			return NewDIDAccountFromPriv(priv)
+		}
+		return NewAccountFromPriv(priv)
+	case KeyTypeECDSAR1:
+		return NewAccountR1FromPriv(priv)
+	case KeyTypeSM2:
+		return NewAccountSm2FromPriv(priv)
+	case KeyTypeED25519:
+		return newAccountED25519FromPriv(priv)
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", keyType)
+	}
+}
+
+func keyTypeOf(key Key) (KeyType, error) {
+	algo, err := AlgorithmOf(key)
+	if err != nil {
+		return "", err
+	}
+	switch algo {
+	case AlgoECDSAR1:
+		return KeyTypeECDSAR1, nil
+	case AlgoSM2:
+		return KeyTypeSM2, nil
+	case AlgoED25519:
+		return KeyTypeED25519, nil
+	default:
+		return KeyTypeECDSA, nil
+	}
+}
+
+// exportAlgo return the account json algo of the key type
+func exportAlgo(keyType KeyType, encrypted bool) (string, error) {
+	switch keyType {
+	case KeyTypeECDSA:
+		if encrypted {
+			return ECAES, nil
+		}
+		return ECRAW, nil
+	case KeyTypeECDSAR1:
+		if encrypted {
+			return ECAESR1, nil
+		}
+		return ECRAWR1, nil
+	case KeyTypeSM2:
+		if encrypted {
+			return SMSM4, nil
+		}
+		return SMRAW, nil
+	case KeyTypeED25519:
+		if encrypted {
+			return ED25519AES, nil
+		}
+		return ED25519RAW, nil
+	default:
+		return "", fmt.Errorf("unsupported key type %s", keyType)
+	}
+}
+
+func normalizeAddress(address string) string {
+	if strings.HasPrefix(address, DIDPREFIX) {
+		return address
+	}
+	return "0x" + strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(address, "0x"), "0X"))
+}
@@ -0,0 +1,26 @@
+//go:build !windows
+// +build !windows
+
+package account
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockDir hold the exclusive lock of the lock file among processes until release is called
+func lockDir(path string) (release func(), err error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err = unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = unix.Flock(int(f.Fd()), unix.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
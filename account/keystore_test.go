@@ -0,0 +1,170 @@
+package account
+
+import (
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestKeystore(t *testing.T) *Keystore {
+	ks, err := NewKeystore(t.TempDir())
+	assert.Nil(t, err)
+	return ks.ScryptParams(LightScryptN, LightScryptP)
+}
+
+func TestKeystore_NewAccount(t *testing.T) {
+	ks := newTestKeystore(t)
+	for _, keyType := range []KeyType{KeyTypeECDSA, KeyTypeECDSAR1, KeyTypeSM2, KeyTypeED25519} {
+		ac, err := ks.NewAccount(keyType, "pwd")
+		assert.Nil(t, err)
+		assert.Equal(t, keyType, ac.Type)
+
+		assert.Nil(t, ks.Unlock(ac.Address, "pwd", 0))
+		key, err := ks.GetKey(ac.Address)
+		assert.Nil(t, err)
+		assert.Equal(t, ac.Address, key.(Key).GetAddress().Hex())
+	}
+	accounts, err := ks.Accounts()
+	assert.Nil(t, err)
+	assert.Len(t, accounts, 4)
+
+	_, err = ks.NewAccount(KeyTypePKI, "pwd")
+	assert.NotNil(t, err)
+}
+
+func TestKeystore_Import(t *testing.T) {
+	ks := newTestKeystore(t)
+	ac, err := ks.ImportPriv(KeyTypeECDSA, "a1fd6ed6225e76aac3884b5420c8cdbb4fde1db01e9ef773415b8f2b5a9b77d4", "pwd")
+	assert.Nil(t, err)
+	key, _ := NewAccountFromPriv("a1fd6ed6225e76aac3884b5420c8cdbb4fde1db01e9ef773415b8f2b5a9b77d4")
+	assert.Equal(t, key.GetAddress().Hex(), ac.Address)
+
+	_, err = ks.ImportPriv(KeyTypeECDSA, "0xa1fd6ed6225e76aac3884b5420c8cdbb4fde1db01e9ef773415b8f2b5a9b77d4", "pwd")
+	assert.Equal(t, ErrAccountExists, err)
+
+	js, _ := NewAccountSm2("123")
+	ac, err = ks.ImportAccountJSON(js, "123", "pwd")
+	assert.Nil(t, err)
+	assert.Equal(t, KeyTypeSM2, ac.Type)
+
+	pfx, err := ioutil.ReadFile("idcert.pfx")
+	assert.Nil(t, err)
+	ac, err = ks.ImportPfx(pfx, "123456", "pwd")
+	assert.Nil(t, err)
+	assert.Equal(t, KeyTypePKI, ac.Type)
+	assert.Nil(t, ks.Unlock(ac.Address, "pwd", 0))
+	pki, err := ks.GetKey(ac.Address)
+	assert.Nil(t, err)
+	assert.Equal(t, ac.Address, pki.(*PKIKey).GetAddress().Hex())
+
+	accounts, err := ks.Accounts()
+	assert.Nil(t, err)
+	assert.Len(t, accounts, 3)
+}
+
+func TestKeystore_DID(t *testing.T) {
+	ks := newTestKeystore(t)
+	js, _ := NewAccountDID("")
+	didKey, err := NewDIDFromAccountJson(js, "", "chainID", "suffix")
+	assert.Nil(t, err)
+	ac, err := ks.ImportKey(didKey, "pwd")
+	assert.Nil(t, err)
+	assert.True(t, ac.IsDID)
+	assert.Equal(t, "did:hpc:chainID:suffix", ac.Address)
+
+	assert.Nil(t, ks.Unlock(ac.Address, "pwd", 0))
+	key, err := ks.GetKey(ac.Address)
+	assert.Nil(t, err)
+	assert.Equal(t, didKey.GetAddress(), key.(*DIDKey).GetAddress())
+	assert.Equal(t, didKey.GetNormalKey().GetAddress(), key.(*DIDKey).GetNormalKey().GetAddress())
+
+	exported, err := ks.Export(ac.Address, "pwd", "export")
+	assert.Nil(t, err)
+	imported, err := NewDIDFromString(exported, "export")
+	assert.Nil(t, err)
+	assert.Equal(t, didKey.GetAddress(), imported.GetAddress())
+	assert.Equal(t, didKey.GetNormalKey().GetAddress(), imported.GetNormalKey().GetAddress())
+}
+
+func TestKeystore_Export(t *testing.T) {
+	ks := newTestKeystore(t)
+	for _, keyType := range []KeyType{KeyTypeECDSA, KeyTypeECDSAR1, KeyTypeSM2, KeyTypeED25519} {
+		ac, err := ks.NewAccount(keyType, "pwd")
+		assert.Nil(t, err)
+
+		_, err = ks.Export(ac.Address, "wrong", "")
+		assert.Equal(t, ErrWrongPassword, err)
+
+		for _, exportPassword := range []string{"", "export"} {
+			js, err := ks.Export(ac.Address, "pwd", exportPassword)
+			assert.Nil(t, err)
+			key, err := GenKeyFromAccountJson(js, exportPassword)
+			assert.Nil(t, err)
+			assert.Equal(t, ac.Address, key.(Key).GetAddress().Hex())
+		}
+	}
+}
+
+func TestKeystore_ChangePasswordAndDelete(t *testing.T) {
+	ks := newTestKeystore(t)
+	ac, err := ks.NewAccount(KeyTypeSM2, "pwd")
+	assert.Nil(t, err)
+
+	assert.Equal(t, ErrWrongPassword, ks.ChangePassword(ac.Address, "wrong", "new"))
+	assert.Nil(t, ks.ChangePassword(ac.Address, "pwd", "new"))
+	assert.Equal(t, ErrWrongPassword, ks.Unlock(ac.Address, "pwd", 0))
+	assert.Nil(t, ks.Unlock(ac.Address, "new", 0))
+
+	assert.Equal(t, ErrWrongPassword, ks.Delete(ac.Address, "pwd"))
+	assert.Nil(t, ks.Delete(ac.Address, "new"))
+	_, err = ks.GetKey(ac.Address)
+	assert.Equal(t, ErrAccountLocked, err)
+	_, err = ks.Find(ac.Address)
+	assert.Equal(t, ErrAccountNotFound, err)
+}
+
+func TestKeystore_UnlockTimeout(t *testing.T) {
+	ks := newTestKeystore(t)
+	ac, err := ks.NewAccount(KeyTypeED25519, "pwd")
+	assert.Nil(t, err)
+
+	_, err = ks.GetKey(ac.Address)
+	assert.Equal(t, ErrAccountLocked, err)
+	assert.Nil(t, ks.Unlock(ac.Address, "pwd", 50*time.Millisecond))
+	_, err = ks.GetKey(ac.Address)
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		_, err := ks.GetKey(ac.Address)
+		return err == ErrAccountLocked
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Nil(t, ks.Unlock(ac.Address, "pwd", 0))
+	ks.Lock(ac.Address)
+	_, err = ks.GetKey(ac.Address)
+	assert.Equal(t, ErrAccountLocked, err)
+}
+
+func TestKeystore_Concurrent(t *testing.T) {
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// every keystore locks the directory by the lock file as separate processes do
+			ks, err := NewKeystore(dir)
+			assert.Nil(t, err)
+			ks.ScryptParams(LightScryptN, LightScryptP)
+			_, err = ks.NewAccount(KeyTypeSM2, "pwd")
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	ks, _ := NewKeystore(dir)
+	accounts, err := ks.Accounts()
+	assert.Nil(t, err)
+	assert.Len(t, accounts, 4)
+}
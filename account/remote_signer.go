@@ -0,0 +1,205 @@
+package account
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/meshplus/gosdk/common"
+)
+
+// The remote signer protocol is json over http:
+//
+//	GET  {endpoint}/keys/{keyID}       -> {"address":"0x..","publicKey":"0x..","algorithm":"sm2"}
+//	POST {endpoint}/keys/{keyID}/sign  <- {"hash":"0x.."} -> {"signature":"0x.."}
+//
+// a failed request answers a non 200 status with {"error":"..."}
+
+type signerKeyJSON struct {
+	Address   string   `json:"address"`
+	PublicKey string   `json:"publicKey"`
+	Algorithm SignAlgo `json:"algorithm"`
+}
+
+type signRequestJSON struct {
+	Hash string `json:"hash"`
+}
+
+type signResponseJSON struct {
+	Signature string `json:"signature"`
+}
+
+type signerErrorJSON struct {
+	Error string `json:"error"`
+}
+
+// RemoteSigner is a Signer which asks a signer daemon to sign, so the private key stays in the daemon
+type RemoteSigner struct {
+	client  *http.Client
+	keyURL  string
+	keyID   string
+	address common.Address
+	pub     []byte
+	algo    SignAlgo
+}
+
+// NewRemoteSigner create a Signer of the key keyID held by the signer daemon listening on endpoint,
+// e.g. http://127.0.0.1:9090
+func NewRemoteSigner(endpoint, keyID string) (*RemoteSigner, error) {
+	return NewRemoteSignerWithClient(endpoint, keyID, &http.Client{Timeout: 10 * time.Second})
+}
+
+// NewRemoteSignerWithClient is the same as NewRemoteSigner but use the given http client,
+// e.g. one with mutual tls or an authorization transport
+func NewRemoteSignerWithClient(endpoint, keyID string, client *http.Client) (*RemoteSigner, error) {
+	if keyID == "" {
+		return nil, errors.New("key id is empty")
+	}
+	s := &RemoteSigner{
+		client: client,
+		keyURL: strings.TrimSuffix(endpoint, "/") + "/keys/" + keyID,
+		keyID:  keyID,
+	}
+	var key signerKeyJSON
+	if err := s.do(http.MethodGet, s.keyURL, nil, &key); err != nil {
+		return nil, err
+	}
+	switch key.Algorithm {
+	case AlgoECDSA, AlgoECDSAR1, AlgoSM2, AlgoED25519:
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %s of key %s", key.Algorithm, keyID)
+	}
+	s.address = common.HexToAddress(key.Address)
+	s.pub = common.FromHex(key.PublicKey)
+	s.algo = key.Algorithm
+	return s, nil
+}
+
+// KeyID return the id of the key in the signer daemon
+func (s *RemoteSigner) KeyID() string {
+	return s.keyID
+}
+
+// PublicBytes return the public key
+func (s *RemoteSigner) PublicBytes() ([]byte, error) {
+	return s.pub, nil
+}
+
+// GetAddress return the account address
+func (s *RemoteSigner) GetAddress() common.Address {
+	return s.address
+}
+
+// Algorithm return the signature algorithm
+func (s *RemoteSigner) Algorithm() SignAlgo {
+	return s.algo
+}
+
+// Sign ask the signer daemon to sign the hash
+func (s *RemoteSigner) Sign(hash []byte) ([]byte, error) {
+	var resp signResponseJSON
+	if err := s.do(http.MethodPost, s.keyURL+"/sign", &signRequestJSON{Hash: common.ToHex(hash)}, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Signature == "" {
+		return nil, errors.New("remote signer return empty signature")
+	}
+	return common.FromHex(resp.Signature), nil
+}
+
+func (s *RemoteSigner) do(method, url string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request remote signer error: %v", err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e signerErrorJSON
+		if json.Unmarshal(data, &e) == nil && e.Error != "" {
+			return fmt.Errorf("remote signer error: %s", e.Error)
+		}
+		return fmt.Errorf("remote signer error: %s", resp.Status)
+	}
+	return json.Unmarshal(data, out)
+}
+
+// NewSignerHandler return the http handler of a signer daemon serving the signers by key id,
+// the daemon should listen on a local or mutual tls protected address only
+func NewSignerHandler(signers map[string]Signer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/keys/")
+		if path == r.URL.Path {
+			writeSignerError(w, http.StatusNotFound, "not found")
+			return
+		}
+		keyID, action := path, ""
+		if i := strings.Index(path, "/"); i >= 0 {
+			keyID, action = path[:i], path[i+1:]
+		}
+		signer, ok := signers[keyID]
+		if !ok {
+			writeSignerError(w, http.StatusNotFound, "unknown key "+keyID)
+			return
+		}
+
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			pub, err := signer.PublicBytes()
+			if err != nil {
+				writeSignerError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			writeSignerJSON(w, &signerKeyJSON{
+				Address:   signer.GetAddress().Hex(),
+				PublicKey: common.ToHex(pub),
+				Algorithm: signer.Algorithm(),
+			})
+		case action == "sign" && r.Method == http.MethodPost:
+			var req signRequestJSON
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Hash == "" {
+				writeSignerError(w, http.StatusBadRequest, "invalid sign request")
+				return
+			}
+			sig, err := signer.Sign(common.FromHex(req.Hash))
+			if err != nil {
+				writeSignerError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			writeSignerJSON(w, &signResponseJSON{Signature: common.ToHex(sig)})
+		default:
+			writeSignerError(w, http.StatusNotFound, "not found")
+		}
+	})
+}
+
+func writeSignerJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeSignerError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(&signerErrorJSON{Error: msg})
+}
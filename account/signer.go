@@ -0,0 +1,88 @@
+package account
+
+import (
+	"crypto/rand"
+	"errors"
+
+	"github.com/meshplus/crypto-standard/asym"
+	"github.com/meshplus/gosdk/common"
+)
+
+// SignAlgo is the signature algorithm of a Signer
+type SignAlgo string
+
+const (
+	// AlgoECDSA ecdsa on secp256k1
+	AlgoECDSA SignAlgo = "ecdsa"
+	// AlgoECDSAR1 ecdsa on secp256r1
+	AlgoECDSAR1 SignAlgo = "ecdsa-r1"
+	// AlgoSM2 sm2
+	AlgoSM2 SignAlgo = "sm2"
+	// AlgoED25519 ed25519
+	AlgoED25519 SignAlgo = "ed25519"
+)
+
+// Signer signs the hash of transactions, the private key may live outside the process,
+// e.g. in a KMS, a HSM or a local signer daemon
+type Signer interface {
+	// PublicBytes return the public key
+	PublicBytes() ([]byte, error)
+	// GetAddress return the account address
+	GetAddress() common.Address
+	// Algorithm return the signature algorithm
+	Algorithm() SignAlgo
+	// Sign sign the hash and return the raw signature without the algorithm prefix
+	Sign(hash []byte) ([]byte, error)
+}
+
+// AlgorithmOf return the signature algorithm of the key
+func AlgorithmOf(key Key) (SignAlgo, error) {
+	switch k := key.(type) {
+	case *ECDSAKey:
+		if k.AlgorithmType() == asym.AlgoP256R1 {
+			return AlgoECDSAR1, nil
+		}
+		return AlgoECDSA, nil
+	case *SM2Key:
+		return AlgoSM2, nil
+	case *ED25519Key:
+		return AlgoED25519, nil
+	default:
+		return "", errors.New("unsupported key type")
+	}
+}
+
+// LocalSigner is a Signer holding the key in memory
+type LocalSigner struct {
+	key  Key
+	algo SignAlgo
+}
+
+// NewLocalSigner create a Signer of the in-memory ecdsa, sm2 or ed25519 key
+func NewLocalSigner(key Key) (*LocalSigner, error) {
+	algo, err := AlgorithmOf(key)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalSigner{key: key, algo: algo}, nil
+}
+
+// PublicBytes return the public key
+func (s *LocalSigner) PublicBytes() ([]byte, error) {
+	return s.key.PublicBytes()
+}
+
+// GetAddress return the account address
+func (s *LocalSigner) GetAddress() common.Address {
+	return s.key.GetAddress()
+}
+
+// Algorithm return the signature algorithm
+func (s *LocalSigner) Algorithm() SignAlgo {
+	return s.algo
+}
+
+// Sign sign the hash with the in-memory key
+func (s *LocalSigner) Sign(hash []byte) ([]byte, error) {
+	return s.key.Sign(nil, hash, rand.Reader)
+}
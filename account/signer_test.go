@@ -0,0 +1,75 @@
+package account
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/meshplus/crypto-standard/hash"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSigner(t *testing.T) (*SM2Key, *LocalSigner) {
+	js, err := NewAccountSm2("")
+	assert.Nil(t, err)
+	key, err := GenKeyFromAccountJson(js, "")
+	assert.Nil(t, err)
+	signer, err := NewLocalSigner(key.(*SM2Key))
+	assert.Nil(t, err)
+	return key.(*SM2Key), signer
+}
+
+func TestLocalSigner(t *testing.T) {
+	key, signer := newTestSigner(t)
+	assert.Equal(t, AlgoSM2, signer.Algorithm())
+	assert.Equal(t, key.GetAddress(), signer.GetAddress())
+
+	h, _ := hash.NewHasher(hash.KECCAK_256).Hash([]byte("hello"))
+	sig, err := signer.Sign(h)
+	assert.Nil(t, err)
+	valid, err := key.PublicKey.Verify(nil, sig, h)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	_, err = NewLocalSigner(&PKIKey{})
+	assert.NotNil(t, err)
+}
+
+func TestAlgorithmOf(t *testing.T) {
+	js, _ := NewAccountR1("")
+	r1, _ := GenKeyFromAccountJson(js, "")
+	algo, err := AlgorithmOf(r1.(Key))
+	assert.Nil(t, err)
+	assert.Equal(t, AlgoECDSAR1, algo)
+
+	js, _ = NewAccount("")
+	k1, _ := GenKeyFromAccountJson(js, "")
+	algo, err = AlgorithmOf(k1.(Key))
+	assert.Nil(t, err)
+	assert.Equal(t, AlgoECDSA, algo)
+}
+
+func TestRemoteSigner(t *testing.T) {
+	key, local := newTestSigner(t)
+	server := httptest.NewServer(NewSignerHandler(map[string]Signer{"sm2": local}))
+	defer server.Close()
+
+	signer, err := NewRemoteSigner(server.URL, "sm2")
+	assert.Nil(t, err)
+	assert.Equal(t, "sm2", signer.KeyID())
+	assert.Equal(t, AlgoSM2, signer.Algorithm())
+	assert.Equal(t, key.GetAddress(), signer.GetAddress())
+	pub, _ := key.PublicBytes()
+	remotePub, err := signer.PublicBytes()
+	assert.Nil(t, err)
+	assert.Equal(t, pub, remotePub)
+
+	h, _ := hash.NewHasher(hash.KECCAK_256).Hash([]byte("hello"))
+	sig, err := signer.Sign(h)
+	assert.Nil(t, err)
+	valid, err := key.PublicKey.Verify(nil, sig, h)
+	assert.Nil(t, err)
+	assert.True(t, valid)
+
+	_, err = NewRemoteSigner(server.URL, "unknown")
+	assert.EqualError(t, err, "remote signer error: unknown key unknown")
+}
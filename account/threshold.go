@@ -0,0 +1,379 @@
+package account
+
+import (
+	stded25519 "crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/meshplus/crypto-standard/ed25519/ge25519"
+	"github.com/meshplus/crypto-standard/ed25519/modm"
+	"github.com/meshplus/crypto-standard/hash"
+	"github.com/meshplus/gosdk/common"
+)
+
+// The m-of-n threshold account is an ed25519 account whose private key is split into n shares by a trusted
+// dealer, any m parties sign with the two rounds flexible round-optimized schnorr threshold (FROST) protocol:
+//
+//  1. every party calls Commit and sends the ThresholdCommitment to the others
+//  2. every party calls PartialSign with all the commitments and sends the PartialSignature to the coordinator
+//  3. the coordinator calls CombineThresholdSignatures to get the ed25519 signature of the group public key
+//
+// the private key is never rebuilt, and the result is a plain ed25519 signature the chain verifies as usual
+
+// ed25519Order is the order of the ed25519 base point
+var ed25519Order, _ = new(big.Int).SetString("7237005577332262213973186563042994240857116359379907606001950938285454250989", 10)
+
+// ThresholdShare is the key share of a party of the m-of-n ed25519 threshold account
+type ThresholdShare struct {
+	// Index is the index of the party, from 1 to n
+	Index uint32 `json:"index"`
+	// Threshold is m, the number of the parties needed to sign
+	Threshold int `json:"threshold"`
+	// PublicKey is the public key of the group
+	PublicKey []byte `json:"publicKey"`
+	// Secret is the secret share of the party, little endian
+	Secret []byte `json:"secret"`
+}
+
+// ThresholdCommitment is the public nonce commitment of a party in a signing round
+type ThresholdCommitment struct {
+	Index uint32 `json:"index"`
+	D     []byte `json:"d"`
+	E     []byte `json:"e"`
+}
+
+// ThresholdNonce is the secret nonce of a party in a signing round, it can be used only once
+type ThresholdNonce struct {
+	d, e       *big.Int
+	commitment *ThresholdCommitment
+	used       bool
+	mutex      sync.Mutex
+}
+
+// PartialSignature is the signature share of a party
+type PartialSignature struct {
+	Index uint32 `json:"index"`
+	Z     []byte `json:"z"`
+}
+
+// NewThresholdED25519 generate an ed25519 key and split it into n shares, any m of which can sign
+func NewThresholdED25519(m, n int) ([]*ThresholdShare, error) {
+	if m < 1 || m > n || n > 255 {
+		return nil, fmt.Errorf("invalid threshold %d of %d", m, n)
+	}
+	// f(x) = a0 + a1*x + ... + a(m-1)*x^(m-1), a0 is the private key
+	coefficients := make([]*big.Int, m)
+	for i := range coefficients {
+		c, err := randScalar()
+		if err != nil {
+			return nil, err
+		}
+		coefficients[i] = c
+	}
+	pub := scalarBaseMult(coefficients[0])
+
+	shares := make([]*ThresholdShare, n)
+	for i := 1; i <= n; i++ {
+		x := big.NewInt(int64(i))
+		y := new(big.Int)
+		for j := m - 1; j >= 0; j-- {
+			y.Mul(y, x)
+			y.Add(y, coefficients[j])
+			y.Mod(y, ed25519Order)
+		}
+		shares[i-1] = &ThresholdShare{Index: uint32(i), Threshold: m, PublicKey: pub, Secret: scalarBytes(y)}
+	}
+	return shares, nil
+}
+
+// GetAddress return the address of the group
+func (s *ThresholdShare) GetAddress() common.Address {
+	return ed25519Address(s.PublicKey)
+}
+
+// PublicBytes return the public key of the group
+func (s *ThresholdShare) PublicBytes() ([]byte, error) {
+	return s.PublicKey, nil
+}
+
+// Commit start a signing round, the nonce is kept by the party and the commitment is sent to the others
+func (s *ThresholdShare) Commit() (*ThresholdNonce, *ThresholdCommitment, error) {
+	d, err := randScalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	e, err := randScalar()
+	if err != nil {
+		return nil, nil, err
+	}
+	commitment := &ThresholdCommitment{Index: s.Index, D: scalarBaseMult(d), E: scalarBaseMult(e)}
+	return &ThresholdNonce{d: d, e: e, commitment: commitment}, commitment, nil
+}
+
+// PartialSign sign the message with the nonce of Commit, commitments are the commitments of all
+// the signing parties including this one
+func (s *ThresholdShare) PartialSign(nonce *ThresholdNonce, msg []byte, commitments []*ThresholdCommitment) (*PartialSignature, error) {
+	nonce.mutex.Lock()
+	defer nonce.mutex.Unlock()
+	if nonce.used {
+		return nil, errors.New("threshold nonce is used")
+	}
+	commitments, err := sortCommitments(commitments, s.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	var own *ThresholdCommitment
+	for _, c := range commitments {
+		if c.Index == s.Index {
+			own = c
+		}
+	}
+	if own == nil || string(own.D) != string(nonce.commitment.D) || string(own.E) != string(nonce.commitment.E) {
+		return nil, errors.New("commitment of the party is missing or mismatched")
+	}
+	nonce.used = true
+
+	R, rhos, err := groupCommitment(msg, commitments)
+	if err != nil {
+		return nil, err
+	}
+	c := challenge(R, s.PublicKey, msg)
+	lambda := lagrange(s.Index, commitments)
+
+	// z = d + e * rho + lambda * s * c
+	z := new(big.Int).Mul(nonce.e, rhos[s.Index])
+	z.Add(z, nonce.d)
+	t := new(big.Int).Mul(lambda, scalarFromBytes(s.Secret))
+	t.Mul(t, c)
+	z.Add(z, t)
+	z.Mod(z, ed25519Order)
+	return &PartialSignature{Index: s.Index, Z: scalarBytes(z)}, nil
+}
+
+// CombineThresholdSignatures combine the partial signatures into the ed25519 signature of the group
+// public key, the signature is verified before returned
+func CombineThresholdSignatures(pub, msg []byte, commitments []*ThresholdCommitment, partials []*PartialSignature) ([]byte, error) {
+	commitments, err := sortCommitments(commitments, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(partials) != len(commitments) {
+		return nil, errors.New("partial signatures and commitments are mismatched")
+	}
+	R, _, err := groupCommitment(msg, commitments)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[uint32]bool, len(partials))
+	S := new(big.Int)
+	for _, p := range partials {
+		if _, ok := findCommitment(commitments, p.Index); !ok || seen[p.Index] {
+			return nil, fmt.Errorf("unexpected partial signature of party %d", p.Index)
+		}
+		seen[p.Index] = true
+		S.Add(S, scalarFromBytes(p.Z))
+	}
+	S.Mod(S, ed25519Order)
+
+	sig := append(append([]byte{}, R...), scalarBytes(S)...)
+	if len(pub) != stded25519.PublicKeySize || !stded25519.Verify(pub, msg, sig) {
+		return nil, errors.New("invalid threshold signature")
+	}
+	return sig, nil
+}
+
+// ThresholdSigner is a Signer running the signing rounds among the shares in process,
+// it's useful for tests and for the parties living in one process
+type ThresholdSigner struct {
+	shares []*ThresholdShare
+}
+
+// NewThresholdSigner create a Signer of at least threshold shares of the same group
+func NewThresholdSigner(shares ...*ThresholdShare) (*ThresholdSigner, error) {
+	if len(shares) == 0 || len(shares) < shares[0].Threshold {
+		return nil, errors.New("shares are less than the threshold")
+	}
+	seen := make(map[uint32]bool, len(shares))
+	for _, s := range shares {
+		if string(s.PublicKey) != string(shares[0].PublicKey) || seen[s.Index] {
+			return nil, errors.New("shares are duplicated or of different groups")
+		}
+		seen[s.Index] = true
+	}
+	return &ThresholdSigner{shares: shares}, nil
+}
+
+// PublicBytes return the public key of the group
+func (ts *ThresholdSigner) PublicBytes() ([]byte, error) {
+	return ts.shares[0].PublicKey, nil
+}
+
+// GetAddress return the address of the group
+func (ts *ThresholdSigner) GetAddress() common.Address {
+	return ts.shares[0].GetAddress()
+}
+
+// Algorithm return AlgoED25519
+func (ts *ThresholdSigner) Algorithm() SignAlgo {
+	return AlgoED25519
+}
+
+// Sign sign the message, ed25519 signs the message itself rather than its hash
+func (ts *ThresholdSigner) Sign(msg []byte) ([]byte, error) {
+	nonces := make([]*ThresholdNonce, len(ts.shares))
+	commitments := make([]*ThresholdCommitment, len(ts.shares))
+	for i, s := range ts.shares {
+		var err error
+		if nonces[i], commitments[i], err = s.Commit(); err != nil {
+			return nil, err
+		}
+	}
+	partials := make([]*PartialSignature, len(ts.shares))
+	for i, s := range ts.shares {
+		var err error
+		if partials[i], err = s.PartialSign(nonces[i], msg, commitments); err != nil {
+			return nil, err
+		}
+	}
+	return CombineThresholdSignatures(ts.shares[0].PublicKey, msg, commitments, partials)
+}
+
+// groupCommitment return R = sum(D + rho * E) and the binding factor rho of every party
+func groupCommitment(msg []byte, commitments []*ThresholdCommitment) ([]byte, map[uint32]*big.Int, error) {
+	encoded := make([]byte, 0, len(commitments)*68)
+	for _, c := range commitments {
+		encoded = append(encoded, ser32(c.Index)...)
+		encoded = append(encoded, c.D...)
+		encoded = append(encoded, c.E...)
+	}
+	rhos := make(map[uint32]*big.Int, len(commitments))
+	var R ge25519.Ge25519
+	R.Zero()
+	R.Y[0], R.Z[0] = 1, 1
+	for _, c := range commitments {
+		h := sha512.New()
+		_, _ = h.Write([]byte("FROST-ED25519-rho"))
+		_, _ = h.Write(ser32(c.Index))
+		_, _ = h.Write(msg)
+		_, _ = h.Write(encoded)
+		rho := scalarFromBytes(h.Sum(nil))
+		rhos[c.Index] = rho
+
+		var D, E, rhoE ge25519.Ge25519
+		if !ge25519.UnpackVartime(&D, c.D, false) || !ge25519.UnpackVartime(&E, c.E, false) {
+			return nil, nil, fmt.Errorf("invalid commitment of party %d", c.Index)
+		}
+		var zero, rhoM modm.Bignum256
+		toModm(&rhoM, rho)
+		ge25519.DoubleScalarmultVartime(&rhoE, &E, &rhoM, &zero)
+		// the result is in the partial form without T, repack it before adding
+		packed := make([]byte, 32)
+		ge25519.Pack(packed, &rhoE)
+		ge25519.UnpackVartime(&rhoE, packed, false)
+		ge25519.Add(&R, &R, &D)
+		ge25519.Add(&R, &R, &rhoE)
+	}
+	packed := make([]byte, 32)
+	ge25519.Pack(packed, &R)
+	return packed, rhos, nil
+}
+
+// challenge is the ed25519 challenge H(R || A || M)
+func challenge(R, pub, msg []byte) *big.Int {
+	h := sha512.New()
+	_, _ = h.Write(R)
+	_, _ = h.Write(pub)
+	_, _ = h.Write(msg)
+	return scalarFromBytes(h.Sum(nil))
+}
+
+// lagrange return the lagrange coefficient at 0 of the party among the signing parties
+func lagrange(index uint32, commitments []*ThresholdCommitment) *big.Int {
+	num, den := big.NewInt(1), big.NewInt(1)
+	xi := big.NewInt(int64(index))
+	for _, c := range commitments {
+		if c.Index == index {
+			continue
+		}
+		xj := big.NewInt(int64(c.Index))
+		num.Mul(num, xj)
+		den.Mul(den, new(big.Int).Sub(xj, xi))
+	}
+	den.Mod(den, ed25519Order)
+	num.Mul(num, den.ModInverse(den, ed25519Order))
+	return num.Mod(num, ed25519Order)
+}
+
+func sortCommitments(commitments []*ThresholdCommitment, threshold int) ([]*ThresholdCommitment, error) {
+	if len(commitments) < threshold {
+		return nil, errors.New("commitments are less than the threshold")
+	}
+	sorted := append([]*ThresholdCommitment{}, commitments...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Index < sorted[j].Index })
+	for i, c := range sorted {
+		if c.Index == 0 || len(c.D) != 32 || len(c.E) != 32 || (i > 0 && sorted[i-1].Index == c.Index) {
+			return nil, fmt.Errorf("invalid commitment of party %d", c.Index)
+		}
+	}
+	return sorted, nil
+}
+
+func findCommitment(commitments []*ThresholdCommitment, index uint32) (*ThresholdCommitment, bool) {
+	for _, c := range commitments {
+		if c.Index == index {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+func randScalar() (*big.Int, error) {
+	buf := make([]byte, 64)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	return scalarFromBytes(buf), nil
+}
+
+// scalarFromBytes read the little endian bytes as a scalar mod the order
+func scalarFromBytes(le []byte) *big.Int {
+	be := make([]byte, len(le))
+	for i, b := range le {
+		be[len(le)-1-i] = b
+	}
+	return new(big.Int).Mod(new(big.Int).SetBytes(be), ed25519Order)
+}
+
+// scalarBytes return the 32 bytes little endian of the scalar
+func scalarBytes(s *big.Int) []byte {
+	be := common.LeftPadBytes(s.Bytes(), 32)
+	le := make([]byte, 32)
+	for i, b := range be {
+		le[31-i] = b
+	}
+	return le
+}
+
+func toModm(out *modm.Bignum256, s *big.Int) {
+	modm.Expand(out, scalarBytes(s))
+}
+
+func scalarBaseMult(s *big.Int) []byte {
+	var m modm.Bignum256
+	toModm(&m, s)
+	var p ge25519.Ge25519
+	ge25519.ScalarmultBaseNiels(&p, &m)
+	packed := make([]byte, 32)
+	ge25519.Pack(packed, &p)
+	return packed
+}
+
+func ed25519Address(pub []byte) common.Address {
+	h, _ := hash.NewHasher(hash.SHA2_256).Hash(pub)
+	return common.BytesToAddress(h[12:])
+}
@@ -0,0 +1,75 @@
+package account
+
+import (
+	stded25519 "crypto/ed25519"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThresholdED25519(t *testing.T) {
+	shares, err := NewThresholdED25519(2, 3)
+	assert.Nil(t, err)
+	assert.Len(t, shares, 3)
+	msg := []byte("need hash string")
+
+	// any 2 of the 3 parties sign
+	for _, group := range [][]*ThresholdShare{{shares[0], shares[1]}, {shares[1], shares[2]}, {shares[2], shares[0]}, shares} {
+		nonces := make([]*ThresholdNonce, len(group))
+		commitments := make([]*ThresholdCommitment, len(group))
+		for i, s := range group {
+			nonces[i], commitments[i], err = s.Commit()
+			assert.Nil(t, err)
+		}
+		partials := make([]*PartialSignature, len(group))
+		for i, s := range group {
+			partials[i], err = s.PartialSign(nonces[i], msg, commitments)
+			assert.Nil(t, err)
+		}
+		sig, err := CombineThresholdSignatures(shares[0].PublicKey, msg, commitments, partials)
+		assert.Nil(t, err)
+		assert.True(t, stded25519.Verify(shares[0].PublicKey, msg, sig))
+
+		// the nonce can't be reused
+		_, err = group[0].PartialSign(nonces[0], msg, commitments)
+		assert.NotNil(t, err)
+	}
+
+	// one party is not enough
+	nonce, commitment, err := shares[0].Commit()
+	assert.Nil(t, err)
+	_, err = shares[0].PartialSign(nonce, msg, []*ThresholdCommitment{commitment})
+	assert.NotNil(t, err)
+}
+
+func TestThresholdED25519_BadPartial(t *testing.T) {
+	shares, err := NewThresholdED25519(2, 2)
+	assert.Nil(t, err)
+	msg := []byte("need hash string")
+	n1, c1, _ := shares[0].Commit()
+	n2, c2, _ := shares[1].Commit()
+	commitments := []*ThresholdCommitment{c1, c2}
+	p1, err := shares[0].PartialSign(n1, msg, commitments)
+	assert.Nil(t, err)
+	p2, err := shares[1].PartialSign(n2, []byte("another message"), commitments)
+	assert.Nil(t, err)
+	_, err = CombineThresholdSignatures(shares[0].PublicKey, msg, commitments, []*PartialSignature{p1, p2})
+	assert.EqualError(t, err, "invalid threshold signature")
+}
+
+func TestThresholdSigner(t *testing.T) {
+	shares, err := NewThresholdED25519(3, 5)
+	assert.Nil(t, err)
+	_, err = NewThresholdSigner(shares[0], shares[1])
+	assert.NotNil(t, err)
+	_, err = NewThresholdSigner(shares[0], shares[1], shares[1])
+	assert.NotNil(t, err)
+
+	signer, err := NewThresholdSigner(shares[4], shares[1], shares[3])
+	assert.Nil(t, err)
+	assert.Equal(t, AlgoED25519, signer.Algorithm())
+	assert.Equal(t, ed25519Address(shares[0].PublicKey), signer.GetAddress())
+	sig, err := signer.Sign([]byte("msg"))
+	assert.Nil(t, err)
+	assert.True(t, stded25519.Verify(shares[0].PublicKey, []byte("msg"), sig))
+}
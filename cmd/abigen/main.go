@@ -0,0 +1,94 @@
+// Command abigen generates the typed Go bindings of the solidity contracts, e.g.
+//
+//	abigen -abi token.abi -bin token.bin -type Token -pkg token -out token.go
+//	abigen -sol token.sol -pkg token -out token.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/meshplus/gosdk/abi2/bind"
+	"github.com/meshplus/gosdk/common/compiler"
+)
+
+var (
+	abiFlag  = flag.String("abi", "", "Path to the contract ABI json to bind, - for STDIN")
+	binFlag  = flag.String("bin", "", "Path to the contract bytecode (generate deploy method)")
+	typeFlag = flag.String("type", "", "Go struct name for the binding (default = ABI file name)")
+	solFlag  = flag.String("sol", "", "Path to the solidity source to build and bind, solc or solcjs is required")
+	pkgFlag  = flag.String("pkg", "", "Package name to generate the binding into")
+	outFlag  = flag.String("out", "", "Output file for the generated binding (default = stdout)")
+)
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "abigen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	if *pkgFlag == "" {
+		return fmt.Errorf("no destination package specified (-pkg)")
+	}
+	var types, abis, bins []string
+	switch {
+	case *solFlag != "" && *abiFlag != "":
+		return fmt.Errorf("-sol and -abi are exclusive")
+	case *solFlag != "":
+		source, err := ioutil.ReadFile(*solFlag)
+		if err != nil {
+			return err
+		}
+		if abis, bins, types, err = compiler.CompileSourcefile(string(source)); err != nil {
+			return err
+		}
+	case *abiFlag != "":
+		var (
+			abi []byte
+			err error
+		)
+		if *abiFlag == "-" {
+			abi, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			abi, err = ioutil.ReadFile(*abiFlag)
+		}
+		if err != nil {
+			return err
+		}
+		abis = []string{string(abi)}
+		var bin []byte
+		if *binFlag != "" {
+			if bin, err = ioutil.ReadFile(*binFlag); err != nil {
+				return err
+			}
+		}
+		bins = []string{strings.TrimSpace(string(bin))}
+		kind := *typeFlag
+		if kind == "" {
+			if *abiFlag == "-" {
+				return fmt.Errorf("no type specified (-type) for the ABI from STDIN")
+			}
+			kind = strings.TrimSuffix(filepath.Base(*abiFlag), filepath.Ext(*abiFlag))
+		}
+		types = []string{kind}
+	default:
+		return fmt.Errorf("no contract source specified (-abi or -sol)")
+	}
+
+	code, err := bind.Bind(types, abis, bins, *pkgFlag)
+	if err != nil {
+		return err
+	}
+	if *outFlag == "" {
+		fmt.Print(code)
+		return nil
+	}
+	return ioutil.WriteFile(*outFlag, []byte(code), 0600)
+}
@@ -0,0 +1,68 @@
+// Command hvmgen generates the typed Go bindings of the hvm (java) contracts from the hvm.abi, e.g.
+//
+//	hvmgen -abi hvm.abi -type Share -pkg share -out share.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/meshplus/gosdk/hvm/bind"
+)
+
+var (
+	abiFlag  = flag.String("abi", "", "Path to the hvm.abi of the contract to bind, - for STDIN")
+	typeFlag = flag.String("type", "", "Go struct name for the binding (default = ABI file name)")
+	pkgFlag  = flag.String("pkg", "", "Package name to generate the binding into")
+	outFlag  = flag.String("out", "", "Output file for the generated binding (default = stdout)")
+)
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "hvmgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	if *pkgFlag == "" {
+		return fmt.Errorf("no destination package specified (-pkg)")
+	}
+	if *abiFlag == "" {
+		return fmt.Errorf("no contract ABI specified (-abi)")
+	}
+	var (
+		abi []byte
+		err error
+	)
+	if *abiFlag == "-" {
+		abi, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		abi, err = ioutil.ReadFile(*abiFlag)
+	}
+	if err != nil {
+		return err
+	}
+	kind := *typeFlag
+	if kind == "" {
+		if *abiFlag == "-" {
+			return fmt.Errorf("no type specified (-type) for the ABI from STDIN")
+		}
+		kind = strings.TrimSuffix(filepath.Base(*abiFlag), filepath.Ext(*abiFlag))
+	}
+
+	code, err := bind.Bind([]string{kind}, []string{string(abi)}, *pkgFlag)
+	if err != nil {
+		return err
+	}
+	if *outFlag == "" {
+		fmt.Print(code)
+		return nil
+	}
+	return ioutil.WriteFile(*outFlag, []byte(code), 0600)
+}
@@ -12,9 +12,10 @@ const (
 )
 
 const (
-	JSONRPCNodes    = "jsonRPC.nodes"
-	JSONRPCPorts    = "jsonRPC.ports"
-	JSONRPCPriority = "jsonRPC.priority"
+	JSONRPCNodes       = "jsonRPC.nodes"
+	JSONRPCPorts       = "jsonRPC.ports"
+	JSONRPCPriority    = "jsonRPC.priority"
+	JSONRPCLoadBalance = "jsonRPC.loadBalance"
 )
 
 const (
@@ -75,6 +76,22 @@ const (
 	TxVersion = "tx.version"
 )
 
+const (
+	RateLimitSendTx   = "rateLimit.sendTx"
+	RateLimitSimulate = "rateLimit.simulate"
+	RateLimitQuery    = "rateLimit.query"
+	RateLimitBurst    = "rateLimit.burst"
+	RateLimitNodes    = "rateLimit.nodes"
+)
+
+const (
+	HealthEnable           = "health.enable"
+	HealthInterval         = "health.interval"
+	HealthMaxBlockLag      = "health.maxBlockLag"
+	HealthFailureThreshold = "health.failureThreshold"
+	HealthOpenTimeout      = "health.openTimeout"
+)
+
 const (
 	CurveNameBN254 = "bn254"
 	CurveNameSM9   = "sm9"
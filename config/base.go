@@ -9,9 +9,10 @@ import (
 )
 
 type jsonRpc struct {
-	node     []string
-	ports    []string
-	priority []int
+	node        []string
+	ports       []string
+	priority    []int
+	loadBalance string
 }
 
 type webSocket struct {
@@ -72,6 +73,30 @@ type tx struct {
 	version string
 }
 
+type rateLimit struct {
+	sendTx   float64
+	simulate float64
+	query    float64
+	burst    int
+	nodes    []NodeRateLimit
+}
+
+// NodeRateLimit is the rate limits of the node at Index of jsonRPC.nodes, a nil limit is not set for the node
+type NodeRateLimit struct {
+	Index    int      `mapstructure:"index"`
+	SendTx   *float64 `mapstructure:"sendTx"`
+	Simulate *float64 `mapstructure:"simulate"`
+	Query    *float64 `mapstructure:"query"`
+}
+
+type health struct {
+	enable           bool
+	interval         int64
+	maxBlockLag      uint64
+	failureThreshold int
+	openTimeout      int64
+}
+
 type Config struct {
 	title         string
 	namespace     string
@@ -86,6 +111,8 @@ type Config struct {
 	transport     transport
 	inspector     inspector
 	tx            tx
+	health        health
+	rateLimit     rateLimit
 	vi            *viper.Viper
 }
 
@@ -99,9 +126,10 @@ func Default() *Config {
 		namespace:     "global",
 		reConnectTime: 10000,
 		jsonRpc: jsonRpc{
-			node:     []string{"localhost", "localhost", "localhost", "localhost"},
-			ports:    []string{"8081", "8082", "8083", "8084"},
-			priority: []int{0, 0, 0, 0},
+			node:        []string{"localhost", "localhost", "localhost", "localhost"},
+			ports:       []string{"8081", "8082", "8083", "8084"},
+			priority:    []int{0, 0, 0, 0},
+			loadBalance: "random",
 		},
 		webSocket: webSocket{
 			ports: []string{"10001", "10002", "10003", "10004"},
@@ -152,6 +180,19 @@ func Default() *Config {
 		tx: tx{
 			version: "2.5",
 		},
+		health: health{
+			enable:           false,
+			interval:         5000,
+			maxBlockLag:      10,
+			failureThreshold: 0,
+			openTimeout:      10000,
+		},
+		rateLimit: rateLimit{
+			sendTx:   0,
+			simulate: 0,
+			query:    0,
+			burst:    10,
+		},
 		vi: viper.New(),
 	}
 }
@@ -166,11 +207,13 @@ func NewFromFile(path string) (*Config, error) {
 	common.InitLog(vip)
 	cc := Default()
 	cc.vi = vip
-	cc.load()
+	if err = cc.load(); err != nil {
+		return nil, err
+	}
 	return cc, nil
 }
 
-func (c *Config) load() {
+func (c *Config) load() error {
 	c.loadBase()
 	c.loadJsonRPC()
 	c.loadWebSocket()
@@ -182,6 +225,8 @@ func (c *Config) load() {
 	c.loadTransport()
 	c.loadInspector()
 	c.loadTx()
+	c.loadHealth()
+	return c.loadRateLimit()
 }
 
 func (c *Config) loadBase() {
@@ -219,6 +264,9 @@ func (c *Config) loadJsonRPC() {
 	if c.vi.Get(common.JSONRPCPriority) != nil {
 		c.jsonRpc.priority = c.vi.GetIntSlice(common.JSONRPCPriority)
 	}
+	if c.vi.Get(common.JSONRPCLoadBalance) != nil {
+		c.jsonRpc.loadBalance = c.vi.GetString(common.JSONRPCLoadBalance)
+	}
 }
 
 func (c *Config) GetNodes() []string {
@@ -233,6 +281,10 @@ func (c *Config) GetPriority() []int {
 	return c.jsonRpc.priority
 }
 
+func (c *Config) GetLoadBalance() string {
+	return c.jsonRpc.loadBalance
+}
+
 func (c *Config) loadWebSocket() {
 	if c.vi.Get(common.WebSocketPorts) != nil {
 		c.webSocket.ports = c.vi.GetStringSlice(common.WebSocketPorts)
@@ -497,6 +549,87 @@ func (c *Config) GetTxVersion() string {
 	return c.tx.version
 }
 
+func (c *Config) loadHealth() {
+	if c.vi.Get(common.HealthEnable) != nil {
+		c.health.enable = c.vi.GetBool(common.HealthEnable)
+	}
+	if c.vi.Get(common.HealthInterval) != nil {
+		c.health.interval = c.vi.GetInt64(common.HealthInterval)
+	}
+	if c.vi.Get(common.HealthMaxBlockLag) != nil {
+		c.health.maxBlockLag = c.vi.GetUint64(common.HealthMaxBlockLag)
+	}
+	if c.vi.Get(common.HealthFailureThreshold) != nil {
+		c.health.failureThreshold = c.vi.GetInt(common.HealthFailureThreshold)
+	}
+	if c.vi.Get(common.HealthOpenTimeout) != nil {
+		c.health.openTimeout = c.vi.GetInt64(common.HealthOpenTimeout)
+	}
+}
+
+func (c *Config) IsHealthEnable() bool {
+	return c.health.enable
+}
+
+func (c *Config) GetHealthInterval() int64 {
+	return c.health.interval
+}
+
+func (c *Config) GetHealthMaxBlockLag() uint64 {
+	return c.health.maxBlockLag
+}
+
+func (c *Config) GetHealthFailureThreshold() int {
+	return c.health.failureThreshold
+}
+
+func (c *Config) GetHealthOpenTimeout() int64 {
+	return c.health.openTimeout
+}
+
+func (c *Config) loadRateLimit() error {
+	if c.vi.Get(common.RateLimitSendTx) != nil {
+		c.rateLimit.sendTx = c.vi.GetFloat64(common.RateLimitSendTx)
+	}
+	if c.vi.Get(common.RateLimitSimulate) != nil {
+		c.rateLimit.simulate = c.vi.GetFloat64(common.RateLimitSimulate)
+	}
+	if c.vi.Get(common.RateLimitQuery) != nil {
+		c.rateLimit.query = c.vi.GetFloat64(common.RateLimitQuery)
+	}
+	if c.vi.Get(common.RateLimitBurst) != nil {
+		c.rateLimit.burst = c.vi.GetInt(common.RateLimitBurst)
+	}
+	if c.vi.Get(common.RateLimitNodes) != nil {
+		var nodes []NodeRateLimit
+		if err := c.vi.UnmarshalKey(common.RateLimitNodes, &nodes); err != nil {
+			return fmt.Errorf("invalid %s: %v", common.RateLimitNodes, err)
+		}
+		c.rateLimit.nodes = nodes
+	}
+	return nil
+}
+
+func (c *Config) GetSendTxRateLimit() float64 {
+	return c.rateLimit.sendTx
+}
+
+func (c *Config) GetSimulateRateLimit() float64 {
+	return c.rateLimit.simulate
+}
+
+func (c *Config) GetQueryRateLimit() float64 {
+	return c.rateLimit.query
+}
+
+func (c *Config) GetRateLimitBurst() int {
+	return c.rateLimit.burst
+}
+
+func (c *Config) GetNodeRateLimits() []NodeRateLimit {
+	return c.rateLimit.nodes
+}
+
 func (c *Config) GetVipper() *viper.Viper {
 	return c.vi
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -12,3 +13,33 @@ func TestNewFromFile(t *testing.T) {
 	}
 	assert.Equal(t, cc.GetNamespace(), "global")
 }
+
+func TestConfig_GetLoadBalance(t *testing.T) {
+	cc, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "random", cc.GetLoadBalance())
+}
+
+func TestConfig_GetNodeRateLimits(t *testing.T) {
+	cc := Default()
+	cc.vi.SetConfigType("toml")
+	err := cc.vi.ReadConfig(strings.NewReader(`
+[rateLimit]
+    sendTx = 10
+    [[rateLimit.nodes]]
+        index = 1
+        sendTx = 100
+        query = 0
+`))
+	assert.Nil(t, err)
+	assert.Nil(t, cc.load())
+	assert.Equal(t, float64(10), cc.GetSendTxRateLimit())
+	limits := cc.GetNodeRateLimits()
+	assert.Len(t, limits, 1)
+	assert.Equal(t, 1, limits[0].Index)
+	assert.Equal(t, float64(100), *limits[0].SendTx)
+	assert.Nil(t, limits[0].Simulate)
+	assert.Equal(t, float64(0), *limits[0].Query)
+}
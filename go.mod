@@ -26,6 +26,8 @@ require (
 	github.com/stretchr/testify v1.8.0
 	github.com/syndtr/goleveldb v1.0.0
 	golang.org/x/crypto v0.0.0-20220622213112-05595931fe9d
+	golang.org/x/sys v0.0.0-20220520151302-bc2c85ada10a
+	golang.org/x/text v0.3.7
 	google.golang.org/grpc v1.46.2
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15
 )
@@ -301,7 +301,8 @@ func (beanAbi BeanAbi) checkMethodInputs(params []string) bool {
 		return false
 	}
 	for i, input := range beanAbi.Inputs {
-		if input.StructName != params[i] {
+		// the lists and maps without StructName are matched by the class in Name
+		if input.StructName != params[i] && (input.StructName != "" || input.Name != params[i]) {
 			return false
 		}
 	}
@@ -28,4 +28,11 @@ func TestAbi_GetMethodAbi(t *testing.T) {
 	methodAbi4, err := abi.GetMethodAbi("Hello(int,java.lang.String)")
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(methodAbi4.Inputs))
+
+	// the list without StructName
+	methodAbi5, err := abi.GetMethodAbi("shareMoney(java.lang.String,int,java.util.ArrayList)")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(methodAbi5.Inputs))
+	_, err = abi.GetMethodAbi("shareMoney(java.lang.String,int,)")
+	assert.Nil(t, err)
 }
@@ -0,0 +1,154 @@
+package bind
+
+import (
+	"errors"
+	"fmt"
+
+	evm "github.com/meshplus/gosdk/abi2/bind"
+	"github.com/meshplus/gosdk/common"
+	"github.com/meshplus/gosdk/hvm"
+	"github.com/meshplus/gosdk/rpc"
+)
+
+// the options and the backends are shared with the bindings of the solidity contracts
+type (
+	// TransactOpts is the collection of options to create a valid transaction
+	TransactOpts = evm.TransactOpts
+	// ContractBackend is the connection to the chain which the bound contracts work with
+	ContractBackend = evm.ContractBackend
+	// Subscription is the delivering of the logs started by ContractBackend.WatchLogs
+	Subscription = evm.Subscription
+	// GRPCBackend is the ContractBackend working with the gRPC of the chain
+	GRPCBackend = evm.GRPCBackend
+	// RevertError is the error of a failed transaction, the message of the java exception is in the receipt
+	RevertError = evm.RevertError
+)
+
+// ErrNoKey is returned if the opts do not carry the key to sign the transaction
+var ErrNoKey = evm.ErrNoKey
+
+// NewRPCBackend return a ContractBackend working with the json rpc of r
+func NewRPCBackend(r *rpc.RPC) ContractBackend {
+	return evm.NewRPCBackend(r)
+}
+
+// NewGRPCBackend return a ContractBackend working with the gRPC of g
+func NewGRPCBackend(g *rpc.GRPC, opt rpc.ClientOption) *GRPCBackend {
+	return evm.NewGRPCBackend(g, opt)
+}
+
+// BoundContract is the base wrapper object that reflects a hvm contract on the chain,
+// it contains a collection of methods used by the generated bindings
+type BoundContract struct {
+	address string
+	abi     hvm.Abi
+	backend ContractBackend
+}
+
+// NewBoundContract creates a low level contract interface through which the beans may be invoked
+func NewBoundContract(address string, abi hvm.Abi, backend ContractBackend) *BoundContract {
+	return &BoundContract{
+		address: address,
+		abi:     abi,
+		backend: backend,
+	}
+}
+
+// DeployContract deploys the jar onto the chain and binds the deployment address with a Go wrapper,
+// jar is the payload read by rpc.DecompressFromJar
+func DeployContract(opts *TransactOpts, abi hvm.Abi, jar []byte, backend ContractBackend) (string, *rpc.TxReceipt, *BoundContract, error) {
+	c := NewBoundContract("", abi, backend)
+	receipt, err := c.Deploy(opts, jar)
+	if err != nil {
+		return "", receipt, nil, err
+	}
+	return c.address, receipt, c, nil
+}
+
+// Address return the address of the contract
+func (c *BoundContract) Address() string {
+	return c.address
+}
+
+// ABI return the abi of the contract
+func (c *BoundContract) ABI() hvm.Abi {
+	return c.abi
+}
+
+// Deploy deploys the jar, the address of c is set by the receipt
+func (c *BoundContract) Deploy(opts *TransactOpts, jar []byte) (*rpc.TxReceipt, error) {
+	from, err := opts.Sender()
+	if err != nil {
+		return nil, err
+	}
+	tx := rpc.NewTransaction(from).Deploy(common.Bytes2Hex(jar)).VMType(rpc.HVM).Simulate(opts.Simulate)
+	receipt, err := c.backend.DeployContract(opts.Context, tx, opts.Key)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReceipt(receipt); err != nil {
+		return receipt, err
+	}
+	c.address = receipt.ContractAddress
+	return receipt, nil
+}
+
+// InvokeBean invokes the invoke bean named beanName with the fields of bean, see hvm.GenBeanPayload,
+// the result is decoded into out by hvm.DecodeResult if out is not nil
+func (c *BoundContract) InvokeBean(opts *TransactOpts, beanName string, bean interface{}, out interface{}) (*rpc.TxReceipt, error) {
+	beanAbi, err := c.abi.GetBeanAbi(beanName)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := hvm.GenBeanPayload(beanAbi, bean)
+	if err != nil {
+		return nil, err
+	}
+	return c.invoke(opts, payload, out)
+}
+
+// InvokeMethod invokes the method bean of the signature with params, e.g. Hello(int,java.lang.String),
+// see hvm.GenMethodPayload, the result is decoded into out by hvm.DecodeResult if out is not nil
+func (c *BoundContract) InvokeMethod(opts *TransactOpts, method string, out interface{}, params ...interface{}) (*rpc.TxReceipt, error) {
+	methodAbi, err := c.abi.GetMethodAbi(method)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := hvm.GenMethodPayload(methodAbi, params...)
+	if err != nil {
+		return nil, err
+	}
+	return c.invoke(opts, payload, out)
+}
+
+func (c *BoundContract) invoke(opts *TransactOpts, payload []byte, out interface{}) (*rpc.TxReceipt, error) {
+	if c.address == "" {
+		return nil, errors.New("bind: the contract is not deployed")
+	}
+	from, err := opts.Sender()
+	if err != nil {
+		return nil, err
+	}
+	tx := rpc.NewTransaction(from).Invoke(c.address, payload).VMType(rpc.HVM).Simulate(opts.Simulate)
+	receipt, err := c.backend.InvokeContract(opts.Context, tx, opts.Key)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReceipt(receipt); err != nil {
+		return receipt, err
+	}
+	if out != nil {
+		if err := hvm.DecodeResult(receipt.Ret, out); err != nil {
+			return receipt, fmt.Errorf("bind: decode the result: %v", err)
+		}
+	}
+	return receipt, nil
+}
+
+// checkReceipt return a RevertError if the transaction failed
+func checkReceipt(receipt *rpc.TxReceipt) error {
+	if receipt == nil || receipt.Valid || receipt.ErrorMsg == "" {
+		return nil
+	}
+	return &RevertError{Receipt: receipt, Data: common.FromHex(receipt.Ret)}
+}
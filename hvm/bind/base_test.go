@@ -0,0 +1,96 @@
+package bind
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/meshplus/gosdk/abi2/bind/bindtest"
+	"github.com/meshplus/gosdk/account"
+	"github.com/meshplus/gosdk/common"
+	"github.com/meshplus/gosdk/hvm"
+	"github.com/meshplus/gosdk/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestContract(t *testing.T) (*BoundContract, *bindtest.Backend, *account.ECDSAKey) {
+	abiJson, err := common.ReadFileAsString("../../hvmtestfile/methodInvoke/hvm.abi")
+	assert.Nil(t, err)
+	abi, err := hvm.GenAbi(abiJson)
+	assert.Nil(t, err)
+	key, err := account.NewAccountFromPriv("a1fd6ed6225e76aac3884b5420c8cdbb4fde1db01e9ef773415b8f2b5a9b77d4")
+	assert.Nil(t, err)
+	backend := &bindtest.Backend{}
+	return NewBoundContract("0x000000000000000000000000000000000000abcd", abi, backend), backend, key
+}
+
+func TestBoundContract_Deploy(t *testing.T) {
+	c, backend, key := newTestContract(t)
+	backend.Receipt = &rpc.TxReceipt{Valid: true, ContractAddress: "0x0000000000000000000000000000000000001234"}
+	address, receipt, bound, err := DeployContract(&TransactOpts{Key: key}, c.ABI(), []byte{0xca, 0xfe}, backend)
+	assert.Nil(t, err)
+	assert.Equal(t, backend.Receipt, receipt)
+	assert.Equal(t, "0x0000000000000000000000000000000000001234", address)
+	assert.Equal(t, address, bound.Address())
+	assert.Equal(t, "0xcafe", backend.Txs[0].GetPayload())
+	assert.Equal(t, key.GetAddress().Hex(), backend.Txs[0].GetFrom())
+
+	_, _, _, err = DeployContract(nil, c.ABI(), []byte{0xca, 0xfe}, backend)
+	assert.Equal(t, ErrNoKey, err)
+}
+
+func TestBoundContract_InvokeBean(t *testing.T) {
+	c, backend, key := newTestContract(t)
+	backend.Receipt = &rpc.TxReceipt{Valid: true, Ret: common.Bytes2Hex([]byte("true"))}
+	bean := struct {
+		Friend string   `json:"friend"`
+		Value  int32    `json:"value"`
+		FList  []string `json:"fList"`
+	}{"friend1", 100, []string{"friend2"}}
+	var out bool
+	_, err := c.InvokeBean(&TransactOpts{Key: key}, "cn.hyperchain.invoke.ShareInvoke", &bean, &out)
+	assert.Nil(t, err)
+	assert.True(t, out)
+
+	tx := backend.Txs[0]
+	assert.Equal(t, c.Address(), tx.GetTo())
+	payload, err := hvm.DecodePayload(tx.GetPayload()[2:])
+	assert.Nil(t, err)
+	assert.Equal(t, "cn.hyperchain.invoke.ShareInvoke", payload.InvokeBeanName)
+	assert.Equal(t, `{"friend":"friend1","value":100,"fList":["friend2"]}`, payload.InvokeArgs)
+
+	_, err = c.InvokeBean(&TransactOpts{Key: key}, "cn.hyperchain.invoke.NoSuchInvoke", &bean, &out)
+	assert.NotNil(t, err)
+}
+
+func TestBoundContract_InvokeMethod(t *testing.T) {
+	c, backend, key := newTestContract(t)
+	backend.Receipt = &rpc.TxReceipt{Valid: true, Ret: common.Bytes2Hex([]byte("hello 1"))}
+	var out string
+	_, err := c.InvokeMethod(&TransactOpts{Key: key}, "Hello(int,java.lang.String)", &out, int32(1), "hello")
+	assert.Nil(t, err)
+	assert.Equal(t, "hello 1", out)
+	expected := hvm.NewParamBuilder("Hello").Addint(1).AddString("hello").Build()
+	assert.Equal(t, "0xfefffbce"+common.Bytes2Hex(expected[8:]), backend.Txs[0].GetPayload())
+
+	// the result of void is not decoded
+	_, err = c.InvokeMethod(&TransactOpts{Key: key}, "displayFriends()", nil)
+	assert.Nil(t, err)
+
+	_, err = c.InvokeMethod(&TransactOpts{Key: key}, "Hello(long)", &out, int64(1))
+	assert.NotNil(t, err)
+}
+
+func TestBoundContract_Failed(t *testing.T) {
+	c, backend, key := newTestContract(t)
+	backend.Receipt = &rpc.TxReceipt{Valid: false, ErrorMsg: "java.lang.RuntimeException: no friend"}
+	var out string
+	receipt, err := c.InvokeMethod(&TransactOpts{Key: key}, "Hello()", &out)
+	assert.Equal(t, backend.Receipt, receipt)
+	var revert *RevertError
+	assert.True(t, errors.As(err, &revert))
+	assert.Equal(t, "transaction failed: java.lang.RuntimeException: no friend", err.Error())
+	assert.Equal(t, "", out)
+
+	_, err = NewBoundContract("", c.ABI(), backend).InvokeMethod(&TransactOpts{Key: key}, "Hello()", &out)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,293 @@
+package bind
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/meshplus/gosdk/hvm"
+)
+
+// Bind generates the Go package named pkg of the typed bindings of the hvm contracts, types and abis
+// are the names and the hvm.abi json of the contracts in order
+func Bind(types []string, abis []string, pkg string) (string, error) {
+	if len(types) != len(abis) {
+		return "", fmt.Errorf("bind: %d types and %d abis are given", len(types), len(abis))
+	}
+	b := &binder{
+		data:    &tmplData{Package: pkg},
+		classes: make(map[string]hvm.Entry),
+		structs: make(map[string]*tmplStruct),
+		names:   make(map[string]bool),
+	}
+	contracts := make([]hvm.Abi, len(types))
+	for i, typ := range types {
+		abi, err := hvm.GenAbi(abis[i])
+		if err != nil {
+			return "", fmt.Errorf("bind: abi of %s: %v", typ, err)
+		}
+		contracts[i] = abi
+		for _, beanAbi := range abi {
+			for _, s := range beanAbi.Structs {
+				if _, ok := b.classes[s.Name]; !ok {
+					b.classes[s.Name] = s
+				}
+			}
+		}
+		b.names[capitalise(typ)] = true
+	}
+
+	for i, typ := range types {
+		compacted := new(bytes.Buffer)
+		if err := json.Compact(compacted, []byte(abis[i])); err != nil {
+			return "", fmt.Errorf("bind: abi of %s: %v", typ, err)
+		}
+		contract := &tmplContract{Type: capitalise(typ), InputABI: compacted.String()}
+		methods := make(map[string]bool)
+		for _, beanAbi := range contracts[i] {
+			var err error
+			switch beanAbi.BeanType {
+			case hvm.MethodBean:
+				err = b.bindMethod(contract, beanAbi, methods)
+			default:
+				err = b.bindBean(contract, beanAbi, methods)
+			}
+			if err != nil {
+				return "", fmt.Errorf("bind: bean %s of %s: %v", beanAbi.BeanName, typ, err)
+			}
+		}
+		b.data.Contracts = append(b.data.Contracts, contract)
+	}
+
+	buffer := new(bytes.Buffer)
+	tmpl := template.Must(template.New("").Parse(tmplSource))
+	if err := tmpl.Execute(buffer, b.data); err != nil {
+		return "", err
+	}
+	code, err := format.Source(buffer.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("bind: %v\n%s", err, buffer)
+	}
+	return string(code), nil
+}
+
+// binder collect the generated types of the contracts, the structs of the same java class are generated once
+type binder struct {
+	data    *tmplData
+	classes map[string]hvm.Entry
+	structs map[string]*tmplStruct
+	// names is the used names of the generated types
+	names map[string]bool
+}
+
+// bindBean bind the invoke bean as a struct of the inputs and a method invoking it
+func (b *binder) bindBean(contract *tmplContract, beanAbi hvm.BeanAbi, methods map[string]bool) error {
+	simple := simpleName(beanAbi.BeanName)
+	bean := &tmplBean{
+		Name:       beanAbi.BeanName,
+		Normalized: methodName(simple, methods),
+		Struct:     uniqueName(capitalise(simple), b.names),
+	}
+	for _, input := range beanAbi.Inputs {
+		typ, err := b.bindType(input)
+		if err != nil {
+			return fmt.Errorf("input %s: %v", input.Name, err)
+		}
+		bean.Fields = append(bean.Fields, tmplField{Name: capitalise(input.Name), Type: typ, Tag: input.Name})
+	}
+	output, err := b.bindOutput(beanAbi.Output)
+	if err != nil {
+		return err
+	}
+	bean.Output = output
+	contract.Beans = append(contract.Beans, bean)
+	return nil
+}
+
+// bindMethod bind the method bean as a method with the typed params, the overloaded methods
+// are suffixed by the index, e.g. Hello, Hello0, Hello1
+func (b *binder) bindMethod(contract *tmplContract, beanAbi hvm.BeanAbi, methods map[string]bool) error {
+	method := &tmplMethod{Normalized: methodName(beanAbi.BeanName, methods)}
+	classes := make([]string, len(beanAbi.Inputs))
+	for i, input := range beanAbi.Inputs {
+		typ, err := b.bindType(input)
+		if err != nil {
+			return fmt.Errorf("input %d: %v", i, err)
+		}
+		method.Inputs = append(method.Inputs, tmplArg{Name: fmt.Sprintf("arg%d", i), Type: typ})
+		classes[i] = className(input)
+	}
+	method.Signature = beanAbi.BeanName + "(" + strings.Join(classes, ",") + ")"
+	output, err := b.bindOutput(beanAbi.Output)
+	if err != nil {
+		return err
+	}
+	method.Output = output
+	method.Java = fmt.Sprintf("%s %s(%s)", className(beanAbi.Output), beanAbi.BeanName, strings.Join(classes, ", "))
+	contract.Methods = append(contract.Methods, method)
+	return nil
+}
+
+// bindOutput return the go type of the result, empty for void
+func (b *binder) bindOutput(output hvm.Entry) (string, error) {
+	if output.EntryType == hvm.Void || output.EntryType == "" {
+		return "", nil
+	}
+	typ, err := b.bindType(output)
+	if err != nil {
+		return "", fmt.Errorf("output: %v", err)
+	}
+	return typ, nil
+}
+
+// bindType return the go type of the java type, which is the same as the json of it
+func (b *binder) bindType(e hvm.Entry) (string, error) {
+	switch e.EntryType {
+	case hvm.Bool:
+		return "bool", nil
+	case hvm.Char, hvm.String:
+		return "string", nil
+	case hvm.Byte:
+		return "int8", nil
+	case hvm.Short:
+		return "int16", nil
+	case hvm.Int:
+		return "int32", nil
+	case hvm.Long:
+		return "int64", nil
+	case hvm.Float:
+		return "float32", nil
+	case hvm.Double:
+		return "float64", nil
+	case hvm.List, hvm.Array:
+		if len(e.Properties) != 1 {
+			return "", fmt.Errorf("%s %s without the element type", e.EntryType, e.Name)
+		}
+		elem, err := b.bindElem(e.Properties[0])
+		return "[]" + elem, err
+	case hvm.Map:
+		if len(e.Properties) != 2 {
+			return "", fmt.Errorf("map %s without the key and value types", e.Name)
+		}
+		val, err := b.bindElem(e.Properties[1])
+		return "map[" + bindKey(e.Properties[0]) + "]" + val, err
+	case hvm.Struct:
+		return b.bindStruct(e.StructName)
+	}
+	return "", fmt.Errorf("unsupported type %s of %s", e.EntryType, e.Name)
+}
+
+// bindElem return the go type of the element of a list, an array or a map, the nested lists,
+// arrays and maps are wrapped by an entry of the same type, see hvm.BeanAbi.resolveNestListOrMap
+func (b *binder) bindElem(e hvm.Entry) (string, error) {
+	switch e.EntryType {
+	case hvm.List, hvm.Array, hvm.Map:
+		if len(e.Properties) != 1 {
+			return "", fmt.Errorf("nested %s %s without the element type", e.EntryType, e.Name)
+		}
+		return b.bindType(e.Properties[0])
+	}
+	return b.bindType(e)
+}
+
+// bindKey return the go type of the map key, the keys which are not the numbers are the json strings
+func bindKey(e hvm.Entry) string {
+	switch e.EntryType {
+	case hvm.Byte:
+		return "int8"
+	case hvm.Short:
+		return "int16"
+	case hvm.Int:
+		return "int32"
+	case hvm.Long:
+		return "int64"
+	}
+	return "string"
+}
+
+// bindStruct return the name of the struct of the java class, the classes without the properties,
+// e.g. java.lang.Object, are interface{}
+func (b *binder) bindStruct(class string) (string, error) {
+	if s, ok := b.structs[class]; ok {
+		if !s.bound {
+			// the recursive class is referred by the pointer
+			return "*" + s.Name, nil
+		}
+		return s.Name, nil
+	}
+	entry, ok := b.classes[class]
+	if !ok {
+		return "interface{}", nil
+	}
+	name := capitalise(simpleName(class))
+	if b.names[name] {
+		name = capitalise(strings.Replace(class, ".", "_", -1))
+	}
+	s := &tmplStruct{Name: uniqueName(name, b.names), Class: class}
+	// reserve the name before binding the properties
+	b.structs[class] = s
+	for _, p := range entry.Properties {
+		typ, err := b.bindType(p)
+		if err != nil {
+			return "", fmt.Errorf("property %s of %s: %v", p.Name, class, err)
+		}
+		s.Fields = append(s.Fields, tmplField{Name: capitalise(p.Name), Type: typ, Tag: p.Name})
+	}
+	s.bound = true
+	b.data.Structs = append(b.data.Structs, s)
+	return s.Name, nil
+}
+
+// methodName return the unique name of the contract method, the ones of the binding itself are suffixed by _
+func methodName(name string, methods map[string]bool) string {
+	name = capitalise(name)
+	if name == "Address" || name == "Contract" {
+		name += "_"
+	}
+	return uniqueName(name, methods)
+}
+
+// uniqueName return name or the name suffixed by the index which is not used, and mark it used
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for idx := 0; used[unique]; idx++ {
+		unique = fmt.Sprintf("%s%d", name, idx)
+	}
+	used[unique] = true
+	return unique
+}
+
+// className return the java class of the input or output of a method bean
+func className(e hvm.Entry) string {
+	if e.StructName != "" {
+		return e.StructName
+	}
+	return e.Name
+}
+
+// simpleName return the class name without the package
+func simpleName(class string) string {
+	return class[strings.LastIndex(class, ".")+1:]
+}
+
+// capitalise make the name an exported go identifier, the characters not allowed are replaced by _
+func capitalise(name string) string {
+	ident := []rune(strings.TrimLeft(name, "_$"))
+	for i, r := range ident {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			ident[i] = '_'
+		}
+	}
+	if len(ident) == 0 {
+		return "X"
+	}
+	if unicode.IsDigit(ident[0]) {
+		return "X" + string(ident)
+	}
+	ident[0] = unicode.ToUpper(ident[0])
+	return string(ident)
+}
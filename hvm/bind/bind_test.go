@@ -0,0 +1,84 @@
+package bind
+
+import (
+	"testing"
+
+	"github.com/meshplus/gosdk/abi2/bind/bindtest"
+	"github.com/meshplus/gosdk/common"
+	"github.com/stretchr/testify/assert"
+)
+
+const nodeABI = `[
+	{"version":"v1","beanName":"address","inputs":[{"name":"a.Node","type":"Struct","structName":"a.Node"}],"output":{"name":"a.Node","type":"Struct","structName":"a.Node"},"structs":[{"name":"a.Node","type":"Struct","properties":[{"name":"value","type":"Long","structName":"long"},{"name":"next","type":"Struct","structName":"a.Node"},{"name":"tags","type":"Map","properties":[{"name":"java.lang.String","type":"String","structName":"java.lang.String"},{"name":"java.util.List","type":"List","properties":[{"name":"java.util.List","type":"List","properties":[{"name":"java.lang.Character","type":"Char","structName":"java.lang.Character"}]}]}]}]}],"beanType":"MethodBean"},
+	{"version":"v1","beanName":"b.Person","inputs":[{"name":"name","type":"String","structName":"java.lang.String"}],"output":{"name":"void","type":"Void","structName":"void"},"structs":[],"beanType":"InvokeBean"},
+	{"version":"v1","beanName":"list","inputs":[{"name":"java.util.List","type":"List","properties":[{"name":"a.Person","type":"Struct","structName":"a.Person"}]}],"output":{"name":"java.lang.Object","type":"Struct","structName":"java.lang.Object"},"structs":[{"name":"a.Person","type":"Struct","properties":[{"name":"age","type":"Short","structName":"short"}]}],"beanType":"MethodBean"}
+]`
+
+func readABI(t *testing.T, path string) string {
+	abi, err := common.ReadFileAsString(path)
+	assert.Nil(t, err)
+	return abi
+}
+
+func TestBind(t *testing.T) {
+	code, err := Bind([]string{"share", "Easy"}, []string{readABI(t, "../../hvmtestfile/methodInvoke/hvm.abi"), readABI(t, "../../hvmtestfile/hvm.abi")}, "contracts")
+	assert.Nil(t, err)
+
+	for _, want := range []string{
+		"func DeployShare(opts *bind.TransactOpts, backend bind.ContractBackend, jar []byte) (string, *rpc.TxReceipt, *Share, error) {",
+		"func NewShare(address string, backend bind.ContractBackend) (*Share, error) {",
+		"type ShareInvoke struct {",
+		"FList  []string `json:\"fList\"`",
+		"func (_Share *Share) ShareInvoke(opts *bind.TransactOpts, bean *ShareInvoke) (bool, *rpc.TxReceipt, error) {",
+		"func (_Share *Share) ShareMoney(opts *bind.TransactOpts, arg0 string, arg1 int32, arg2 []string) (bool, *rpc.TxReceipt, error) {",
+		`_Share.contract.InvokeMethod(opts, "shareMoney(java.lang.String,int,java.util.ArrayList)", &out, arg0, arg1, arg2)`,
+		"// Java: boolean shareMoney(java.lang.String, int, java.util.ArrayList)",
+		"M      map[int32]string `json:\"m\"`",
+		"func (_Share *Share) DisplayMan(opts *bind.TransactOpts, arg0 Man) (*rpc.TxReceipt, error) {",
+		"func (_Share *Share) Hello(opts *bind.TransactOpts, arg0 int32, arg1 string) (string, *rpc.TxReceipt, error) {",
+		"func (_Share *Share) Hello0(opts *bind.TransactOpts, arg0 string) (string, *rpc.TxReceipt, error) {",
+		"func (_Share *Share) Hello1(opts *bind.TransactOpts) (string, *rpc.TxReceipt, error) {",
+		"type Person struct {",
+		"Bean1s  []Bean1                `json:\"bean1s\"`",
+		"HashMap map[string]interface{} `json:\"hashMap\"`",
+		"NestedMap  map[string]map[string]string `json:\"nestedMap\"`",
+		"NestedList [][]string                   `json:\"nestedList\"`",
+		"AByte      int8                         `json:\"aByte\"`",
+		"func (_Easy *Easy) ArraysTestInvoke(opts *bind.TransactOpts, bean *ArraysTestInvoke) ([]string, *rpc.TxReceipt, error) {",
+	} {
+		assert.Contains(t, code, want)
+	}
+
+	_, err = Bind([]string{"share"}, nil, "contracts")
+	assert.NotNil(t, err)
+	_, err = Bind([]string{"share"}, []string{"["}, "contracts")
+	assert.NotNil(t, err)
+}
+
+func TestBind_Names(t *testing.T) {
+	code, err := Bind([]string{"graph"}, []string{nodeABI}, "contracts")
+	assert.Nil(t, err)
+
+	for _, want := range []string{
+		"type Node struct {",
+		"Next  *Node               `json:\"next\"`",
+		"Tags  map[string][]string `json:\"tags\"`",
+		"func (_Graph *Graph) Address_(opts *bind.TransactOpts, arg0 Node) (Node, *rpc.TxReceipt, error) {",
+		// the bean and the class of the same simple name
+		"type Person struct {",
+		"func (_Graph *Graph) Person(opts *bind.TransactOpts, bean *Person) (*rpc.TxReceipt, error) {",
+		"type A_Person struct {",
+		"func (_Graph *Graph) List(opts *bind.TransactOpts, arg0 []A_Person) (interface{}, *rpc.TxReceipt, error) {",
+		`_Graph.contract.InvokeMethod(opts, "list(java.util.List)", &out, arg0)`,
+	} {
+		assert.Contains(t, code, want)
+	}
+}
+
+// TestBindBuild builds the generated package with the go tool
+func TestBindBuild(t *testing.T) {
+	code, err := Bind([]string{"Share", "Easy", "Node"}, []string{readABI(t, "../../hvmtestfile/methodInvoke/hvm.abi"), readABI(t, "../../hvmtestfile/hvm.abi"), nodeABI}, "contracts")
+	assert.Nil(t, err)
+
+	bindtest.Build(t, code)
+}
@@ -0,0 +1,150 @@
+package bind
+
+// tmplData is the data structure required to fill the binding template
+type tmplData struct {
+	Package   string
+	Contracts []*tmplContract
+	Structs   []*tmplStruct
+}
+
+// tmplContract contains the data needed to generate an individual contract binding
+type tmplContract struct {
+	Type     string
+	InputABI string
+	Beans    []*tmplBean
+	Methods  []*tmplMethod
+}
+
+// tmplBean is an invoke bean, which is bound as a struct of the inputs
+type tmplBean struct {
+	Name       string
+	Normalized string
+	Struct     string
+	Fields     []tmplField
+	Output     string
+}
+
+// tmplMethod is a method bean, which is bound as a method with the params
+type tmplMethod struct {
+	Normalized string
+	Signature  string
+	Java       string
+	Inputs     []tmplArg
+	Output     string
+}
+
+// tmplArg is a go parameter of the method
+type tmplArg struct {
+	Name string
+	Type string
+}
+
+// tmplField is a field of the generated structs, Tag is the name of the java field
+type tmplField struct {
+	Name string
+	Type string
+	Tag  string
+}
+
+// tmplStruct is the go struct of a java class
+type tmplStruct struct {
+	Name   string
+	Class  string
+	Fields []tmplField
+	bound  bool
+}
+
+// tmplSource is the Go source template that the generated Go contract binding is based on
+const tmplSource = `// Code generated by hvmgen - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package {{.Package}}
+
+import (
+	"github.com/meshplus/gosdk/hvm"
+	"github.com/meshplus/gosdk/hvm/bind"
+	"github.com/meshplus/gosdk/rpc"
+)
+{{range .Structs}}
+// {{.Name}} is an auto generated Go binding around the java class {{.Class}}.
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} ` + "`" + `json:"{{.Tag}}"` + "`" + `
+{{- end}}
+}
+{{end}}
+{{- range $contract := .Contracts}}
+// {{.Type}}ABI is the input ABI used to generate the binding from.
+const {{.Type}}ABI = {{printf "%q" .InputABI}}
+
+// Deploy{{.Type}} deploys the jar read by rpc.DecompressFromJar, binding an instance of {{.Type}} to it.
+func Deploy{{.Type}}(opts *bind.TransactOpts, backend bind.ContractBackend, jar []byte) (string, *rpc.TxReceipt, *{{.Type}}, error) {
+	contract, err := New{{.Type}}("", backend)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	receipt, err := contract.contract.Deploy(opts, jar)
+	if err != nil {
+		return "", receipt, nil, err
+	}
+	return contract.Address(), receipt, contract, nil
+}
+
+// {{.Type}} is an auto generated Go binding around a hvm contract.
+type {{.Type}} struct {
+	contract *bind.BoundContract
+}
+
+// New{{.Type}} creates a new instance of {{.Type}}, bound to a specific deployed contract.
+func New{{.Type}}(address string, backend bind.ContractBackend) (*{{.Type}}, error) {
+	parsed, err := hvm.GenAbi({{.Type}}ABI)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Type}}{contract: bind.NewBoundContract(address, parsed, backend)}, nil
+}
+
+// Address returns the address of the contract.
+func (_{{$contract.Type}} *{{$contract.Type}}) Address() string {
+	return _{{$contract.Type}}.contract.Address()
+}
+
+// Contract returns the generic binding of the contract.
+func (_{{$contract.Type}} *{{$contract.Type}}) Contract() *bind.BoundContract {
+	return _{{$contract.Type}}.contract
+}
+{{range .Beans}}
+// {{.Struct}} is an auto generated Go binding around the invoke bean {{.Name}}.
+type {{.Struct}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} ` + "`" + `json:"{{.Tag}}"` + "`" + `
+{{- end}}
+}
+
+// {{.Normalized}} is a paid mutator transaction binding the invoke bean {{.Name}}.
+func (_{{$contract.Type}} *{{$contract.Type}}) {{.Normalized}}(opts *bind.TransactOpts, bean *{{.Struct}}) ({{if .Output}}{{.Output}}, {{end}}*rpc.TxReceipt, error) {
+	{{- if .Output}}
+	var out {{.Output}}
+	receipt, err := _{{$contract.Type}}.contract.InvokeBean(opts, "{{.Name}}", bean, &out)
+	return out, receipt, err
+	{{- else}}
+	return _{{$contract.Type}}.contract.InvokeBean(opts, "{{.Name}}", bean, nil)
+	{{- end}}
+}
+{{end}}
+{{- range .Methods}}
+// {{.Normalized}} is a paid mutator transaction binding the method bean {{.Signature}}.
+//
+// Java: {{.Java}}
+func (_{{$contract.Type}} *{{$contract.Type}}) {{.Normalized}}(opts *bind.TransactOpts{{range .Inputs}}, {{.Name}} {{.Type}}{{end}}) ({{if .Output}}{{.Output}}, {{end}}*rpc.TxReceipt, error) {
+	{{- if .Output}}
+	var out {{.Output}}
+	receipt, err := _{{$contract.Type}}.contract.InvokeMethod(opts, "{{.Signature}}", &out{{range .Inputs}}, {{.Name}}{{end}})
+	return out, receipt, err
+	{{- else}}
+	return _{{$contract.Type}}.contract.InvokeMethod(opts, "{{.Signature}}", nil{{range .Inputs}}, {{.Name}}{{end}})
+	{{- end}}
+}
+{{end}}
+{{- end}}
+`
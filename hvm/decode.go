@@ -86,3 +86,17 @@ func decodePayloadInvoke(pl string) (*PayLoad, error) {
 		InvokeMethods:  invokeMethods,
 	}, nil
 }
+
+// DecodeResult decode the ret of the receipt into the value pointed at by v, the strings are returned
+// as the raw text and the other types are decoded as the json, v is not changed if ret is empty
+func DecodeResult(ret string, v interface{}) error {
+	raw := common.FromHex(ret)
+	if len(raw) == 0 {
+		return nil
+	}
+	if s, ok := v.(*string); ok {
+		*s = string(raw)
+		return nil
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -1,6 +1,7 @@
 package hvm
 
 import (
+	"github.com/meshplus/gosdk/common"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -17,3 +18,28 @@ func TestDecodePayload2(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "transfer", result.InvokeMethods[0])
 }
+
+func TestDecodeResult(t *testing.T) {
+	var s string
+	assert.Nil(t, DecodeResult(common.Bytes2Hex([]byte("hello")), &s))
+	assert.Equal(t, "hello", s)
+
+	var b bool
+	assert.Nil(t, DecodeResult("0x"+common.Bytes2Hex([]byte("true")), &b))
+	assert.True(t, b)
+
+	var i int32
+	assert.Nil(t, DecodeResult(common.Bytes2Hex([]byte("-12")), &i))
+	assert.Equal(t, int32(-12), i)
+
+	var list []string
+	assert.Nil(t, DecodeResult(common.Bytes2Hex([]byte(`["a","b"]`)), &list))
+	assert.Equal(t, []string{"a", "b"}, list)
+
+	// void
+	i = 1
+	assert.Nil(t, DecodeResult("0x", &i))
+	assert.Equal(t, int32(1), i)
+
+	assert.NotNil(t, DecodeResult(common.Bytes2Hex([]byte("abc")), &i))
+}
@@ -1,7 +1,9 @@
 package hvm
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/meshplus/gosdk/common"
 )
 
@@ -18,13 +20,6 @@ func GenPayload(beanAbi *BeanAbi, params ...interface{}) ([]byte, error) {
 
 // | class length(4B) | name length(2B) | class | class name | bin |
 func invokeBeanPayload(beanAbi *BeanAbi, params ...interface{}) ([]byte, error) {
-	classBytes := beanAbi.classBytes()
-
-	if len(classBytes) > 0xffff {
-		return nil, errors.New("the bean class is too large") // 64k
-	}
-
-	beanName := []byte(beanAbi.BeanName)
 	isJson := true
 	for _, str := range params {
 		if _, ok := str.(string); !ok {
@@ -43,8 +38,28 @@ func invokeBeanPayload(beanAbi *BeanAbi, params ...interface{}) ([]byte, error)
 	if err != nil {
 		return nil, err
 	}
-	binBytes := []byte(bin)
+	return beanPayload(beanAbi, []byte(bin))
+}
+
+// GenBeanPayload return the payload of the invoke bean, bean is marshalled as the json of the bean fields,
+// e.g. the struct with the fields tagged by the names of the inputs
+func GenBeanPayload(beanAbi *BeanAbi, bean interface{}) ([]byte, error) {
+	bin, err := json.Marshal(bean)
+	if err != nil {
+		return nil, err
+	}
+	return beanPayload(beanAbi, bin)
+}
+
+// beanPayload return the payload of the invoke bean with the json bin
+func beanPayload(beanAbi *BeanAbi, binBytes []byte) ([]byte, error) {
+	classBytes := beanAbi.classBytes()
+
+	if len(classBytes) > 0xffff {
+		return nil, errors.New("the bean class is too large") // 64k
+	}
 
+	beanName := []byte(beanAbi.BeanName)
 	result := make([]byte, 0)
 	classLenByte := common.IntToBytes4(len(classBytes))
 	nameLenByte := common.IntToBytes2(len(beanName))
@@ -80,3 +95,34 @@ func methodBeanPayload(methodAbi *BeanAbi, params ...interface{}) ([]byte, error
 	}
 	return paramBuilder.Build(), nil
 }
+
+// GenMethodPayload return the payload of the method bean with the typed go values, the primitives and
+// their wrappers are sent as the text, and the structs, arrays, lists and maps are sent as the json
+func GenMethodPayload(methodAbi *BeanAbi, params ...interface{}) ([]byte, error) {
+	if len(params) != len(methodAbi.Inputs) {
+		return nil, fmt.Errorf("method %s expects %d params, not %d", methodAbi.BeanName, len(methodAbi.Inputs), len(params))
+	}
+
+	paramBuilder := NewParamBuilder(methodAbi.BeanName)
+
+	for i, input := range methodAbi.Inputs {
+		// the lists and maps are named by the class without StructName
+		class := input.StructName
+		if class == "" {
+			class = input.Name
+		}
+		if class == "" {
+			return nil, errors.New("input StructName is empty")
+		}
+		if params[i] == nil {
+			return nil, fmt.Errorf("param %d of method %s is nil", i, methodAbi.BeanName)
+		}
+		switch input.EntryType {
+		case Bool, Byte, Short, Int, Long, Float, Double, Char, String:
+			paramBuilder.appendPayload([]byte(class), []byte(fmt.Sprintf("%v", params[i])))
+		default:
+			paramBuilder.AddObject(class, params[i])
+		}
+	}
+	return paramBuilder.Build(), nil
+}
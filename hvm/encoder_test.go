@@ -202,3 +202,60 @@ func TestRPC_MethodAbi(t *testing.T) {
 	//
 	//logger.Debug(receipt)
 }
+
+func TestGenBeanPayload(t *testing.T) {
+	abiJson, err := common.ReadFileAsString("../hvmtestfile/methodInvoke/hvm.abi")
+	assert.Nil(t, err)
+	abi, err := GenAbi(abiJson)
+	assert.Nil(t, err)
+	beanAbi, err := abi.GetBeanAbi("cn.hyperchain.invoke.ShareInvoke")
+	assert.Nil(t, err)
+
+	bean := struct {
+		Friend string   `json:"friend"`
+		Value  int32    `json:"value"`
+		FList  []string `json:"fList"`
+	}{"friend1", 100, []string{"friend2", "friend3"}}
+	payload, err := GenBeanPayload(beanAbi, bean)
+	assert.Nil(t, err)
+	expected, err := GenPayload(beanAbi, "friend1", "100", `["friend2","friend3"]`)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, payload)
+}
+
+func TestGenMethodPayload(t *testing.T) {
+	abiJson, err := common.ReadFileAsString("../hvmtestfile/methodInvoke/hvm.abi")
+	assert.Nil(t, err)
+	abi, err := GenAbi(abiJson)
+	assert.Nil(t, err)
+
+	methodAbi, err := abi.GetMethodAbi("Hello(int,java.lang.String)")
+	assert.Nil(t, err)
+	payload, err := GenMethodPayload(methodAbi, int32(1), "hi")
+	assert.Nil(t, err)
+	assert.Equal(t, NewParamBuilder("Hello").Addint(1).AddString("hi").Build(), payload)
+
+	methodAbi, err = abi.GetMethodAbi("shareMoney")
+	assert.Nil(t, err)
+	payload, err = GenMethodPayload(methodAbi, "friend1", int32(100), []string{"friend2"})
+	assert.Nil(t, err)
+	expected := NewParamBuilder("shareMoney").AddString("friend1").Addint(100).AddObject("java.util.ArrayList", []string{"friend2"}).Build()
+	assert.Equal(t, expected, payload)
+
+	man := struct {
+		Name   string           `json:"name"`
+		Number int32            `json:"number"`
+		M      map[int32]string `json:"m"`
+	}{"Jack", 111, map[int32]string{1: "111"}}
+	methodAbi, err = abi.GetMethodAbi("displayMan")
+	assert.Nil(t, err)
+	payload, err = GenMethodPayload(methodAbi, man)
+	assert.Nil(t, err)
+	expected = NewParamBuilder("displayMan").AddObject("cn.hyperchain.bean.Man", `{"name":"Jack","number":111,"m":{"1":"111"}}`).Build()
+	assert.Equal(t, expected, payload)
+
+	_, err = GenMethodPayload(methodAbi)
+	assert.NotNil(t, err)
+	_, err = GenMethodPayload(methodAbi, nil)
+	assert.NotNil(t, err)
+}
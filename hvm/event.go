@@ -0,0 +1,89 @@
+package hvm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DecodeEvent decode the data of a hvm log, it's the json of the event {"name": "...", "properties": {...}},
+// the properties are converted by the struct of the same name if there is one
+func (abi Abi) DecodeEvent(data []byte) (string, map[string]interface{}, error) {
+	var event struct {
+		Name       string                     `json:"name"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return "", nil, err
+	}
+	if event.Properties == nil {
+		// the event without name, the data is the properties
+		if err := json.Unmarshal(data, &event.Properties); err != nil {
+			return "", nil, err
+		}
+	}
+	entries := abi.eventProperties(event.Name)
+	args := make(map[string]interface{}, len(event.Properties))
+	for name, raw := range event.Properties {
+		value, err := decodeEventValue(raw, entries[name])
+		if err != nil {
+			return "", nil, fmt.Errorf("decode property %s error: %v", name, err)
+		}
+		args[name] = value
+	}
+	return event.Name, args, nil
+}
+
+// eventProperties return the properties of the struct named after the event
+func (abi Abi) eventProperties(name string) map[string]Entry {
+	entries := make(map[string]Entry)
+	if name == "" {
+		return entries
+	}
+	simple := name[strings.LastIndex(name, ".")+1:]
+	for _, beanAbi := range abi {
+		for _, s := range beanAbi.Structs {
+			if s.Name != name && s.Name != simple {
+				continue
+			}
+			for _, p := range s.Properties {
+				entries[p.Name] = p
+			}
+			return entries
+		}
+	}
+	return entries
+}
+
+// decodeEventValue decode the json value by the type of entry, the numbers are kept as json.Number
+// if the type is unknown
+func decodeEventValue(raw json.RawMessage, entry Entry) (interface{}, error) {
+	var (
+		value interface{}
+		err   error
+	)
+	switch entry.EntryType {
+	case Bool:
+		var v bool
+		err = json.Unmarshal(raw, &v)
+		value = v
+	case Byte, Short, Int, Long:
+		var v int64
+		err = json.Unmarshal(bytes.Trim(raw, `"`), &v)
+		value = v
+	case Float, Double:
+		var v float64
+		err = json.Unmarshal(bytes.Trim(raw, `"`), &v)
+		value = v
+	case Char, String:
+		var v string
+		err = json.Unmarshal(raw, &v)
+		value = v
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		err = decoder.Decode(&value)
+	}
+	return value, err
+}
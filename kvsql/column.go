@@ -192,6 +192,25 @@ func (c *Column) IsNull(rowIdx int) bool {
 	return nullByte&(1<<(uint(rowIdx)&7)) == 0
 }
 
+// validate report whether the column holds rows values of the size, or var length values if size is 0
+func (c *Column) validate(size int, rows int) bool {
+	if len(c.nullBitmap) < (rows+7)/8 {
+		return false
+	}
+	if size > 0 {
+		return len(c.data)/size >= rows
+	}
+	if len(c.offsets) < rows+1 {
+		return false
+	}
+	for i := 0; i < rows; i++ {
+		if c.offsets[i] < 0 || c.offsets[i] > c.offsets[i+1] {
+			return false
+		}
+	}
+	return c.offsets[rows] <= int64(len(c.data))
+}
+
 func (c *Column) isFixed() bool {
 	return c.elemBuf != nil
 }
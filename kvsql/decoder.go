@@ -2,48 +2,70 @@ package kvsql
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/meshplus/gosdk/kvsql/mysql"
+	"github.com/meshplus/gosdk/kvsql/types"
 	"github.com/meshplus/gosdk/kvsql/util/hack"
 	"math"
-	"unsafe"
 )
 
+// ErrMalformedResult is returned if the encoded result set is truncated or inconsistent
+var ErrMalformedResult = errors.New("kvsql: malformed result set")
+
+// buffer reads the encoded result set, a read past the end returns zero values and records ErrMalformedResult
 type buffer struct {
 	pos int
 	buf []byte
+	err error
 }
 
-func (b *buffer) readInt1() byte {
-	res := b.buf[b.pos]
-	b.pos++
+// next return the next n bytes without copying, nil if there are not enough
+func (b *buffer) next(n int) []byte {
+	if b.err != nil || n < 0 || n > len(b.buf)-b.pos {
+		b.err = ErrMalformedResult
+		return nil
+	}
+	res := b.buf[b.pos : b.pos+n]
+	b.pos += n
 	return res
 }
 
+func (b *buffer) readInt1() byte {
+	if p := b.next(1); p != nil {
+		return p[0]
+	}
+	return 0
+}
+
 func (b *buffer) readInt2() uint16 {
-	res := *(*uint16)(unsafe.Pointer(&b.buf[b.pos]))
-	b.pos += 2
-	return res
+	if p := b.next(2); p != nil {
+		return binary.LittleEndian.Uint16(p)
+	}
+	return 0
 }
 
 func (b *buffer) readInt3() uint32 {
-	b1 := uint32(b.readInt1())
-	b2 := uint32(b.readInt1())
-	b3 := uint32(b.readInt1())
-	return (b1 & 0xff) | ((b2 & 0xff) << 8) | ((b3 & 0xff) << 16)
+	if p := b.next(3); p != nil {
+		return uint32(p[0]) | uint32(p[1])<<8 | uint32(p[2])<<16
+	}
+	return 0
 }
 
 func (b *buffer) readInt4() uint32 {
-	res := *(*uint32)(unsafe.Pointer(&b.buf[b.pos]))
-	b.pos += 4
-	return res
+	if p := b.next(4); p != nil {
+		return binary.LittleEndian.Uint32(p)
+	}
+	return 0
 }
 
 func (b *buffer) readInt8() uint64 {
-	res := *(*uint64)(unsafe.Pointer(&b.buf[b.pos]))
-	b.pos += 8
-	return res
+	if p := b.next(8); p != nil {
+		return binary.LittleEndian.Uint64(p)
+	}
+	return 0
 }
 
 func (b *buffer) readLENENC() uint64 {
@@ -63,22 +85,25 @@ func (b *buffer) readLENENC() uint64 {
 	}
 }
 
-func (b *buffer) getBytes(len int) []byte {
-	res := make([]byte, len)
-	copy(res, b.buf[b.pos:b.pos+len])
-	b.pos += len
-	return res
-}
-
-func (b *buffer) readLenByteArray() []byte {
+// readLenBytes return the length encoded bytes without copying
+func (b *buffer) readLenBytes() []byte {
 	length := b.readLENENC()
 	if length == math.MaxUint64 {
 		return nil
 	}
-	if length == 0 {
-		return make([]byte, 0)
+	if length > uint64(len(b.buf)) {
+		b.err = ErrMalformedResult
+		return nil
+	}
+	return b.next(int(length))
+}
+
+func (b *buffer) readLenByteArray() []byte {
+	res := b.readLenBytes()
+	if res == nil {
+		return nil
 	}
-	return b.getBytes(int(length))
+	return append(make([]byte, 0, len(res)), res...)
 }
 
 type Field struct {
@@ -93,6 +118,44 @@ type Field struct {
 	originalColumnName string
 }
 
+// Name returns the column name.
+func (f *Field) Name() string {
+	return f.columnName
+}
+
+// Table returns the table name of the column.
+func (f *Field) Table() string {
+	return f.tableName
+}
+
+// Type returns the mysql type of the column, see mysql.TypeXXX.
+func (f *Field) Type() byte {
+	return f.colType
+}
+
+// Flag returns the mysql flag of the column, see mysql.XXXFlag.
+func (f *Field) Flag() uint16 {
+	return f.colFlag
+}
+
+// Decimals returns the decimals of the column.
+func (f *Field) Decimals() uint8 {
+	return f.colDecimals
+}
+
+// Length returns the max length of the column.
+func (f *Field) Length() uint32 {
+	return f.colLength
+}
+
+// durationFsp returns the fractional seconds part of the time column, which is at most 6.
+func (f *Field) durationFsp() int {
+	if fsp := int(f.colDecimals); fsp <= 6 {
+		return fsp
+	}
+	return int(types.DefaultFsp)
+}
+
 // resultChunk is used to append chunks to chunk.
 type resultChunk struct {
 	chk *Chunk
@@ -127,6 +190,11 @@ func (c ResultSet) Columns() []string {
 	return names
 }
 
+// Fields returns the column information.
+func (c ResultSet) Fields() []*Field {
+	return c.columnInfo
+}
+
 func (c ResultSet) RowNumber() int {
 	return c.rowNumber
 }
@@ -181,7 +249,8 @@ const (
 	decodeVersion1 = iota
 )
 
-// DecodeRecordSet use this method to decode call ret to a ResultSet Object
+// DecodeRecordSet use this method to decode call ret to a ResultSet Object,
+// it returns nil if the version is not supported or the ret is malformed
 func DecodeRecordSet(buf []byte) *ResultSet {
 	buff := &buffer{
 		buf: buf,
@@ -189,7 +258,11 @@ func DecodeRecordSet(buf []byte) *ResultSet {
 	}
 	switch buff.readInt1() {
 	case decodeVersion1:
-		return decodeV1(buff)
+		rs := decodeV1(buff)
+		if buff.err != nil {
+			return nil
+		}
+		return rs
 	default:
 		return nil
 	}
@@ -205,17 +278,23 @@ func decodeV1(buff *buffer) *ResultSet {
 		}
 	}
 
-	return &ResultSet{
+	rs := &ResultSet{
 		columnCount:  columnCount,
 		lastInsertID: 0,
 		updatedCount: 0,
 		columnInfo:   decodeColumnInfo(buff, int(columnCount)),
 		rowNumber:    int(buff.readInt4()),
-		resChunk:     decodeChunk(buff, int(columnCount)),
 	}
+	rs.resChunk = &resultChunk{chk: NewChunkWithColumn(decodeColumns(buff, rs.columnInfo, rs.rowNumber, true))}
+	return rs
 }
 
 func decodeColumnInfo(buff *buffer, columnCount int) []*Field {
+	// every field takes 12 bytes at least
+	if uint64(columnCount)*12 > uint64(len(buff.buf)) {
+		buff.err = ErrMalformedResult
+		return nil
+	}
 	fields := make([]*Field, 0, columnCount)
 	for i := 0; i < columnCount; i++ {
 		f := &Field{
@@ -234,20 +313,53 @@ func decodeColumnInfo(buff *buffer, columnCount int) []*Field {
 	return fields
 }
 
-func decodeChunk(buff *buffer, columnCount int) *resultChunk {
-	columns := make([]*Column, 0, columnCount)
-	for i := 0; i < columnCount; i++ {
-		data := buff.readLenByteArray()
-		nullBitMap := buff.readLenByteArray()
+// decodeColumns decode the columns of the chunk, the data is copied from the buffer if copyData,
+// the columns are checked against the fields so that the getters of the rows never read out of the data
+func decodeColumns(buff *buffer, fields []*Field, rowNumber int, copyData bool) []*Column {
+	columns := make([]*Column, 0, len(fields))
+	for _, f := range fields {
+		var data, nullBitMap []byte
+		if copyData {
+			data, nullBitMap = buff.readLenByteArray(), buff.readLenByteArray()
+		} else {
+			data, nullBitMap = buff.readLenBytes(), buff.readLenBytes()
+		}
 		length := buff.readLENENC()
+		if length > uint64(len(buff.buf)-buff.pos)/4 {
+			buff.err = ErrMalformedResult
+			return nil
+		}
 		offsets := make([]int64, length)
 		for j := uint64(0); j < length; j++ {
 			offsets[j] = int64(buff.readInt4())
 		}
 		col := NewColumnWithData(data, nullBitMap, offsets)
+		col.length = rowNumber
+		if buff.err != nil || !col.validate(fixedSize(f.colType), rowNumber) {
+			buff.err = ErrMalformedResult
+			return nil
+		}
 		columns = append(columns, col)
 	}
-	return &resultChunk{chk: NewChunkWithColumn(columns)}
+	return columns
+}
+
+// fixedSize return the size of the values of the fixed length column type, 0 for the var length ones
+func fixedSize(tp byte) int {
+	switch tp {
+	case mysql.TypeTiny:
+		return 1
+	case mysql.TypeShort, mysql.TypeYear:
+		return 2
+	case mysql.TypeInt24, mysql.TypeLong, mysql.TypeFloat:
+		return 4
+	case mysql.TypeLonglong, mysql.TypeDouble, mysql.TypeDuration:
+		return 8
+	case mysql.TypeDate, mysql.TypeDatetime, mysql.TypeTimestamp:
+		return SizeTime
+	default:
+		return 0
+	}
 }
 
 // NewChunkWithColumn create a empty chunk to resultChunk
@@ -0,0 +1,43 @@
+package mysql
+
+// escapes is the backslash escape of the special characters in a mysql string literal,
+// see https://dev.mysql.com/doc/refman/5.7/en/string-literals.html
+var escapes = [256]byte{
+	0:      '0',
+	'\n':   'n',
+	'\r':   'r',
+	'\x1a': 'Z',
+	'\'':   '\'',
+	'"':    '"',
+	'\\':   '\\',
+}
+
+// AppendEscaped append the string with the special characters backslash escaped,
+// which is safe between the quotes of a string literal
+func AppendEscaped(buf []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		if e := escapes[s[i]]; e != 0 {
+			buf = append(buf, '\\', e)
+		} else {
+			buf = append(buf, s[i])
+		}
+	}
+	return buf
+}
+
+// AppendQuoted append the string as a single quoted string literal
+func AppendQuoted(buf []byte, s string) []byte {
+	buf = append(buf, '\'')
+	buf = AppendEscaped(buf, s)
+	return append(buf, '\'')
+}
+
+// EscapeString return the string with the special characters backslash escaped
+func EscapeString(s string) string {
+	return string(AppendEscaped(make([]byte, 0, len(s)+8), s))
+}
+
+// QuoteString return the string as a single quoted string literal, e.g. 'it\'s'
+func QuoteString(s string) string {
+	return string(AppendQuoted(make([]byte, 0, len(s)+10), s))
+}
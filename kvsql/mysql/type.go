@@ -53,3 +53,19 @@ const (
 	MaxFloatPrecisionLength  = 24
 	MaxDoublePrecisionLength = 53
 )
+
+// MySQL column flags.
+const (
+	NotNullFlag       uint16 = 1 << 0
+	PriKeyFlag        uint16 = 1 << 1
+	UniqueKeyFlag     uint16 = 1 << 2
+	MultipleKeyFlag   uint16 = 1 << 3
+	BlobFlag          uint16 = 1 << 4
+	UnsignedFlag      uint16 = 1 << 5
+	ZerofillFlag      uint16 = 1 << 6
+	BinaryFlag        uint16 = 1 << 7
+	EnumFlag          uint16 = 1 << 8
+	AutoIncrementFlag uint16 = 1 << 9
+	TimestampFlag     uint16 = 1 << 10
+	SetFlag           uint16 = 1 << 11
+)
@@ -1,6 +1,9 @@
 package kvsql
 
 import (
+	"bytes"
+
+	"github.com/meshplus/gosdk/kvsql/mysql"
 	"github.com/meshplus/gosdk/kvsql/types"
 )
 
@@ -125,3 +128,77 @@ func (r Row) GetDecimal(colIdx int) *types.MyDecimal {
 func (r Row) GetSet(colIdx int) types.Set {
 	return r.c.columns[colIdx].GetSet(r.idx)
 }
+
+// GetValue returns the value with the colIdx typed by the field of the column, the values are
+//
+//	integer                   int64, or uint64 for bigint unsigned
+//	float, double             float64
+//	decimal                   *types.MyDecimal
+//	date, datetime, timestamp types.Time
+//	time                      types.Duration
+//	enum                      types.Enum
+//	set                       types.Set
+//	char, varchar, json       string
+//	binary, blob, bit         []byte
+//	NULL                      nil
+func (r Row) GetValue(colIdx int, f *Field) interface{} {
+	if r.IsNull(colIdx) {
+		return nil
+	}
+	unsigned := f.Flag()&mysql.UnsignedFlag > 0
+	switch f.Type() {
+	case mysql.TypeTiny:
+		if unsigned {
+			return int64(r.GetUint8(colIdx))
+		}
+		return int64(r.GetInt8(colIdx))
+	case mysql.TypeShort:
+		if unsigned {
+			return int64(r.GetUint16(colIdx))
+		}
+		return int64(r.GetInt16(colIdx))
+	case mysql.TypeYear:
+		return int64(r.GetUint16(colIdx))
+	case mysql.TypeInt24, mysql.TypeLong:
+		if unsigned {
+			return int64(r.GetUint32(colIdx))
+		}
+		return int64(r.GetInt32(colIdx))
+	case mysql.TypeLonglong:
+		if unsigned {
+			return r.GetUint64(colIdx)
+		}
+		return r.GetInt64(colIdx)
+	case mysql.TypeFloat:
+		return float64(r.GetFloat32(colIdx))
+	case mysql.TypeDouble:
+		return r.GetFloat64(colIdx)
+	case mysql.TypeNewDecimal:
+		return r.GetDecimal(colIdx)
+	case mysql.TypeDate, mysql.TypeDatetime, mysql.TypeTimestamp:
+		return r.GetTime(colIdx)
+	case mysql.TypeDuration:
+		return r.GetDuration(colIdx, f.durationFsp())
+	case mysql.TypeEnum:
+		return r.GetEnum(colIdx)
+	case mysql.TypeSet:
+		return r.GetSet(colIdx)
+	case mysql.TypeTinyBlob, mysql.TypeMediumBlob, mysql.TypeLongBlob, mysql.TypeBlob, mysql.TypeBit:
+		return append([]byte{}, r.GetBytes(colIdx)...)
+	default:
+		switch {
+		case f.Flag()&mysql.EnumFlag > 0:
+			return r.GetEnum(colIdx)
+		case f.Flag()&mysql.SetFlag > 0:
+			return r.GetSet(colIdx)
+		case f.Flag()&mysql.BinaryFlag > 0 && f.Type() != mysql.TypeJSON:
+			return append([]byte{}, r.GetBytes(colIdx)...)
+		}
+		// note: will split by zero byte as ResultSet.ToExecuteResult
+		data := r.GetBytes(colIdx)
+		if i := bytes.IndexByte(data, 0); i != -1 {
+			data = data[:i]
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,204 @@
+package kvsql
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Rows iterates the rows of an encoded result set without building the ResultSet, e.g.
+//
+//	rows, err := kvsql.NewRows(common.FromHex(receipt.Ret))
+//	for rows.Next() {
+//		var u User
+//		if err := rows.ScanStruct(&u); err != nil {
+//			...
+//		}
+//	}
+//	err = rows.Err()
+//
+// The chunk is decoded on the first Next without copying the ret, so the ret should not be
+// modified until the iteration is done, and the values are decoded when scanned.
+type Rows struct {
+	buff         *buffer
+	fields       []*Field
+	rowNumber    int
+	lastInsertID uint64
+	updatedCount uint32
+
+	chk  *Chunk
+	next int
+	row  Row
+	err  error
+
+	structType reflect.Type
+	structCols [][]int
+}
+
+// NewRows decode the header and the fields of the encoded result set
+func NewRows(ret []byte) (*Rows, error) {
+	buff := &buffer{buf: ret}
+	if version := buff.readInt1(); buff.err == nil && version != decodeVersion1 {
+		return nil, fmt.Errorf("kvsql: unsupported result version %d", version)
+	}
+	r := &Rows{buff: buff}
+	if columnCount := buff.readInt4(); columnCount == 0 {
+		r.updatedCount = buff.readInt4()
+		r.lastInsertID = buff.readInt8()
+	} else {
+		r.fields = decodeColumnInfo(buff, int(columnCount))
+		r.rowNumber = int(buff.readInt4())
+	}
+	if buff.err != nil {
+		return nil, buff.err
+	}
+	return r, nil
+}
+
+// Fields return the fields of the columns
+func (r *Rows) Fields() []*Field {
+	return r.fields
+}
+
+// Columns return the names of the columns
+func (r *Rows) Columns() []string {
+	names := make([]string, len(r.fields))
+	for i, f := range r.fields {
+		names[i] = f.columnName
+	}
+	return names
+}
+
+// RowNumber return the number of the rows
+func (r *Rows) RowNumber() int {
+	return r.rowNumber
+}
+
+// LastInsertID return the last insert id of the statement without columns
+func (r *Rows) LastInsertID() uint64 {
+	return r.lastInsertID
+}
+
+// UpdatedCount return the updated count of the statement without columns
+func (r *Rows) UpdatedCount() uint32 {
+	return r.updatedCount
+}
+
+// Next prepare the next row for Scan, it returns false when there are no more rows or an error occurs
+func (r *Rows) Next() bool {
+	if r.err != nil || r.next >= r.rowNumber {
+		return false
+	}
+	if r.chk == nil {
+		columns := decodeColumns(r.buff, r.fields, r.rowNumber, false)
+		if r.buff.err != nil {
+			r.err = r.buff.err
+			return false
+		}
+		r.chk = NewChunkWithColumn(columns)
+	}
+	r.row = r.chk.GetRow(r.next)
+	r.next++
+	return true
+}
+
+// Err return the error occurred during the iteration
+func (r *Rows) Err() error {
+	return r.err
+}
+
+// Row return the current row
+func (r *Rows) Row() Row {
+	return r.row
+}
+
+// Values return the values of the current row typed as Row.GetValue
+func (r *Rows) Values() ([]interface{}, error) {
+	if r.row.IsEmpty() {
+		return nil, errors.New("kvsql: Values called without Next")
+	}
+	values := make([]interface{}, len(r.fields))
+	for i, f := range r.fields {
+		values[i] = r.row.GetValue(i, f)
+	}
+	return values, nil
+}
+
+// Scan copy the columns of the current row into the values pointed at by dest, see ScanValue
+// for the conversions, dest may be nil to skip the column
+func (r *Rows) Scan(dest ...interface{}) error {
+	if r.row.IsEmpty() {
+		return errors.New("kvsql: Scan called without Next")
+	}
+	if len(dest) != len(r.fields) {
+		return fmt.Errorf("kvsql: expected %d destination arguments in Scan, not %d", len(r.fields), len(dest))
+	}
+	for i, d := range dest {
+		if d == nil {
+			continue
+		}
+		if err := ScanValue(d, r.row.GetValue(i, r.fields[i])); err != nil {
+			return fmt.Errorf("kvsql: scanning column %s: %v", r.fields[i].columnName, err)
+		}
+	}
+	return nil
+}
+
+// ScanStruct copy the columns of the current row into the fields of the struct pointed at by dest,
+// the column of a field is named by the kvsql tag, or matches the field name case-insensitively, e.g.
+//
+//	type User struct {
+//		ID      int64            `kvsql:"autoId"`
+//		Name    *string          // NULL as nil
+//		Balance types.MyDecimal
+//		Ignored string           `kvsql:"-"`
+//	}
+//
+// the columns without fields are skipped.
+func (r *Rows) ScanStruct(dest interface{}) error {
+	if r.row.IsEmpty() {
+		return errors.New("kvsql: ScanStruct called without Next")
+	}
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("kvsql: ScanStruct expects a non-nil pointer to struct, not %T", dest)
+	}
+	v = v.Elem()
+	if v.Type() != r.structType {
+		r.structType, r.structCols = v.Type(), structColumns(v.Type(), r.fields)
+	}
+	for i, index := range r.structCols {
+		if index == nil {
+			continue
+		}
+		if err := ScanValue(v.FieldByIndex(index).Addr().Interface(), r.row.GetValue(i, r.fields[i])); err != nil {
+			return fmt.Errorf("kvsql: scanning column %s: %v", r.fields[i].columnName, err)
+		}
+	}
+	return nil
+}
+
+// structColumns return the index of the struct field of every column, nil for the columns without fields
+func structColumns(t reflect.Type, fields []*Field) [][]int {
+	names := make(map[string][]int)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name := sf.Name
+		if tag, ok := sf.Tag.Lookup("kvsql"); ok {
+			if tag == "-" {
+				continue
+			}
+			name = tag
+		}
+		names[strings.ToLower(name)] = sf.Index
+	}
+	cols := make([][]int, len(fields))
+	for i, f := range fields {
+		cols[i] = names[strings.ToLower(f.columnName)]
+	}
+	return cols
+}
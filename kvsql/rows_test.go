@@ -0,0 +1,148 @@
+package kvsql
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/meshplus/gosdk/common/hexutil"
+	"github.com/meshplus/gosdk/kvsql/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type scanRecord struct {
+	ID       int64           `kvsql:"autoId"`
+	Tiny     int8            `kvsql:"type0"`
+	Int      *uint32         `kvsql:"type5"`
+	Bigint   uint64          `kvsql:"type7"`
+	Float    float32         `kvsql:"type8"`
+	Decimal  types.MyDecimal `kvsql:"type10"`
+	Varchar  sql.NullString  `kvsql:"type11"`
+	Datetime time.Time       `kvsql:"type13"`
+	Duration time.Duration   `kvsql:"type16"`
+	Type17   string
+	Ignored  string `kvsql:"-"`
+}
+
+func TestRows(t *testing.T) {
+	b, _ := hexutil.Decode(setEnumDecimalRet)
+	rows, err := NewRows(b)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, rows.RowNumber())
+	assert.Equal(t, "autoId", rows.Columns()[0])
+
+	var records []scanRecord
+	for rows.Next() {
+		var r scanRecord
+		assert.Nil(t, rows.ScanStruct(&r))
+		records = append(records, r)
+	}
+	assert.Nil(t, rows.Err())
+	assert.Len(t, records, 3)
+
+	assert.Equal(t, int64(1), records[0].ID)
+	assert.Equal(t, int8(-128), records[0].Tiny)
+	assert.Equal(t, uint32(0), *records[0].Int)
+	assert.Equal(t, "-0.999999999999999999999999999999", records[0].Decimal.String())
+	assert.True(t, records[0].Varchar.Valid)
+	assert.Equal(t, "a1", records[0].Type17)
+	assert.Equal(t, records[0].Datetime.Format("2006-01-02 15:04:05"), DecodeRecordSet(b).GetRow(0).GetTime(14).String())
+
+	// NULL
+	assert.Nil(t, records[1].Int)
+	assert.False(t, records[1].Varchar.Valid)
+	assert.Equal(t, 11*time.Hour+59*time.Minute+59*time.Second, records[1].Duration)
+
+	assert.Equal(t, uint64(18446744073709551615), records[2].Bigint)
+	assert.Equal(t, float32(3.4028200e+38), records[2].Float)
+	assert.Equal(t, "a999", records[2].Type17)
+	assert.False(t, rows.Next())
+}
+
+func TestRows_Scan(t *testing.T) {
+	b, _ := hexutil.Decode(setEnumDecimalRet)
+	rows, err := NewRows(b)
+	assert.Nil(t, err)
+	assert.NotNil(t, rows.Scan())
+
+	var (
+		id      int
+		tiny    int8
+		tinyU   string
+		dec     *types.MyDecimal
+		blob    []byte
+		enum    types.Enum
+		set     interface{}
+		varchar *string
+		decF    float64
+		dest    = make([]interface{}, len(rows.Fields()))
+	)
+	dest[0], dest[1], dest[2], dest[11], dest[12], dest[13], dest[18], dest[19] = &id, &tiny, &tinyU, &dec, &varchar, &blob, &enum, &set
+	assert.True(t, rows.Next())
+	assert.Nil(t, rows.Scan(dest...))
+	assert.Equal(t, 1, id)
+	assert.Equal(t, int8(-128), tiny)
+	assert.Equal(t, "0", tinyU)
+	assert.Equal(t, "-0.999999999999999999999999999999", dec.String())
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 1}, blob)
+	assert.NotNil(t, varchar)
+	assert.Equal(t, "a1", enum.Name)
+	assert.Equal(t, "a2", set)
+
+	assert.True(t, rows.Next())
+	assert.Nil(t, rows.Scan(dest...))
+	assert.Nil(t, varchar)
+	values, err := rows.Values()
+	assert.Nil(t, err)
+	assert.Nil(t, values[6])
+
+	// NULL into a non-pointer
+	var i int
+	dest[6] = &i
+	assert.NotNil(t, rows.Scan(dest...))
+
+	// overflow
+	assert.True(t, rows.Next())
+	dest[6], dest[1], dest[2], dest[11] = nil, &tinyU, nil, &decF
+	assert.Nil(t, rows.Scan(dest...))
+	assert.Equal(t, "127", tinyU)
+	assert.InDelta(t, 1.0, decF, 1e-9)
+	dest[6] = &tiny
+	assert.NotNil(t, rows.Scan(dest...))
+	assert.False(t, rows.Next())
+	assert.Nil(t, rows.Err())
+}
+
+func TestRows_Update(t *testing.T) {
+	b, _ := hexutil.Decode("0x0000000000030000000300000000000000")
+	rows, err := NewRows(b)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(3), rows.UpdatedCount())
+	assert.Equal(t, uint64(3), rows.LastInsertID())
+	assert.False(t, rows.Next())
+	assert.Nil(t, rows.Err())
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	b, _ := hexutil.Decode(setEnumDecimalRet)
+	for i := 0; i < len(b); i++ {
+		assert.Nil(t, DecodeRecordSet(b[:i]), i)
+		rows, err := NewRows(b[:i])
+		if err != nil {
+			assert.Equal(t, ErrMalformedResult, err)
+			continue
+		}
+		for rows.Next() {
+		}
+		assert.Equal(t, ErrMalformedResult, rows.Err(), i)
+	}
+
+	// the offsets beyond the data
+	c := append([]byte{}, b...)
+	c[len(c)-1] = 0xff
+	assert.Nil(t, DecodeRecordSet(c))
+}
+
+// setEnumDecimalRet is the result of a select of 3 rows and 20 columns, type10 is a decimal,
+// type13 a datetime, type16 a time, type17 and type18 are an enum and a set
+const setEnumDecimalRet = "0x00140000000d746573745461626c654e616d650d746573745461626c654e616d65066175746f4964066175746f49643f000b000000030502000d746573745461626c654e616d650d746573745461626c654e616d650574797065300574797065303f0004000000010300000d746573745461626c654e616d650d746573745461626c654e616d650574797065310574797065313f0003000000012000000d746573745461626c654e616d650d746573745461626c654e616d650574797065320574797065323f0006000000020800000d746573745461626c654e616d650d746573745461626c654e616d650574797065330574797065333f0005000000022800000d746573745461626c654e616d650d746573745461626c654e616d650574797065340574797065343f000b000000030800000d746573745461626c654e616d650d746573745461626c654e616d650574797065350574797065353f000a000000032800000d746573745461626c654e616d650d746573745461626c654e616d650574797065360574797065363f0014000000080800000d746573745461626c654e616d650d746573745461626c654e616d650574797065370574797065373f0014000000082000000d746573745461626c654e616d650d746573745461626c654e616d650574797065380574797065383f000c0000000400001f0d746573745461626c654e616d650d746573745461626c654e616d650574797065390574797065393f00160000000500001f0d746573745461626c654e616d650d746573745461626c654e616d6506747970653130067479706531303f0020000000f600001e0d746573745461626c654e616d650d746573745461626c654e616d6506747970653131067479706531312e00fc030000fd0000000d746573745461626c654e616d650d746573745461626c654e616d6506747970653132067479706531323f0040000000102000000d746573745461626c654e616d650d746573745461626c654e616d6506747970653133067479706531333f00130000000c8000000d746573745461626c654e616d650d746573745461626c654e616d6506747970653134067479706531343f0013000000078000000d746573745461626c654e616d650d746573745461626c654e616d6506747970653135067479706531353f000a0000000a8000000d746573745461626c654e616d650d746573745461626c654e616d6506747970653136067479706531363f000a0000000b8000000d746573745461626c654e616d650d746573745461626c654e616d6506747970653137067479706531372e0010000000fe0000000d746573745461626c654e616d650d746573745461626c654e616d6506747970653138067479706531382e00d4030000fe000000030000000c010000000200000003000000010701000000000380007f01070100000000030080ff010701000000000600800000ff7f010701000000000600000080ffff010701000000000c0000008000000000ffffff7f010701000000000c0000000000000000ffffffff010501000000001800000000000000800100000000000000ffffffffffffff7f010701000000001800000000000000000100000000000000ffffffffffffffff010701000000000c010000000000803feeff7f7f01070100000000180100000000000000000000000000f03fffffffffffffef7f01070100000000612d302e393939393939393939393939393939393939393939393939393939393939302e303030303030303030303030303030303030303030303030303030303030302e39393939393939393939393939393939393939393939393939393939393901070400000000210000004100000061000000fc00016a6d3751446761437a48656836743835776d57616b783759673438744d32484e3041736f4c715154556f695a746e6139636e38537152594937675661526a636e324f376c4f6b6647344f773043485173346258754a596d744933385673764b69645557475555306a4d5542704f6a6268664a4844694c48696b6c58756f676b616f30303537634b3158763244774f57456339583339336d3742717a656b364466373641594959566d414f356a784c75504945456542494161355078433934746c75784161516a3671716d32714232446b656c33626478336c4252454542497577735642764f716e687077756b39634b4767726b54393542545a5366506e4b6b5601050400000000ff000000ff00000000010000180000000000000001000000000000000100000000000000010107040000000008000000100000001800000018000000000042a00f0000000000420400000000000042e02e01070100000000180100000100426c1f010000873366881f010000873366d81f01070100000000180e0000000042ac0f0e000000008c8d1f0e00000000063f9c0107010000000018006a868ef644f5ff00b60c0d4a2700000036b455944e00000107010000000009613161313161393939010704000000000200000005000000090000000661326131613101070400000000020000000400000006000000"
@@ -0,0 +1,248 @@
+package kvsql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/meshplus/gosdk/kvsql/types"
+)
+
+var errNilPtr = errors.New("destination pointer is nil")
+
+// ScanValue copy the value typed as Row.GetValue into the value pointed at by dest.
+// NULL can be scanned into pointers as nil, *[]byte, *interface{} and sql.Scanner only,
+// and the other conversions are
+//
+//	*string              from strings, []byte and the text of the numbers and the kvsql types
+//	*[]byte              from []byte and strings
+//	*int, *uint...       from the integers and decimals which do not overflow
+//	*float32, *float64   from the integers, floats and decimals
+//	*bool                from the integers
+//	*time.Time           from types.Time in UTC
+//	*time.Duration       from types.Duration
+//	*types.XXX           from the same kvsql type, and the strings or time.Time by the Scan of it
+//	sql.Scanner          from the value, with the kvsql types as time.Time or strings
+func ScanValue(dest interface{}, src interface{}) error {
+	if s, ok := dest.(sql.Scanner); ok {
+		switch dest.(type) {
+		case *types.Time, *types.Duration, *types.Enum, *types.Set, *types.MyDecimal:
+			// the kvsql types keep the values of the same type
+			if src == nil {
+				return fmt.Errorf("converting NULL to %T is unsupported", dest)
+			}
+			return s.Scan(src)
+		}
+		return s.Scan(DriverValue(src))
+	}
+	switch d := dest.(type) {
+	case *interface{}:
+		if d == nil {
+			return errNilPtr
+		}
+		*d = src
+		return nil
+	case *[]byte:
+		if d == nil {
+			return errNilPtr
+		}
+		switch s := src.(type) {
+		case nil:
+			*d = nil
+			return nil
+		case []byte:
+			*d = append([]byte{}, s...)
+			return nil
+		case string:
+			*d = []byte(s)
+			return nil
+		}
+	case *string:
+		if d == nil {
+			return errNilPtr
+		}
+		switch s := src.(type) {
+		case string:
+			*d = s
+			return nil
+		case []byte:
+			*d = string(s)
+			return nil
+		case int64:
+			*d = strconv.FormatInt(s, 10)
+			return nil
+		case uint64:
+			*d = strconv.FormatUint(s, 10)
+			return nil
+		case float64:
+			*d = strconv.FormatFloat(s, 'g', -1, 64)
+			return nil
+		case fmt.Stringer:
+			*d = s.String()
+			return nil
+		}
+	case *time.Time:
+		if d == nil {
+			return errNilPtr
+		}
+		if s, ok := src.(types.Time); ok {
+			t, err := s.CoreTime().GoTime(time.UTC)
+			if err != nil {
+				return err
+			}
+			*d = t
+			return nil
+		}
+	case *time.Duration:
+		if d == nil {
+			return errNilPtr
+		}
+		if s, ok := src.(types.Duration); ok {
+			*d = s.Duration
+			return nil
+		}
+	}
+
+	dv := reflect.ValueOf(dest)
+	if dv.Kind() != reflect.Ptr {
+		return fmt.Errorf("destination not a pointer: %T", dest)
+	}
+	if dv.IsNil() {
+		return errNilPtr
+	}
+	dv = dv.Elem()
+	if src == nil {
+		if dv.Kind() == reflect.Ptr {
+			dv.Set(reflect.Zero(dv.Type()))
+			return nil
+		}
+		return fmt.Errorf("converting NULL to %s is unsupported", dv.Type())
+	}
+	sv := reflect.ValueOf(src)
+	if sv.Type().AssignableTo(dv.Type()) {
+		dv.Set(sv)
+		return nil
+	}
+	// the decimals are scanned as *types.MyDecimal
+	if sv.Kind() == reflect.Ptr && sv.Elem().Type().AssignableTo(dv.Type()) {
+		dv.Set(sv.Elem())
+		return nil
+	}
+	if dv.Kind() == reflect.Ptr {
+		v := reflect.New(dv.Type().Elem())
+		if err := ScanValue(v.Interface(), src); err != nil {
+			return err
+		}
+		dv.Set(v)
+		return nil
+	}
+
+	switch dv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		switch s := src.(type) {
+		case int64:
+			i = s
+		case uint64:
+			if s > 1<<63-1 {
+				return fmt.Errorf("converting %d to %s overflows", s, dv.Type())
+			}
+			i = int64(s)
+		case *types.MyDecimal:
+			var err error
+			if i, err = s.ToInt(); err != nil {
+				return fmt.Errorf("converting %s to %s: %v", s, dv.Type(), err)
+			}
+		default:
+			return unsupportedScan(src, dv)
+		}
+		if dv.OverflowInt(i) {
+			return fmt.Errorf("converting %d to %s overflows", i, dv.Type())
+		}
+		dv.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		switch s := src.(type) {
+		case int64:
+			if s < 0 {
+				return fmt.Errorf("converting %d to %s overflows", s, dv.Type())
+			}
+			u = uint64(s)
+		case uint64:
+			u = s
+		case *types.MyDecimal:
+			var err error
+			if u, err = s.ToUint(); err != nil {
+				return fmt.Errorf("converting %s to %s: %v", s, dv.Type(), err)
+			}
+		default:
+			return unsupportedScan(src, dv)
+		}
+		if dv.OverflowUint(u) {
+			return fmt.Errorf("converting %d to %s overflows", u, dv.Type())
+		}
+		dv.SetUint(u)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		switch s := src.(type) {
+		case int64:
+			dv.SetFloat(float64(s))
+		case uint64:
+			dv.SetFloat(float64(s))
+		case float64:
+			dv.SetFloat(s)
+		case *types.MyDecimal:
+			f, err := s.ToFloat64()
+			if err != nil {
+				return fmt.Errorf("converting %s to %s: %v", s, dv.Type(), err)
+			}
+			dv.SetFloat(f)
+		default:
+			return unsupportedScan(src, dv)
+		}
+		return nil
+	case reflect.Bool:
+		switch s := src.(type) {
+		case int64:
+			dv.SetBool(s != 0)
+		case uint64:
+			dv.SetBool(s != 0)
+		default:
+			return unsupportedScan(src, dv)
+		}
+		return nil
+	case reflect.String:
+		// the named string types
+		var s string
+		if err := ScanValue(&s, src); err != nil {
+			return err
+		}
+		dv.SetString(s)
+		return nil
+	}
+	return unsupportedScan(src, dv)
+}
+
+func unsupportedScan(src interface{}, dv reflect.Value) error {
+	return fmt.Errorf("unsupported scan, storing %T into %s", src, dv.Type())
+}
+
+// DriverValue convert the value typed as Row.GetValue to a driver.Value, types.Time is time.Time in UTC,
+// or the string if it's out of the range of time.Time, and the other kvsql types are strings
+func DriverValue(src interface{}) driver.Value {
+	switch s := src.(type) {
+	case types.Time:
+		if t, err := s.CoreTime().GoTime(time.UTC); err == nil {
+			return t
+		}
+		return s.String()
+	case fmt.Stringer:
+		return s.String()
+	}
+	return src
+}
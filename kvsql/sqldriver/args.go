@@ -0,0 +1,44 @@
+package sqldriver
+
+import (
+	"database/sql/driver"
+	"errors"
+
+	"github.com/meshplus/gosdk/kvsql"
+	"github.com/meshplus/gosdk/kvsql/types"
+)
+
+// checkNamedValue accept the kvsql types which are bound by kvsql.NewDatum, and uint64 which the default
+// converter rejects if it overflows int64, the other values are converted by the default converter
+func checkNamedValue(nv *driver.NamedValue) error {
+	switch nv.Value.(type) {
+	case *types.MyDecimal, types.MyDecimal, types.Time, types.Duration, types.Enum, types.Set, uint64:
+		return nil
+	}
+	var err error
+	nv.Value, err = driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	return err
+}
+
+// interpolate replace the ? placeholders of the query with the arguments, as the KVSQL contract takes a plain sql
+func interpolate(query string, args []driver.NamedValue) (string, error) {
+	if len(args) == 0 {
+		return query, nil
+	}
+	s, err := kvsql.Prepare(query)
+	if err != nil {
+		return "", err
+	}
+	return bind(s, args)
+}
+
+func bind(s *kvsql.Stmt, args []driver.NamedValue) (string, error) {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return "", errors.New("kvsql: named arguments are not supported")
+		}
+		values[i] = arg.Value
+	}
+	return s.Bind(values...)
+}
@@ -0,0 +1,311 @@
+// Package sqldriver is a database/sql driver running sql on a KVSQL contract, e.g.
+//
+//	db, err := sql.Open("hyperchain-kvsql", "conf=../conf&account=./account.json&password=123&contract=0x1234...")
+//
+// Query runs the sql by a simulate transaction and Exec by a real transaction,
+// the transactions are signed by the account of the data source name.
+package sqldriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/meshplus/gosdk/account"
+	"github.com/meshplus/gosdk/common"
+	"github.com/meshplus/gosdk/config"
+	"github.com/meshplus/gosdk/kvsql"
+	"github.com/meshplus/gosdk/rpc"
+)
+
+// DriverName is the name the driver registered as
+const DriverName = "hyperchain-kvsql"
+
+// ErrTxNotSupported is returned by Begin, every statement is a transaction on chain already
+var ErrTxNotSupported = errors.New("kvsql: transactions are not supported")
+
+func init() {
+	sql.Register(DriverName, &Driver{})
+}
+
+// Driver is the database/sql driver of KVSQL contracts
+type Driver struct{}
+
+// Open return a new connection of the data source name, the connection owns the RPC created for it,
+// which is closed with the connection. Prefer sql.Open which shares the RPC among connections
+func (d *Driver) Open(dsn string) (driver.Conn, error) {
+	c, err := d.openConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{connector: c, closer: c}, nil
+}
+
+// OpenConnector parse the data source name, load the account and create the RPC
+func (d *Driver) OpenConnector(dsn string) (driver.Connector, error) {
+	return d.openConnector(dsn)
+}
+
+func (d *Driver) openConnector(dsn string) (*Connector, error) {
+	cfg, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	key, err := loadKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// NewRPCWithPath panics on a bad conf
+	if _, err := config.NewFromFile(cfg.ConfPath); err != nil {
+		return nil, err
+	}
+	c, err := NewConnector(rpc.NewRPCWithPath(cfg.ConfPath), key, cfg.Contract)
+	if err != nil {
+		return nil, err
+	}
+	c.driver = d
+	c.ownRPC = true
+	return c, nil
+}
+
+func loadKey(cfg *Config) (interface{}, error) {
+	if cfg.Account != "" {
+		accountJSON, err := readAccountJSON(cfg.Account)
+		if err != nil {
+			return nil, err
+		}
+		return account.GenKeyFromAccountJson(accountJSON, cfg.Password)
+	}
+	ks, err := account.NewKeystore(cfg.Keystore)
+	if err != nil {
+		return nil, err
+	}
+	if err := ks.Unlock(cfg.Address, cfg.Password, 0); err != nil {
+		return nil, err
+	}
+	return ks.GetKey(cfg.Address)
+}
+
+// Connector creates connections sharing one RPC, use it with sql.OpenDB
+type Connector struct {
+	driver   driver.Driver
+	rpc      *rpc.RPC
+	key      interface{}
+	from     string
+	contract string
+	ownRPC   bool
+}
+
+// NewConnector create a Connector running sql on the contract by hrpc, key is an account.Key,
+// an account.Signer, a *account.DIDKey or a *account.PKIKey which signs the transactions
+func NewConnector(hrpc *rpc.RPC, key interface{}, contract string) (*Connector, error) {
+	var from string
+	switch k := key.(type) {
+	case *account.DIDKey:
+		from = k.GetAddress()
+	case interface{ GetAddress() common.Address }:
+		from = k.GetAddress().Hex()
+	default:
+		return nil, errors.New("kvsql: unsupported key type")
+	}
+	return &Connector{driver: &Driver{}, rpc: hrpc, key: key, from: from, contract: contract}, nil
+}
+
+// Connect return a connection, it's cheap as the connections share the RPC
+func (c *Connector) Connect(context.Context) (driver.Conn, error) {
+	return &conn{connector: c}, nil
+}
+
+// Driver return the Driver
+func (c *Connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Close close the RPC created by the Connector, it's called by sql.DB.Close
+func (c *Connector) Close() error {
+	if c.ownRPC {
+		c.rpc.Close()
+	}
+	return nil
+}
+
+var _ io.Closer = (*Connector)(nil)
+
+type conn struct {
+	connector *Connector
+	// closer is the connector owned by the conn, see Driver.Open
+	closer io.Closer
+	closed bool
+}
+
+// Prepare return a statement, the placeholders are bound on the client by kvsql.Stmt
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(_ context.Context, query string) (driver.Stmt, error) {
+	if c.closed {
+		return nil, driver.ErrBadConn
+	}
+	prepared, err := kvsql.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &stmt{conn: c, prepared: prepared}, nil
+}
+
+func (c *conn) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	if c.closer != nil {
+		return c.closer.Close()
+	}
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return nil, ErrTxNotSupported
+}
+
+func (c *conn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return nil, ErrTxNotSupported
+}
+
+// CheckNamedValue accept the kvsql types as arguments besides the default ones
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	return checkNamedValue(nv)
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	query, err := interpolate(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return c.exec(ctx, query)
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	query, err := interpolate(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return c.query(ctx, query)
+}
+
+func (c *conn) exec(ctx context.Context, query string) (driver.Result, error) {
+	rs, err := c.run(ctx, query, false)
+	if err != nil {
+		return nil, err
+	}
+	return &result{lastInsertID: int64(rs.LastInsertID()), rowsAffected: int64(rs.UpdatedCount())}, nil
+}
+
+func (c *conn) query(ctx context.Context, query string) (driver.Rows, error) {
+	rs, err := c.run(ctx, query, true)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(rs), nil
+}
+
+// run send the bound sql by a simulate transaction for query or a real transaction for exec
+func (c *conn) run(ctx context.Context, query string, simulate bool) (*kvsql.ResultSet, error) {
+	if c.closed {
+		return nil, driver.ErrBadConn
+	}
+	tx := rpc.NewTransaction(c.connector.from).InvokeSql(c.connector.contract, []byte(query))
+	tx.VMType(rpc.KVSQL)
+	tx.Simulate(simulate)
+	receipt, stdErr := c.connector.rpc.WithContext(ctx).SignAndInvokeContract(tx, c.connector.key)
+	if stdErr != nil {
+		return nil, stdErr
+	}
+	ret := common.FromHex(receipt.Ret)
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("kvsql: empty result of %s", receipt.TxHash)
+	}
+	rs := kvsql.DecodeRecordSet(ret)
+	if rs == nil {
+		return nil, fmt.Errorf("kvsql: unsupported or malformed result of %s", receipt.TxHash)
+	}
+	return rs, nil
+}
+
+type stmt struct {
+	conn     *conn
+	prepared *kvsql.Stmt
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+// NumInput return the number of the placeholders, which is checked by database/sql
+func (s *stmt) NumInput() int {
+	return s.prepared.NumInput()
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	return checkNamedValue(nv)
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	query, err := bind(s.prepared, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.conn.exec(ctx, query)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	query, err := bind(s.prepared, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.conn.query(ctx, query)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+type result struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+func (r *result) LastInsertId() (int64, error) {
+	return r.lastInsertID, nil
+}
+
+func (r *result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+var (
+	_ driver.DriverContext      = (*Driver)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+	_ driver.StmtExecContext    = (*stmt)(nil)
+	_ driver.StmtQueryContext   = (*stmt)(nil)
+)
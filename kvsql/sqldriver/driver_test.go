@@ -0,0 +1,234 @@
+package sqldriver
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/meshplus/gosdk/account"
+	"github.com/meshplus/gosdk/common/hexutil"
+	"github.com/meshplus/gosdk/kvsql"
+	"github.com/meshplus/gosdk/kvsql/types"
+	"github.com/meshplus/gosdk/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// selectRet is the result of a select of 20 columns, type10 is a decimal, type13 a datetime,
+// type16 a time, and the flags of type17 and type18 are patched to enum and set
+var selectRet = strings.NewReplacer(
+	"067479706531372e0010000000fe000000", "067479706531372e0010000000fe000100",
+	"067479706531382e00d4030000fe000000", "067479706531382e00d4030000fe000800",
+).Replace("0x00140000000d746573745461626c654e616d650d746573745461626c654e616d65066175746f4964066175746f49643f000b000000030502000d746573745461626c654e616d650d746573745461626c654e616d650574797065300574797065303f0004000000010300000d746573745461626c654e616d650d746573745461626c654e616d650574797065310574797065313f0003000000012000000d746573745461626c654e616d650d746573745461626c654e616d650574797065320574797065323f0006000000020800000d746573745461626c654e616d650d746573745461626c654e616d650574797065330574797065333f0005000000022800000d746573745461626c654e616d650d746573745461626c654e616d650574797065340574797065343f000b000000030800000d746573745461626c654e616d650d746573745461626c654e616d650574797065350574797065353f000a000000032800000d746573745461626c654e616d650d746573745461626c654e616d650574797065360574797065363f0014000000080800000d746573745461626c654e616d650d746573745461626c654e616d650574797065370574797065373f0014000000082000000d746573745461626c654e616d650d746573745461626c654e616d650574797065380574797065383f000c0000000400001f0d746573745461626c654e616d650d746573745461626c654e616d650574797065390574797065393f00160000000500001f0d746573745461626c654e616d650d746573745461626c654e616d6506747970653130067479706531303f0020000000f600001e0d746573745461626c654e616d650d746573745461626c654e616d6506747970653131067479706531312e00fc030000fd0000000d746573745461626c654e616d650d746573745461626c654e616d6506747970653132067479706531323f0040000000102000000d746573745461626c654e616d650d746573745461626c654e616d6506747970653133067479706531333f00130000000c8000000d746573745461626c654e616d650d746573745461626c654e616d6506747970653134067479706531343f0013000000078000000d746573745461626c654e616d650d746573745461626c654e616d6506747970653135067479706531353f000a0000000a8000000d746573745461626c654e616d650d746573745461626c654e616d6506747970653136067479706531363f000a0000000b8000000d746573745461626c654e616d650d746573745461626c654e616d6506747970653137067479706531372e0010000000fe0000000d746573745461626c654e616d650d746573745461626c654e616d6506747970653138067479706531382e00d4030000fe000000030000000c010000000200000003000000010701000000000380007f01070100000000030080ff010701000000000600800000ff7f010701000000000600000080ffff010701000000000c0000008000000000ffffff7f010701000000000c0000000000000000ffffffff010501000000001800000000000000800100000000000000ffffffffffffff7f010701000000001800000000000000000100000000000000ffffffffffffffff010701000000000c010000000000803feeff7f7f01070100000000180100000000000000000000000000f03fffffffffffffef7f01070100000000612d302e393939393939393939393939393939393939393939393939393939393939302e303030303030303030303030303030303030303030303030303030303030302e39393939393939393939393939393939393939393939393939393939393901070400000000210000004100000061000000fc00016a6d3751446761437a48656836743835776d57616b783759673438744d32484e3041736f4c715154556f695a746e6139636e38537152594937675661526a636e324f376c4f6b6647344f773043485173346258754a596d744933385673764b69645557475555306a4d5542704f6a6268664a4844694c48696b6c58756f676b616f30303537634b3158763244774f57456339583339336d3742717a656b364466373641594959566d414f356a784c75504945456542494161355078433934746c75784161516a3671716d32714232446b656c33626478336c4252454542497577735642764f716e687077756b39634b4767726b54393542545a5366506e4b6b5601050400000000ff000000ff00000000010000180000000000000001000000000000000100000000000000010107040000000008000000100000001800000018000000000042a00f0000000000420400000000000042e02e01070100000000180100000100426c1f010000873366881f010000873366d81f01070100000000180e0000000042ac0f0e000000008c8d1f0e00000000063f9c0107010000000018006a868ef644f5ff00b60c0d4a2700000036b455944e00000107010000000009613161313161393939010704000000000200000005000000090000000661326131613101070400000000020000000400000006000000")
+
+// execRet is the result of an insert of 3 rows
+const execRet = "0x0000000000030000000300000000000000"
+
+func TestParseDSN(t *testing.T) {
+	cfg, err := ParseDSN("conf=../../conf&account=./account.json&password=123&contract=0x1234")
+	assert.Nil(t, err)
+	assert.Equal(t, &Config{ConfPath: "../../conf", Account: "./account.json", Password: "123", Contract: "0x1234"}, cfg)
+	again, err := ParseDSN(cfg.FormatDSN())
+	assert.Nil(t, err)
+	assert.Equal(t, cfg, again)
+
+	cfg, err = ParseDSN("conf=../../conf&keystore=./keystore&address=0xabcd&contract=0x1234")
+	assert.Nil(t, err)
+	assert.Equal(t, "./keystore", cfg.Keystore)
+
+	for _, dsn := range []string{
+		"account=./account.json&contract=0x1234",
+		"conf=../../conf&account=./account.json",
+		"conf=../../conf&contract=0x1234",
+		"conf=../../conf&keystore=./keystore&contract=0x1234",
+		"conf=../../conf&account=./account.json&contract=0x1234&unknown=1",
+	} {
+		_, err = ParseDSN(dsn)
+		assert.NotNil(t, err, dsn)
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	args := namedValues([]driver.Value{int64(1), "it's \"a\"\n", nil, []byte{0xde, 0xad}, 1.5, true,
+		time.Date(2022, 5, 6, 7, 8, 9, 0, time.UTC)})
+	query, err := interpolate("insert into t values (?, ?, ?, ?, ?, ?, ?)", args)
+	assert.Nil(t, err)
+	assert.Equal(t, `insert into t values (1, 'it\'s \"a\"\n', NULL, X'dead', 1.5, 1, '2022-05-06 07:08:09')`, query)
+
+	// the placeholders in quotes are kept
+	query, err = interpolate("select * from t where a = '?' and b = \"\\\"?\" and `?` = ?", namedValues([]driver.Value{"x"}))
+	assert.Nil(t, err)
+	assert.Equal(t, "select * from t where a = '?' and b = \"\\\"?\" and `?` = 'x'", query)
+
+	_, err = interpolate("select ?, ?", namedValues([]driver.Value{int64(1)}))
+	assert.NotNil(t, err)
+	_, err = interpolate("select ?", namedValues([]driver.Value{int64(1), int64(2)}))
+	assert.NotNil(t, err)
+	_, err = interpolate("select ?", []driver.NamedValue{{Name: "a", Ordinal: 1, Value: int64(1)}})
+	assert.NotNil(t, err)
+
+	dec := new(types.MyDecimal)
+	assert.Nil(t, dec.FromString([]byte("1.25")))
+	nv := driver.NamedValue{Value: dec}
+	assert.Nil(t, checkNamedValue(&nv))
+	assert.Equal(t, dec, nv.Value)
+	query, err = interpolate("select ?", []driver.NamedValue{nv})
+	assert.Nil(t, err)
+	assert.Equal(t, "select 1.25", query)
+	nv = driver.NamedValue{Value: int32(3)}
+	assert.Nil(t, checkNamedValue(&nv))
+	assert.Equal(t, int64(3), nv.Value)
+}
+
+func TestRows(t *testing.T) {
+	b, _ := hexutil.Decode(selectRet)
+	r := newRows(kvsql.DecodeRecordSet(b))
+	assert.Equal(t, "autoId", r.Columns()[0])
+	dest := make([]driver.Value, len(r.Columns()))
+	assert.Nil(t, r.Next(dest))
+
+	// the kvsql types are the values database/sql is able to convert
+	assert.Equal(t, int64(1), dest[0])
+	assert.Equal(t, "-0.999999999999999999999999999999", dest[11])
+	assert.IsType(t, time.Time{}, dest[14])
+	assert.IsType(t, "", dest[17])
+	assert.Equal(t, "a1", dest[18])
+	assert.Equal(t, "a2", dest[19])
+	for _, v := range dest {
+		// bigint unsigned is uint64, which database/sql converts as well
+		if _, ok := v.(uint64); !ok {
+			assert.True(t, driver.IsValue(v), "%T", v)
+		}
+	}
+
+	assert.Equal(t, "DECIMAL", r.ColumnTypeDatabaseTypeName(11))
+	assert.Equal(t, "ENUM", r.ColumnTypeDatabaseTypeName(18))
+	assert.Equal(t, "SET", r.ColumnTypeDatabaseTypeName(19))
+	assert.Equal(t, scanTypeString, r.ColumnTypeScanType(11))
+	assert.Equal(t, scanTypeTime, r.ColumnTypeScanType(14))
+	assert.Equal(t, scanTypeString, r.ColumnTypeScanType(18))
+	nullable, ok := r.ColumnTypeNullable(0)
+	assert.True(t, ok)
+	assert.False(t, nullable)
+
+	for i := 1; i < r.rs.RowNumber(); i++ {
+		assert.Nil(t, r.Next(dest))
+	}
+	assert.Equal(t, io.EOF, r.Next(dest))
+}
+
+func TestDriver(t *testing.T) {
+	var sqls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var req struct {
+			Method string                   `json:"method"`
+			Params []map[string]interface{} `json:"params"`
+		}
+		_ = json.Unmarshal(body, &req)
+		var result interface{}
+		switch req.Method {
+		case "simulate_invokeContract":
+			payload, _ := hexutil.Decode(req.Params[0]["payload"].(string))
+			sqls = append(sqls, string(payload[1:]))
+			result = map[string]string{"txHash": "0x01", "ret": selectRet}
+		case "contract_invokeContract":
+			payload, _ := hexutil.Decode(req.Params[0]["payload"].(string))
+			sqls = append(sqls, string(payload[1:]))
+			result = "0x02"
+		case "tx_getTransactionReceipt":
+			result = map[string]string{"txHash": "0x02", "ret": execRet}
+		default:
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":-32601,"message":"method not found"}`))
+			return
+		}
+		data, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "code": 0, "message": "SUCCESS", "result": result})
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+	host, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+
+	key, err := account.NewAccountFromPriv("a1fd6ed6225e76aac3884b5420c8cdbb4fde1db01e9ef773415b8f2b5a9b77b4")
+	assert.Nil(t, err)
+	connector, err := NewConnector(rpc.DefaultRPC(rpc.NewNode(host, port, port)), key, "0x0000000000000000000000000000000000000001")
+	assert.Nil(t, err)
+	db := sql.OpenDB(connector)
+	defer db.Close()
+
+	res, err := db.Exec("insert into t values (?, ?)", 1, "a'b")
+	assert.Nil(t, err)
+	affected, _ := res.RowsAffected()
+	assert.Equal(t, int64(3), affected)
+	id, _ := res.LastInsertId()
+	assert.Equal(t, int64(3), id)
+
+	var (
+		autoID   int64
+		dec      *types.MyDecimal
+		text     string
+		bit      []byte
+		datetime time.Time
+		date     sql.NullTime
+		kvTime   types.Time
+		duration string
+		enum     sql.NullString
+		set      types.Set
+	)
+	row := db.QueryRow("select autoId, type10, type17, type18, type11 from t where autoId = ?", 1)
+	// the fake node returns all the columns
+	var discard [10]interface{}
+	dest := []interface{}{&autoID}
+	for i := range discard {
+		dest = append(dest, &discard[i])
+	}
+	dest = append(dest, &dec, &text, &bit, &datetime, &date, &kvTime, &duration, &enum, &set)
+	assert.Nil(t, row.Scan(dest...))
+	assert.Equal(t, int64(1), autoID)
+	assert.Equal(t, "-0.999999999999999999999999999999", dec.String())
+	assert.NotEmpty(t, text)
+	assert.Equal(t, time.Date(1000, 1, 1, 0, 0, 0, 0, time.UTC), datetime)
+	assert.Equal(t, sql.NullTime{Time: time.Date(2011, 1, 1, 0, 0, 1, 0, time.UTC), Valid: true}, date)
+	assert.Equal(t, "1003-01-01 00:00:00", kvTime.String())
+	assert.Equal(t, "-838:59:59", duration)
+	assert.Equal(t, sql.NullString{String: "a1", Valid: true}, enum)
+	assert.Equal(t, "a2", set.String())
+
+	assert.Equal(t, []string{
+		`insert into t values (1, 'a\'b')`,
+		"select autoId, type10, type17, type18, type11 from t where autoId = 1",
+	}, sqls)
+
+	_, err = db.Begin()
+	assert.Equal(t, ErrTxNotSupported, err)
+}
+
+// countCloser count the calls of Close
+type countCloser struct {
+	closed int
+}
+
+func (c *countCloser) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestConn_Close(t *testing.T) {
+	closer := &countCloser{}
+	c := &conn{connector: &Connector{}, closer: closer}
+	assert.Nil(t, c.Close())
+	assert.Nil(t, c.Close())
+	// the owned connector is closed once
+	assert.Equal(t, 1, closer.closed)
+	_, err := c.Prepare("select 1")
+	assert.Equal(t, driver.ErrBadConn, err)
+
+	_, err = (&Driver{}).Open("conf=../../conf&account=./not_exist.json&contract=0x1234")
+	assert.NotNil(t, err)
+}
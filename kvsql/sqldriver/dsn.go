@@ -0,0 +1,87 @@
+package sqldriver
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+)
+
+// Config is the parsed data source name of the driver
+type Config struct {
+	// ConfPath is the root conf directory of the sdk, see rpc.NewRPCWithPath
+	ConfPath string
+	// Account is the path of the account json file
+	Account string
+	// Keystore is the keystore directory, the account Address in it is used if Account is empty
+	Keystore string
+	// Address is the address of the account in Keystore
+	Address string
+	// Password is the password of the account
+	Password string
+	// Contract is the address of the KVSQL contract
+	Contract string
+}
+
+// ParseDSN parse the data source name, which is url query values, e.g.
+//
+//	conf=../conf&account=./account.json&password=123&contract=0x1234...
+//	conf=../conf&keystore=./keystore&address=0xabcd...&password=123&contract=0x1234...
+func ParseDSN(dsn string) (*Config, error) {
+	values, err := url.ParseQuery(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dsn: %v", err)
+	}
+	cfg := &Config{
+		ConfPath: values.Get("conf"),
+		Account:  values.Get("account"),
+		Keystore: values.Get("keystore"),
+		Address:  values.Get("address"),
+		Password: values.Get("password"),
+		Contract: values.Get("contract"),
+	}
+	for k := range values {
+		switch k {
+		case "conf", "account", "keystore", "address", "password", "contract":
+		default:
+			return nil, fmt.Errorf("invalid dsn: unknown parameter %s", k)
+		}
+	}
+	if cfg.ConfPath == "" {
+		return nil, errors.New("invalid dsn: conf is empty")
+	}
+	if cfg.Contract == "" {
+		return nil, errors.New("invalid dsn: contract is empty")
+	}
+	if cfg.Account == "" && (cfg.Keystore == "" || cfg.Address == "") {
+		return nil, errors.New("invalid dsn: either account or keystore and address should be given")
+	}
+	return cfg, nil
+}
+
+// FormatDSN return the data source name of the config
+func (cfg *Config) FormatDSN() string {
+	values := url.Values{}
+	for k, v := range map[string]string{
+		"conf":     cfg.ConfPath,
+		"account":  cfg.Account,
+		"keystore": cfg.Keystore,
+		"address":  cfg.Address,
+		"password": cfg.Password,
+		"contract": cfg.Contract,
+	} {
+		if v != "" {
+			values.Set(k, v)
+		}
+	}
+	return values.Encode()
+}
+
+func readAccountJSON(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,139 @@
+package sqldriver
+
+import (
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/meshplus/gosdk/kvsql"
+	"github.com/meshplus/gosdk/kvsql/mysql"
+)
+
+// rows is backed by the chunk of the result set, the values are typed as kvsql.DriverValue
+type rows struct {
+	rs     *kvsql.ResultSet
+	fields []*kvsql.Field
+	next   int
+}
+
+func newRows(rs *kvsql.ResultSet) *rows {
+	return &rows{rs: rs, fields: rs.Fields()}
+}
+
+func (r *rows) Columns() []string {
+	columns := make([]string, len(r.fields))
+	for i, f := range r.fields {
+		columns[i] = f.Name()
+	}
+	return columns
+}
+
+func (r *rows) Close() error {
+	r.next = r.rs.RowNumber()
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= r.rs.RowNumber() {
+		return io.EOF
+	}
+	row := r.rs.GetRow(r.next)
+	r.next++
+	for i, f := range r.fields {
+		dest[i] = kvsql.DriverValue(row.GetValue(i, f))
+	}
+	return nil
+}
+
+var (
+	scanTypeInt64   = reflect.TypeOf(int64(0))
+	scanTypeUint64  = reflect.TypeOf(uint64(0))
+	scanTypeFloat64 = reflect.TypeOf(float64(0))
+	scanTypeTime    = reflect.TypeOf(time.Time{})
+	scanTypeString  = reflect.TypeOf("")
+	scanTypeBytes   = reflect.TypeOf([]byte{})
+	scanTypeUnknown = reflect.TypeOf(new(interface{})).Elem()
+)
+
+// ColumnTypeScanType return the type of the values of the column
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	f := r.fields[index]
+	switch f.Type() {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeYear, mysql.TypeInt24, mysql.TypeLong:
+		return scanTypeInt64
+	case mysql.TypeLonglong:
+		if f.Flag()&mysql.UnsignedFlag > 0 {
+			return scanTypeUint64
+		}
+		return scanTypeInt64
+	case mysql.TypeFloat, mysql.TypeDouble:
+		return scanTypeFloat64
+	case mysql.TypeDate, mysql.TypeDatetime, mysql.TypeTimestamp:
+		return scanTypeTime
+	case mysql.TypeNewDecimal, mysql.TypeDuration, mysql.TypeEnum, mysql.TypeSet:
+		return scanTypeString
+	case mysql.TypeTinyBlob, mysql.TypeMediumBlob, mysql.TypeLongBlob, mysql.TypeBlob, mysql.TypeBit:
+		return scanTypeBytes
+	case mysql.TypeVarchar, mysql.TypeVarString, mysql.TypeString, mysql.TypeJSON:
+		switch {
+		case f.Flag()&(mysql.EnumFlag|mysql.SetFlag) > 0:
+			return scanTypeString
+		case f.Flag()&mysql.BinaryFlag > 0 && f.Type() != mysql.TypeJSON:
+			return scanTypeBytes
+		}
+		return scanTypeString
+	default:
+		return scanTypeUnknown
+	}
+}
+
+var typeNames = map[byte]string{
+	mysql.TypeTiny:       "TINYINT",
+	mysql.TypeShort:      "SMALLINT",
+	mysql.TypeLong:       "INT",
+	mysql.TypeFloat:      "FLOAT",
+	mysql.TypeDouble:     "DOUBLE",
+	mysql.TypeTimestamp:  "TIMESTAMP",
+	mysql.TypeLonglong:   "BIGINT",
+	mysql.TypeInt24:      "MEDIUMINT",
+	mysql.TypeDate:       "DATE",
+	mysql.TypeDuration:   "TIME",
+	mysql.TypeDatetime:   "DATETIME",
+	mysql.TypeYear:       "YEAR",
+	mysql.TypeVarchar:    "VARCHAR",
+	mysql.TypeBit:        "BIT",
+	mysql.TypeJSON:       "JSON",
+	mysql.TypeNewDecimal: "DECIMAL",
+	mysql.TypeEnum:       "ENUM",
+	mysql.TypeSet:        "SET",
+	mysql.TypeTinyBlob:   "TINYBLOB",
+	mysql.TypeMediumBlob: "MEDIUMBLOB",
+	mysql.TypeLongBlob:   "LONGBLOB",
+	mysql.TypeBlob:       "BLOB",
+	mysql.TypeVarString:  "VARCHAR",
+	mysql.TypeString:     "CHAR",
+}
+
+// ColumnTypeDatabaseTypeName return the mysql type name of the column, e.g. BIGINT
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	f := r.fields[index]
+	switch {
+	case f.Flag()&mysql.EnumFlag > 0:
+		return "ENUM"
+	case f.Flag()&mysql.SetFlag > 0:
+		return "SET"
+	}
+	return typeNames[f.Type()]
+}
+
+// ColumnTypeNullable report whether the column may be NULL
+func (r *rows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	return r.fields[index].Flag()&mysql.NotNullFlag == 0, true
+}
+
+var (
+	_ driver.RowsColumnTypeScanType         = (*rows)(nil)
+	_ driver.RowsColumnTypeDatabaseTypeName = (*rows)(nil)
+	_ driver.RowsColumnTypeNullable         = (*rows)(nil)
+)
@@ -0,0 +1,340 @@
+package kvsql
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/meshplus/gosdk/kvsql/mysql"
+	"github.com/meshplus/gosdk/kvsql/types"
+)
+
+var (
+	// ErrNotBatchable is returned by BindBatch if the statement is not an INSERT or REPLACE
+	// whose placeholders are all in one VALUES row
+	ErrNotBatchable = errors.New("kvsql: only INSERT or REPLACE with the placeholders in one VALUES row can be batched")
+	// ErrEmptyBatch is returned by BindBatch without rows
+	ErrEmptyBatch = errors.New("kvsql: batch without rows")
+)
+
+// Stmt is a client side prepared statement, as the KVSQL contract takes a plain sql,
+// the ? placeholders outside the quotes and comments are replaced by the sql literals of the arguments, e.g.
+//
+//	stmt, err := kvsql.Prepare("INSERT INTO t (id, name, price) VALUES (?, ?, ?)")
+//	query, err := stmt.Bind(1, "it's", price)
+//	query, err := stmt.BindBatch([][]interface{}{{1, "a", price}, {2, "b", price}})
+type Stmt struct {
+	query        string
+	placeholders []int
+	// rowStart and rowEnd are the offsets of the parentheses of the VALUES row, rowStart is -1 if not batchable
+	rowStart, rowEnd int
+}
+
+// Prepare parse the placeholders of the query
+func Prepare(query string) (*Stmt, error) {
+	s := &Stmt{query: query, rowStart: -1, rowEnd: -1}
+	var (
+		insert, values bool
+		firstWord      = true
+		depth          int
+	)
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := skipQuoted(query, i)
+			if end < 0 {
+				return nil, fmt.Errorf("kvsql: unterminated quote at %d", i)
+			}
+			i = end
+		case c == '#' || c == '-' && strings.HasPrefix(query[i:], "-- "):
+			if end := strings.IndexByte(query[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(query)
+			}
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("kvsql: unterminated comment at %d", i)
+			}
+			i += end + 3
+		case c == '?':
+			s.placeholders = append(s.placeholders, i)
+		case c == '(':
+			if values && depth == 0 {
+				s.rowStart = i
+			}
+			depth++
+		case c == ')':
+			depth--
+			if values && depth == 0 && s.rowStart >= 0 {
+				s.rowEnd = i
+				values = false
+			}
+		case isWordChar(c):
+			start := i
+			for i+1 < len(query) && isWordChar(query[i+1]) {
+				i++
+			}
+			word := query[start : i+1]
+			if firstWord {
+				insert = strings.EqualFold(word, "INSERT") || strings.EqualFold(word, "REPLACE")
+				firstWord = false
+			} else if insert && s.rowStart < 0 && (strings.EqualFold(word, "VALUES") || strings.EqualFold(word, "VALUE")) {
+				values = true
+			}
+		}
+	}
+	if s.rowEnd < 0 {
+		s.rowStart = -1
+	}
+	for _, p := range s.placeholders {
+		if p < s.rowStart || p > s.rowEnd {
+			s.rowStart, s.rowEnd = -1, -1
+			break
+		}
+	}
+	return s, nil
+}
+
+// skipQuoted return the offset of the closing quote of the quoted string starting at i, -1 if unterminated
+func skipQuoted(query string, i int) int {
+	quote := query[i]
+	for i++; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '$'
+}
+
+// Query return the query with the placeholders
+func (s *Stmt) Query() string {
+	return s.query
+}
+
+// NumInput return the number of the placeholders
+func (s *Stmt) NumInput() int {
+	return len(s.placeholders)
+}
+
+// Batchable report whether the statement can be bound by BindBatch
+func (s *Stmt) Batchable() bool {
+	return s.rowStart >= 0
+}
+
+// Bind return the query with the placeholders replaced by the arguments, see NewDatum for the supported types
+func (s *Stmt) Bind(args ...interface{}) (string, error) {
+	if len(args) != len(s.placeholders) {
+		return "", fmt.Errorf("kvsql: %d arguments are given but the statement has %d placeholders", len(args), len(s.placeholders))
+	}
+	buf := make([]byte, 0, len(s.query)+16*len(args))
+	buf, err := s.appendBound(buf, 0, len(s.query), args)
+	if err != nil {
+		return "", fmt.Errorf("kvsql: %v", err)
+	}
+	return string(buf), nil
+}
+
+// BindBatch return a multi-row INSERT or REPLACE with the VALUES row repeated for each row of the arguments
+func (s *Stmt) BindBatch(rows [][]interface{}) (string, error) {
+	if !s.Batchable() {
+		return "", ErrNotBatchable
+	}
+	if len(rows) == 0 {
+		return "", ErrEmptyBatch
+	}
+	rowLen := s.rowEnd + 1 - s.rowStart
+	buf := make([]byte, 0, len(s.query)+(rowLen+16*len(s.placeholders))*len(rows))
+	buf = append(buf, s.query[:s.rowStart]...)
+	for i, args := range rows {
+		if len(args) != len(s.placeholders) {
+			return "", fmt.Errorf("kvsql: %d arguments are given in row %d but the statement has %d placeholders", len(args), i, len(s.placeholders))
+		}
+		if i > 0 {
+			buf = append(buf, ", "...)
+		}
+		var err error
+		buf, err = s.appendBound(buf, s.rowStart, s.rowEnd+1, args)
+		if err != nil {
+			return "", fmt.Errorf("kvsql: row %d, %v", i, err)
+		}
+	}
+	buf = append(buf, s.query[s.rowEnd+1:]...)
+	return string(buf), nil
+}
+
+// appendBound append query[start:end] with the placeholders in it replaced by the arguments
+func (s *Stmt) appendBound(buf []byte, start, end int, args []interface{}) ([]byte, error) {
+	for i, p := range s.placeholders {
+		buf = append(buf, s.query[start:p]...)
+		d, err := NewDatum(args[i])
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %v", i, err)
+		}
+		if buf, err = AppendDatum(buf, &d); err != nil {
+			return nil, fmt.Errorf("argument %d: %v", i, err)
+		}
+		start = p + 1
+	}
+	return append(buf, s.query[start:end]...), nil
+}
+
+// NewDatum convert the go value to the datum, the supported types are
+//
+//	nil, bool, signed and unsigned integers, float32, float64, string, []byte
+//	time.Time       datetime, with microseconds if any
+//	time.Duration   time, with microseconds if any
+//	types.Time, types.Duration, types.Enum, types.Set, types.MyDecimal, *types.MyDecimal, types.Datum
+//	driver.Valuer   the value of it
+func NewDatum(v interface{}) (types.Datum, error) {
+	var d types.Datum
+	switch v := v.(type) {
+	case nil:
+		d.SetNull()
+	case types.Datum:
+		d = v
+	case *types.Datum:
+		if v == nil {
+			d.SetNull()
+		} else {
+			d = *v
+		}
+	case bool:
+		if v {
+			d.SetInt64(1)
+		} else {
+			d.SetInt64(0)
+		}
+	case int:
+		d.SetInt64(int64(v))
+	case int8:
+		d.SetInt64(int64(v))
+	case int16:
+		d.SetInt64(int64(v))
+	case int32:
+		d.SetInt64(int64(v))
+	case int64:
+		d.SetInt64(v)
+	case uint:
+		d.SetUint64(uint64(v))
+	case uint8:
+		d.SetUint64(uint64(v))
+	case uint16:
+		d.SetUint64(uint64(v))
+	case uint32:
+		d.SetUint64(uint64(v))
+	case uint64:
+		d.SetUint64(v)
+	case float32:
+		d.SetFloat32(v)
+	case float64:
+		d.SetFloat64(v)
+	case string:
+		d.SetString(v, "")
+	case []byte:
+		if v == nil {
+			d.SetNull()
+		} else {
+			d.SetBytes(v)
+		}
+	case time.Time:
+		if v.IsZero() {
+			d.SetMysqlTime(types.NewTime(types.ZeroCoreTime, mysql.TypeDatetime, types.DefaultFsp))
+			break
+		}
+		fsp := types.DefaultFsp
+		if v.Nanosecond() != 0 {
+			fsp = 6
+		}
+		d.SetMysqlTime(types.NewTime(types.FromGoTime(v), mysql.TypeDatetime, fsp))
+	case time.Duration:
+		fsp := types.DefaultFsp
+		if v%time.Second != 0 {
+			fsp = 6
+		}
+		d.SetMysqlDuration(types.Duration{Duration: v, Fsp: fsp})
+	case types.Time:
+		d.SetMysqlTime(v)
+	case types.Duration:
+		d.SetMysqlDuration(v)
+	case types.Enum:
+		d.SetMysqlEnum(v, "")
+	case types.Set:
+		d.SetMysqlSet(v, "")
+	case types.MyDecimal:
+		d.SetMysqlDecimal(&v)
+	case *types.MyDecimal:
+		if v == nil {
+			d.SetNull()
+		} else {
+			d.SetMysqlDecimal(v)
+		}
+	case driver.Valuer:
+		value, err := v.Value()
+		if err != nil {
+			return d, err
+		}
+		return NewDatum(value)
+	default:
+		return d, fmt.Errorf("unsupported type %T", v)
+	}
+	return d, nil
+}
+
+// AppendDatum append the sql literal of the datum, strings are quoted with the mysql escapes
+// and bytes are hexadecimal literals, e.g. X'dead'
+func AppendDatum(buf []byte, d *types.Datum) ([]byte, error) {
+	switch d.Kind() {
+	case types.KindNull:
+		return append(buf, "NULL"...), nil
+	case types.KindInt64:
+		return strconv.AppendInt(buf, d.GetInt64(), 10), nil
+	case types.KindUint64:
+		return strconv.AppendUint(buf, d.GetUint64(), 10), nil
+	case types.KindFloat32:
+		return appendFloat(buf, float64(d.GetFloat32()), 32)
+	case types.KindFloat64:
+		return appendFloat(buf, d.GetFloat64(), 64)
+	case types.KindString:
+		return mysql.AppendQuoted(buf, d.GetString()), nil
+	case types.KindBytes:
+		buf = append(buf, "X'"...)
+		buf = append(buf, hex.EncodeToString(d.GetBytes())...)
+		return append(buf, '\''), nil
+	case types.KindMysqlDecimal:
+		return append(buf, d.GetMysqlDecimal().String()...), nil
+	case types.KindMysqlTime:
+		return mysql.AppendQuoted(buf, d.GetMysqlTime().String()), nil
+	case types.KindMysqlDuration:
+		return mysql.AppendQuoted(buf, d.GetMysqlDuration().String()), nil
+	case types.KindMysqlEnum:
+		return mysql.AppendQuoted(buf, d.GetMysqlEnum().Name), nil
+	case types.KindMysqlSet:
+		return mysql.AppendQuoted(buf, d.GetMysqlSet().Name), nil
+	default:
+		return nil, fmt.Errorf("unsupported datum kind %d", d.Kind())
+	}
+}
+
+func appendFloat(buf []byte, f float64, bitSize int) ([]byte, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("unsupported float %v", f)
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bitSize), nil
+}
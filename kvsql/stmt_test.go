@@ -0,0 +1,76 @@
+package kvsql
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/meshplus/gosdk/kvsql/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrepare(t *testing.T) {
+	s, err := Prepare("select * from t where a = '?' and b = \"\\\"?\" and `?` = ? -- ?\n and c = ? # ?\n and d /* ? */ = ?")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, s.NumInput())
+	assert.False(t, s.Batchable())
+
+	_, err = Prepare("select 'abc")
+	assert.NotNil(t, err)
+	_, err = Prepare("select /* abc")
+	assert.NotNil(t, err)
+
+	s, err = Prepare("INSERT INTO t (a, b) VALUES (?, concat(?, 'x')) ON DUPLICATE KEY UPDATE b = VALUES(b)")
+	assert.Nil(t, err)
+	assert.True(t, s.Batchable())
+
+	// the placeholders should be in the VALUES row
+	s, err = Prepare("insert into t values (?, ?), (?, ?)")
+	assert.Nil(t, err)
+	assert.False(t, s.Batchable())
+	s, err = Prepare("update t set a = ? where b in (?)")
+	assert.Nil(t, err)
+	assert.False(t, s.Batchable())
+}
+
+func TestStmt_Bind(t *testing.T) {
+	s, err := Prepare("insert into t values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	assert.Nil(t, err)
+
+	dec := new(types.MyDecimal)
+	assert.Nil(t, dec.FromString([]byte("-1.25")))
+	query, err := s.Bind(-1, uint64(math.MaxUint64), "it's \"a\"\n\\\x00\x1a", nil, []byte{0xde, 0xad}, float32(1.5), true,
+		time.Date(2022, 5, 6, 7, 8, 9, 0, time.UTC), time.Date(2022, 5, 6, 7, 8, 9, 1000, time.UTC),
+		90*time.Minute, dec, types.Enum{Name: "a'1"}, types.Set{Name: "a,b"})
+	assert.Nil(t, err)
+	assert.Equal(t, `insert into t values (-1, 18446744073709551615, 'it\'s \"a\"\n\\\0\Z', NULL, X'dead', 1.5, 1, `+
+		`'2022-05-06 07:08:09', '2022-05-06 07:08:09.000001', '01:30:00', -1.25, 'a\'1', 'a,b')`, query)
+
+	_, err = s.Bind(1)
+	assert.NotNil(t, err)
+
+	s, err = Prepare("select ?")
+	assert.Nil(t, err)
+	_, err = s.Bind(math.NaN())
+	assert.NotNil(t, err)
+	_, err = s.Bind(struct{}{})
+	assert.NotNil(t, err)
+}
+
+func TestStmt_BindBatch(t *testing.T) {
+	s, err := Prepare("insert into t (a, b, c) values (?, ?, now()) on duplicate key update b = values(b)")
+	assert.Nil(t, err)
+	query, err := s.BindBatch([][]interface{}{{1, "a"}, {2, nil}, {3, "c"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "insert into t (a, b, c) values (1, 'a', now()), (2, NULL, now()), (3, 'c', now()) on duplicate key update b = values(b)", query)
+
+	_, err = s.BindBatch(nil)
+	assert.Equal(t, ErrEmptyBatch, err)
+	_, err = s.BindBatch([][]interface{}{{1, "a"}, {2}})
+	assert.NotNil(t, err)
+
+	s, err = Prepare("delete from t where a = ?")
+	assert.Nil(t, err)
+	_, err = s.BindBatch([][]interface{}{{1}})
+	assert.Equal(t, ErrNotBatchable, err)
+}
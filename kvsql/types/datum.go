@@ -15,8 +15,10 @@ const (
 	KindFloat64       byte = 4
 	KindString        byte = 5
 	KindBytes         byte = 6
+	KindMysqlDecimal  byte = 8
 	KindMysqlDuration byte = 9
 	KindMysqlEnum     byte = 10
+	KindMysqlSet      byte = 12
 	KindMysqlTime     byte = 13
 	KindInterface     byte = 14
 )
@@ -196,6 +198,31 @@ func (d *Datum) SetMysqlEnum(b Enum, collation string) {
 	d.b = hack.Slice(b.Name)
 }
 
+// GetMysqlDecimal gets MyDecimal value
+func (d *Datum) GetMysqlDecimal() *MyDecimal {
+	return d.x.(*MyDecimal)
+}
+
+// SetMysqlDecimal sets MyDecimal value
+func (d *Datum) SetMysqlDecimal(b *MyDecimal) {
+	d.k = KindMysqlDecimal
+	d.x = b
+}
+
+// GetMysqlSet gets Set value
+func (d *Datum) GetMysqlSet() Set {
+	str := string(hack.String(d.b))
+	return Set{Name: str}
+}
+
+// SetMysqlSet sets Set value
+func (d *Datum) SetMysqlSet(b Set, collation string) {
+	d.k = KindMysqlSet
+	sink(b.Name)
+	d.collation = collation
+	d.b = hack.Slice(b.Name)
+}
+
 // IsNull checks if datum is null.
 func (d *Datum) IsNull() bool {
 	return d.k == KindNull
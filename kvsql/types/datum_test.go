@@ -118,6 +118,26 @@ func TestDatum_MysqlEnum(t *testing.T) {
 
 }
 
+func TestDatum_MysqlSet(t *testing.T) {
+	d := Datum{}
+
+	d.SetMysqlSet(Set{
+		Name: "a,b",
+	}, "")
+	assert.Equal(t, KindMysqlSet, d.Kind())
+	assert.Equal(t, Set{
+		Name: "a,b",
+	}, d.GetMysqlSet())
+}
+
+func TestDatum_MysqlDecimal(t *testing.T) {
+	d := Datum{}
+
+	d.SetMysqlDecimal(NewDecFromInt(-12))
+	assert.Equal(t, KindMysqlDecimal, d.Kind())
+	assert.Equal(t, "-12", d.GetMysqlDecimal().String())
+}
+
 func TestDatum_IsNull(t *testing.T) {
 	d := Datum{
 		k:         KindInt64,
@@ -6,4 +6,6 @@ const (
 	// DefaultFsp is the default digit of fractional seconds part.
 	// MySQL use 0 as the default Fsp.
 	DefaultFsp = int8(0)
+	// MaxFsp is the maximum digit of fractional seconds part.
+	MaxFsp = int8(6)
 )
@@ -0,0 +1,169 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	gotime "time"
+
+	"github.com/meshplus/gosdk/kvsql/mysql"
+)
+
+// the kvsql types implement sql.Scanner, the values of the same type are kept as they are,
+// and the driver values returned by the kvsql database/sql driver are converted
+
+// Scan implements sql.Scanner interface.
+func (e *Enum) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case Enum:
+		*e = s
+	case string:
+		*e = Enum{Name: s}
+	case []byte:
+		*e = Enum{Name: string(s)}
+	default:
+		return unsupportedScan(src, e)
+	}
+	return nil
+}
+
+// Scan implements sql.Scanner interface.
+func (e *Set) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case Set:
+		*e = s
+	case string:
+		*e = Set{Name: s}
+	case []byte:
+		*e = Set{Name: string(s)}
+	default:
+		return unsupportedScan(src, e)
+	}
+	return nil
+}
+
+// Scan implements sql.Scanner interface.
+func (d *MyDecimal) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case *MyDecimal:
+		if s == nil {
+			return unsupportedScan(src, d)
+		}
+		*d = *s
+	case string:
+		return d.FromString([]byte(s))
+	case []byte:
+		return d.FromString(s)
+	case int64:
+		*d = MyDecimal{}
+		d.FromInt(s)
+	case uint64:
+		*d = MyDecimal{}
+		d.FromUint(s)
+	case float64:
+		return d.FromFloat64(s)
+	default:
+		return unsupportedScan(src, d)
+	}
+	return nil
+}
+
+// Scan implements sql.Scanner interface, the time.Time is scanned as a datetime.
+func (t *Time) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case Time:
+		*t = s
+		return nil
+	case gotime.Time:
+		fsp := DefaultFsp
+		if s.Nanosecond() != 0 {
+			fsp = MaxFsp
+		}
+		*t = NewTime(FromGoTime(s), mysql.TypeDatetime, fsp)
+		return nil
+	case string:
+		return t.parse(s)
+	case []byte:
+		return t.parse(string(s))
+	}
+	return unsupportedScan(src, t)
+}
+
+// parse parse the date, e.g. 2006-01-02, or the datetime, e.g. 2006-01-02 15:04:05.999999
+func (t *Time) parse(str string) error {
+	if len(str) == len("2006-01-02") {
+		tm, err := gotime.Parse("2006-01-02", str)
+		if err != nil {
+			return err
+		}
+		*t = NewTime(FromGoTime(tm), mysql.TypeDate, DefaultFsp)
+		return nil
+	}
+	tm, err := gotime.Parse("2006-01-02 15:04:05.999999", str)
+	if err != nil {
+		return err
+	}
+	fsp := DefaultFsp
+	if i := strings.IndexByte(str, '.'); i != -1 {
+		fsp = int8(len(str) - i - 1)
+	}
+	*t = NewTime(FromGoTime(tm), mysql.TypeDatetime, fsp)
+	return nil
+}
+
+// Scan implements sql.Scanner interface, the string is parsed as [-]HH:MM:SS[.fraction].
+func (d *Duration) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case Duration:
+		*d = s
+		return nil
+	case gotime.Duration:
+		*d = Duration{Duration: s}
+		return nil
+	case string:
+		return d.parse(s)
+	case []byte:
+		return d.parse(string(s))
+	}
+	return unsupportedScan(src, d)
+}
+
+func (d *Duration) parse(str string) error {
+	negative := strings.HasPrefix(str, "-")
+	parts := strings.Split(strings.TrimPrefix(str, "-"), ":")
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid time format: %s", str)
+	}
+	var frac string
+	if i := strings.IndexByte(parts[2], '.'); i != -1 {
+		parts[2], frac = parts[2][:i], parts[2][i+1:]
+	}
+	if len(frac) > int(MaxFsp) {
+		return fmt.Errorf("invalid time format: %s", str)
+	}
+	var values [3]int64
+	for i, part := range parts {
+		v, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || v < 0 {
+			return fmt.Errorf("invalid time format: %s", str)
+		}
+		values[i] = v
+	}
+	duration := gotime.Duration(values[0])*gotime.Hour + gotime.Duration(values[1])*gotime.Minute + gotime.Duration(values[2])*gotime.Second
+	if frac != "" {
+		micro, err := strconv.ParseInt(frac+strings.Repeat("0", int(MaxFsp)-len(frac)), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid time format: %s", str)
+		}
+		duration += gotime.Duration(micro) * gotime.Microsecond
+	}
+	if negative {
+		duration = -duration
+	}
+	*d = Duration{Duration: duration, Fsp: int8(len(frac))}
+	return nil
+}
+
+func unsupportedScan(src interface{}, dest interface{}) error {
+	return fmt.Errorf("unsupported scan, storing %T into %T", src, dest)
+}
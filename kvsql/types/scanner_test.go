@@ -0,0 +1,40 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/meshplus/gosdk/kvsql/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScan(t *testing.T) {
+	var e Enum
+	assert.Nil(t, e.Scan("a"))
+	assert.Equal(t, Enum{Name: "a"}, e)
+	var s Set
+	assert.Nil(t, s.Scan([]byte("a,b")))
+	assert.Equal(t, Set{Name: "a,b"}, s)
+
+	var d MyDecimal
+	assert.Nil(t, d.Scan("-1.25"))
+	assert.Equal(t, "-1.25", d.String())
+	assert.Nil(t, d.Scan(int64(3)))
+	assert.Equal(t, "3", d.String())
+
+	var tm Time
+	assert.Nil(t, tm.Scan(time.Date(2022, 5, 6, 7, 8, 9, 0, time.UTC)))
+	assert.Equal(t, "2022-05-06 07:08:09", tm.String())
+	assert.Nil(t, tm.Scan("2022-05-06"))
+	assert.Equal(t, uint8(mysql.TypeDate), tm.Type())
+	assert.Nil(t, tm.Scan("2022-05-06 07:08:09.12"))
+	assert.Equal(t, "2022-05-06 07:08:09.12", tm.String())
+
+	var du Duration
+	assert.Nil(t, du.Scan("-838:59:59"))
+	assert.Equal(t, "-838:59:59", du.String())
+	assert.Nil(t, du.Scan("01:02:03.5"))
+	assert.Equal(t, time.Hour+2*time.Minute+3*time.Second+500*time.Millisecond, du.Duration)
+	assert.NotNil(t, du.Scan("01:02"))
+	assert.NotNil(t, du.Scan(nil))
+}
@@ -122,6 +122,36 @@ type Time struct {
 	coreTime
 }
 
+// NewTime constructs time from core time, type and fsp.
+func NewTime(coreTime CoreTime, tp uint8, fsp int8) Time {
+	t := ZeroTime
+	p := (*uint64)(&t.coreTime)
+	*p |= uint64(coreTime) & coreTimeBitFieldMask
+	if tp == mysql.TypeDate {
+		*p |= uint64(fspTtForDate)
+		return t
+	}
+	if fsp == UnspecifiedFsp {
+		fsp = DefaultFsp
+	}
+	*p |= uint64(fsp) << 1
+	if tp == mysql.TypeTimestamp {
+		*p |= 1
+	}
+	return t
+}
+
+// FromGoTime translates time.Time to mysql time internal representation.
+func FromGoTime(t gotime.Time) CoreTime {
+	// Plus 500 nanosecond for rounding of the millisecond part.
+	t = t.Add(500 * gotime.Nanosecond)
+
+	year, month, day := t.Date()
+	hour, minute, second := t.Clock()
+	microsecond := t.Nanosecond() / 1000
+	return FromDate(year, int(month), day, hour, minute, second, microsecond)
+}
+
 // Clock returns the hour, minute, and second within the day specified by t.
 func (t Time) Clock() (hour int, minute int, second int) {
 	return t.Hour(), t.Minute(), t.Second()
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"github.com/meshplus/gosdk/kvsql/mysql"
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
@@ -301,3 +302,19 @@ func TestDuration(t *testing.T) {
 	}
 	assert.Equal(t, "-00:00:00.000000", d.String())
 }
+
+func TestNewTime(t *testing.T) {
+	ct := FromGoTime(time.Date(2022, 5, 6, 7, 8, 9, 123456789, time.UTC))
+	assert.Equal(t, FromDate(2022, 5, 6, 7, 8, 9, 123457), ct)
+
+	a := NewTime(ct, mysql.TypeDatetime, 6)
+	assert.Equal(t, mysql.TypeDatetime, a.Type())
+	assert.Equal(t, "2022-05-06 07:08:09.123457", a.String())
+
+	a = NewTime(ct, mysql.TypeTimestamp, UnspecifiedFsp)
+	assert.Equal(t, mysql.TypeTimestamp, a.Type())
+	assert.Equal(t, "2022-05-06 07:08:09", a.String())
+
+	a = NewTime(ct, mysql.TypeDate, 6)
+	assert.Equal(t, "2022-05-06", a.String())
+}
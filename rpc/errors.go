@@ -0,0 +1,154 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// sentinel errors, compare with errors.Is, an error matches the sentinel of the same code, e.g.
+//
+//	if errors.Is(err, rpc.ErrNotFound) { ... }
+var (
+	ErrRequestCanceled      = &RetError{code: RequestCanceledErrorCode, message: "request canceled"}
+	ErrSystem               = &RetError{code: SystemErrorCode, message: "system error"}
+	ErrRequestTimeout       = &RetError{code: RequestTimeoutErrorCode, message: "request timeout"}
+	ErrGetResponse          = &RetError{code: GetResponseErrorCode, message: "get response error"}
+	ErrMethodNotExist       = &RetError{code: MethodNotExistOrInvalidCode, message: "method not exist or invalid"}
+	ErrNotFound             = &RetError{code: DataNotExistCode, message: "data not exist"}
+	ErrBalanceInsufficient  = &RetError{code: BalanceInsufficientCode, message: "balance insufficient"}
+	ErrInvalidSignature     = &RetError{code: InvalidSignature, message: "invalid signature"}
+	ErrSystemBusy           = &RetError{code: SystemBusyCode, message: "system busy"}
+	ErrDuplicateTransaction = &RetError{code: DuplicateTransactionsCode, message: "duplicate transaction"}
+	ErrInvalidParams        = &RetError{code: InvalidParams, message: "invalid params"}
+	ErrConsensusAbnormal    = &RetError{code: ConsensusStatusAbnormal, message: "consensus status abnormal"}
+	ErrDispatcherFull       = &RetError{code: DispatcherFull, message: "dispatcher full"}
+	ErrQPSLimit             = &RetError{code: QPSLimit, message: "qps limit"}
+	ErrSimulateLimit        = &RetError{code: SimulateLimit, message: "simulate limit"}
+)
+
+// Is report whether target is a RetError of the same code, so that errors.Is works with the sentinel errors
+func (re *RetError) Is(target error) bool {
+	t, ok := target.(*RetError)
+	return ok && t.code == re.code
+}
+
+// Unwrap return the cause of the error, e.g. the network error or context.DeadlineExceeded
+func (re *RetError) Unwrap() error {
+	return re.cause
+}
+
+// Cause return the original error which produces the error, it's nil for the errors responded by node
+func (re *RetError) Cause() error {
+	return re.cause
+}
+
+// HTTPStatus return the http status code responded by node, it's zero if the http request is not responded
+func (re *RetError) HTTPStatus() int {
+	return re.httpStatus
+}
+
+// NodeURL return the url of the node which the request is sent to
+func (re *RetError) NodeURL() string {
+	return re.nodeURL
+}
+
+// Method return the json rpc method of the request
+func (re *RetError) Method() string {
+	return re.method
+}
+
+// Data return the data responded with the error, e.g. the revert data of a failed transaction, it's nil if not responded
+func (re *RetError) Data() json.RawMessage {
+	return re.data
+}
+
+// withData keep the data responded with err
+func withData(err StdError, data json.RawMessage) StdError {
+	if re, ok := err.(*RetError); ok && re != nil && len(data) > 0 {
+		re.data = data
+	}
+	return err
+}
+
+// withRequest record the node and method of the request which produces err, the ones recorded before are kept
+func withRequest(err StdError, nodeURL, method string) StdError {
+	if re, ok := err.(*RetError); ok && re != nil {
+		if re.nodeURL == "" {
+			re.nodeURL = nodeURL
+		}
+		if re.method == "" {
+			re.method = method
+		}
+	}
+	return err
+}
+
+// retErrorCode return the code of err if it is or wraps a RetError
+func retErrorCode(err error) (int, bool) {
+	var re *RetError
+	if errors.As(err, &re) && re != nil {
+		return re.code, true
+	}
+	return 0, false
+}
+
+// IsRetryable report whether the request may succeed if it's sent again later,
+// e.g. the node is busy, limited or the network is broken
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var re *RetError
+	if errors.As(err, &re) && re != nil {
+		switch re.code {
+		case SystemBusyCode, ConsensusStatusAbnormal, DispatcherFull, QPSLimit, SimulateLimit, GetResponseErrorCode:
+			return true
+		}
+		if re.httpStatus >= http.StatusInternalServerError {
+			return isTemporaryError(re.httpStatus)
+		}
+		if re.httpStatus == http.StatusTooManyRequests {
+			return true
+		}
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsTimeout report whether the request or polling is timeout
+func IsTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if code, ok := retErrorCode(err); ok && code == RequestTimeoutErrorCode {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsCanceled report whether the request is canceled by its context
+func IsCanceled(err error) bool {
+	return errors.Is(err, ErrRequestCanceled) || errors.Is(err, context.Canceled)
+}
+
+// IsNotFound report whether the queried data does not exist
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsBalanceInsufficient report whether the balance of account is not enough
+func IsBalanceInsufficient(err error) bool {
+	return errors.Is(err, ErrBalanceInsufficient)
+}
+
+// IsInvalidSignature report whether the signature of transaction is invalid
+func IsInvalidSignature(err error) bool {
+	return errors.Is(err, ErrInvalidSignature)
+}
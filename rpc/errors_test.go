@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetError_Is(t *testing.T) {
+	err := NewServerError(DataNotExistCode, "not found")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.False(t, errors.Is(err, ErrBalanceInsufficient))
+	assert.True(t, IsNotFound(err))
+	assert.True(t, IsNotFound(fmt.Errorf("query block: %w", err)))
+
+	var re *RetError
+	assert.True(t, errors.As(fmt.Errorf("wrapped: %w", err), &re))
+	assert.Equal(t, DataNotExistCode, re.Code())
+
+	assert.True(t, IsBalanceInsufficient(NewServerError(BalanceInsufficientCode, "")))
+	assert.True(t, IsInvalidSignature(NewServerError(InvalidSignature, "")))
+	assert.False(t, IsNotFound(nil))
+}
+
+func TestRetError_Cause(t *testing.T) {
+	err := newContextError(context.DeadlineExceeded)
+	assert.True(t, IsTimeout(err))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, errors.Is(err, ErrRequestTimeout))
+
+	err = newContextError(context.Canceled)
+	assert.True(t, IsCanceled(err))
+	assert.False(t, IsTimeout(err))
+	assert.False(t, IsRetryable(err))
+
+	cause := errors.New("connection reset")
+	err = NewGetResponseError(cause)
+	assert.Equal(t, cause, err.(*RetError).Cause())
+	assert.True(t, errors.Is(err, cause))
+	assert.True(t, IsRetryable(err))
+}
+
+func TestIsRetryable(t *testing.T) {
+	for _, code := range []int{SystemBusyCode, ConsensusStatusAbnormal, DispatcherFull, QPSLimit, SimulateLimit} {
+		assert.True(t, IsRetryable(NewServerError(code, "")))
+	}
+	assert.False(t, IsRetryable(NewServerError(InvalidParams, "")))
+	assert.False(t, IsRetryable(NewHttpResponseError(http.StatusNotFound, "404 Not Found")))
+	assert.True(t, IsRetryable(NewHttpResponseError(http.StatusTooManyRequests, "429 Too Many Requests")))
+	assert.False(t, IsRetryable(nil))
+}
+
+func TestRPC_ErrorRequestInfo(t *testing.T) {
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":-32001,"message":"not found"}`))
+	})
+	_, err := rpc.GetTransactionByHash("0x01")
+	assert.True(t, IsNotFound(err))
+	var re *RetError
+	assert.True(t, errors.As(err, &re))
+	assert.Equal(t, TRANSACTION+"getTransactionByHash", re.Method())
+	assert.Equal(t, rpc.hrm.nodes[0].url, re.NodeURL())
+	assert.Nil(t, re.Data())
+
+	// the data responded with the error is kept
+	rpc = newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":-32005,"message":"reverted","data":"0x08c379a0"}`))
+	})
+	_, err = rpc.GetTxReceipt("0x01", false)
+	assert.True(t, errors.As(err, &re))
+	assert.Equal(t, `"0x08c379a0"`, string(re.Data()))
+
+	rpc = newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	_, err = rpc.GetChainHeight()
+	assert.True(t, errors.As(err, &re))
+	assert.Equal(t, http.StatusNotFound, re.HTTPStatus())
+	assert.Equal(t, BLOCK+"getChainHeight", re.Method())
+	assert.False(t, IsRetryable(err))
+}
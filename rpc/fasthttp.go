@@ -13,7 +13,7 @@ type RpcStatistic struct {
 func (rpc *RPC) FastInvokeContract(body []byte, randomURL string) (*RpcStatistic, StdError) {
 	requestTime := time.Now()
 	logger.Debug("invoke contract server url,", randomURL)
-	ret, err := rpc.hrm.SyncRequestSpecificURL(body, randomURL, GENERAL, nil, nil)
+	ret, err := rpc.hrm.roundTrip(rpc.Context(), newCall(nil, body, randomURL))
 	responseTime := time.Now()
 	return &RpcStatistic{
 		TxReceipt:    ret,
@@ -1,6 +1,7 @@
 package rpc
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/meshplus/gosdk/account"
@@ -35,9 +36,12 @@ func (rpc *RPC) callFM(method string, nodeID int, requestType RequestType, extra
 	if sysErr != nil {
 		return nil, NewSystemError(sysErr)
 	}
-	extraHeaders["params"] = string(bytesRequest)
 
-	data, err := rpc.hrm.SyncRequestSpecificURL(bytesRequest, url, requestType, extraHeaders, rwSeeker)
+	// the file is sent by the final RoundTripper, the params header carries the body the middlewares leave
+	data, err := rpc.hrm.roundTripWith(rpc.Context(), newCall(jsonRequest, bytesRequest, url), RoundTripperFunc(func(ctx context.Context, call *Call) ([]byte, StdError) {
+		extraHeaders["params"] = string(call.Body)
+		return rpc.hrm.syncRequestSpecificURL(ctx, call.Body, call.URL, requestType, extraHeaders, call.Header, rwSeeker)
+	}))
 	if err != nil {
 		return nil, err
 	}
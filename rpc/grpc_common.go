@@ -11,7 +11,9 @@ import (
 	"github.com/meshplus/gosdk/grpc/pool"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/resolver"
+	"net"
 	"strings"
 	"time"
 )
@@ -24,6 +26,12 @@ type GRPC struct {
 	conn      *grpc.ClientConn
 	gopts     grpcOption
 	rootPath  string
+	limiter   *RateLimiter
+	// nodeURLs map the addresses of the grpc nodes to the json rpc urls of the same nodes,
+	// so the rate limiter and the instrumentation identify a node the same way in both transports
+	nodeURLs map[string]string
+
+	instrumentation Instrumentation
 }
 
 type GRPCOption interface {
@@ -79,6 +87,8 @@ func NewGRPCWithConfPath(path string, opts ...GRPCOption) *GRPC {
 		namespace: cf.Namespace(),
 		im:        rpc.im,
 		rootPath:  path,
+		limiter:   rpc.hrm.limiter,
+		nodeURLs:  grpcNodeURLs(cf, rpc.hrm.nodes),
 	}
 	for _, opt := range opts {
 		opt.apply(&gg.gopts)
@@ -137,6 +147,80 @@ func (g *GRPC) newGrpcConn() (*grpc.ClientConn, error) {
 	return conn, err
 }
 
+// SetRateLimiter replace the client side rate limiter of grpc requests
+func (g *GRPC) SetRateLimiter(limiter *RateLimiter) *GRPC {
+	if limiter == nil {
+		limiter = NewRateLimiter()
+	}
+	g.limiter = limiter
+	return g
+}
+
+// grpcNodeURLs map the dial address of every grpc node, and the addresses its host resolves to,
+// to the url of the json rpc node configured at the same index
+func grpcNodeURLs(cf *pool.Config, nodes []*Node) map[string]string {
+	urls := make(map[string]string)
+	for i := range cf.Targets() {
+		if i >= len(nodes) {
+			break
+		}
+		addr := cf.GetDailStringByIndex(i)
+		urls[addr] = nodes[i].url
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			continue
+		}
+		ips, err := net.LookupHost(host)
+		if err != nil {
+			continue
+		}
+		for _, ip := range ips {
+			urls[net.JoinHostPort(ip, port)] = nodes[i].url
+		}
+	}
+	return urls
+}
+
+// grpcNode return the url of the node which the stream is connected to, or its address if it's not configured
+func (g *GRPC) grpcNode(stream *pool.IdleStream) string {
+	p, ok := peer.FromContext(stream.GetStream().Context())
+	if !ok || p.Addr == nil {
+		return grpcServiceName
+	}
+	if url, ok := g.nodeURLs[p.Addr.String()]; ok {
+		return url
+	}
+	return p.Addr.String()
+}
+
+// grpcClass return the method class of the transaction sent by grpc
+func grpcClass(sendTxArgsProto *api.SendTxArgs) MethodClass {
+	if sendTxArgsProto.Simulate {
+		return SimulateClass
+	}
+	return SendTxClass
+}
+
+// limit wait for the rate limiter before sending the request by stream
+func (g *GRPC) limit(stream *pool.IdleStream, class MethodClass) StdError {
+	if g.limiter == nil {
+		return nil
+	}
+	return g.limiter.Wait(stream.GetStream().Context(), g.grpcNode(stream), class)
+}
+
+// feedback tell the rate limiter whether the request is rejected by the node
+func (g *GRPC) feedback(stream *pool.IdleStream, class MethodClass, code int64) {
+	if g.limiter == nil {
+		return
+	}
+	if code == QPSLimit || code == SimulateLimit {
+		g.limiter.Backoff(g.grpcNode(stream), class)
+	} else {
+		g.limiter.Recover(g.grpcNode(stream), class)
+	}
+}
+
 func (g *GRPC) CheckClientOption(copt ClientOption) (bool, error) {
 	if copt.StreamNumber <= 0 {
 		return false, errors.New("num value is error, should > 0")
@@ -329,7 +413,33 @@ func (g *GRPC) prepareMqCommReq(meta interface{}) (*api.CommonReq, error) {
 	return commonReq, nil
 }
 
-func (g *GRPC) sendAndRecvReturnString(stream *pool.IdleStream, sendTxArgsProto *api.SendTxArgs, method string) (string, StdError) {
+// instrument start the instrumentation of a grpc request, the returned function should be called once it's finished
+func (g *GRPC) instrument(stream *pool.IdleStream, sendTxArgsProto *api.SendTxArgs, method string) func(txHash string, err StdError) {
+	ins := g.instrumentation
+	if ins == nil || stream == nil {
+		return func(string, StdError) {}
+	}
+	info := &RequestInfo{
+		Transport: GRPCTransport,
+		Method:    method,
+		Class:     grpcClass(sendTxArgsProto),
+		Account:   sendTxArgsProto.From,
+		NodeURL:   g.grpcNode(stream),
+		Attempt:   1,
+	}
+	ctx := ins.RequestStart(stream.GetStream().Context(), info)
+	start := time.Now()
+	return func(txHash string, err StdError) {
+		info.TxHash = txHash
+		ins.RequestEnd(ctx, info, time.Since(start), err)
+	}
+}
+
+func (g *GRPC) sendAndRecvReturnString(stream *pool.IdleStream, sendTxArgsProto *api.SendTxArgs, method string) (txHash string, stdErr StdError) {
+	end := g.instrument(stream, sendTxArgsProto, method)
+	defer func() {
+		end(txHash, stdErr)
+	}()
 	if stream == nil {
 		return "", NewSystemError(errors.New("system is busy"))
 	}
@@ -338,6 +448,10 @@ func (g *GRPC) sendAndRecvReturnString(stream *pool.IdleStream, sendTxArgsProto
 		grpcLogger.Errorf("prepareCommonReq err %v", err1)
 		return "", err1
 	}
+	class := grpcClass(sendTxArgsProto)
+	if err1 = g.limit(stream, class); err1 != nil {
+		return "", err1
+	}
 	grpcLogger.Debugf("[REQUEST] method: %s, req: %+v", method, commonReq)
 	err := stream.GetStream().Send(commonReq)
 	if err != nil {
@@ -350,6 +464,7 @@ func (g *GRPC) sendAndRecvReturnString(stream *pool.IdleStream, sendTxArgsProto
 		grpcLogger.Errorf("Recv err %v", err)
 		return "", NewSystemError(err)
 	}
+	g.feedback(stream, class, ans.Code)
 	grpcLogger.Debugf("[RESPONSE] %s", formatCommonRes(ans))
 	if ans.Code != SuccessCode {
 		grpcLogger.Errorf("response not success code: %d, codeDesc: %s", ans.Code, ans.CodeDesc)
@@ -358,7 +473,15 @@ func (g *GRPC) sendAndRecvReturnString(stream *pool.IdleStream, sendTxArgsProto
 	return common.BytesToHash(ans.Result).Hex(), nil
 }
 
-func (g *GRPC) sendAndRecv(stream *pool.IdleStream, sendTxArgsProto *api.SendTxArgs, method string) (*TxReceipt, StdError) {
+func (g *GRPC) sendAndRecv(stream *pool.IdleStream, sendTxArgsProto *api.SendTxArgs, method string) (receipt *TxReceipt, stdErr StdError) {
+	end := g.instrument(stream, sendTxArgsProto, method)
+	defer func() {
+		if receipt != nil {
+			end(receipt.TxHash, stdErr)
+		} else {
+			end("", stdErr)
+		}
+	}()
 	if stream == nil {
 		return nil, NewSystemError(errors.New("system is busy"))
 	}
@@ -367,6 +490,10 @@ func (g *GRPC) sendAndRecv(stream *pool.IdleStream, sendTxArgsProto *api.SendTxA
 		grpcLogger.Errorf("prepareCommonReq err %v", err1)
 		return nil, err1
 	}
+	class := grpcClass(sendTxArgsProto)
+	if err1 = g.limit(stream, class); err1 != nil {
+		return nil, err1
+	}
 	grpcLogger.Debugf("[REQUEST] method: %s, req: %+v", method, commonReq)
 	err := stream.GetStream().Send(commonReq)
 	if err != nil {
@@ -380,6 +507,7 @@ func (g *GRPC) sendAndRecv(stream *pool.IdleStream, sendTxArgsProto *api.SendTxA
 		grpcLogger.Errorf("Recv err %v", err)
 		return nil, NewSystemError(err)
 	}
+	g.feedback(stream, class, ans.Code)
 	var ret = new(api.ReceiptResult)
 	err = proto.Unmarshal(ans.Result, ret)
 	if err != nil {
@@ -0,0 +1,239 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type circuitState int
+
+const (
+	// circuitClosed is the normal state, requests are sent to the node
+	circuitClosed circuitState = iota
+	// circuitOpen means the node keeps failing, no request is sent until the open timeout passes
+	circuitOpen
+	// circuitHalfOpen means a trial request is in flight, its result decides to close or reopen the circuit
+	circuitHalfOpen
+)
+
+// circuitBreaker stops sending requests to a node after consecutive failures
+type circuitBreaker struct {
+	mutex    sync.Mutex
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+// allow report whether a request can be sent, it does not change the state
+func (cb *circuitBreaker) allow(openTimeout time.Duration) bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	switch cb.state {
+	case circuitOpen:
+		return time.Since(cb.openedAt) >= openTimeout
+	case circuitHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// acquire is called before a request is sent, an open circuit turns to half-open after the open timeout
+func (cb *circuitBreaker) acquire(openTimeout time.Duration) bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	switch cb.state {
+	case circuitOpen:
+		if time.Since(cb.openedAt) < openTimeout {
+			return false
+		}
+		cb.state = circuitHalfOpen
+		return true
+	case circuitHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+func (cb *circuitBreaker) onSuccess() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.state = circuitClosed
+	cb.failures = 0
+}
+
+// onFailure open the circuit if the trial request is failed or the failures reach threshold, 0 threshold never opens
+func (cb *circuitBreaker) onFailure(threshold int) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.failures++
+	if cb.state == circuitHalfOpen || (threshold > 0 && cb.failures >= threshold) {
+		if cb.state != circuitOpen {
+			logger.Warningf("circuit is open after %d consecutive failures", cb.failures)
+		}
+		cb.state = circuitOpen
+		cb.openedAt = time.Now()
+	}
+}
+
+// onAbort is called if the request is aborted by user, the node is neither healthy nor failed
+func (cb *circuitBreaker) onAbort() {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	if cb.state == circuitHalfOpen {
+		cb.state = circuitOpen
+	}
+}
+
+// isNodeFailure report whether the error is caused by the node instead of the request or user
+func isNodeFailure(err StdError) bool {
+	return err.Code() == GetResponseErrorCode
+}
+
+// available report whether a request can be sent to the node
+func (n *Node) available(openTimeout time.Duration) bool {
+	return n.status && atomic.LoadInt32(&n.ejected) == 0 && n.breaker.allow(openTimeout)
+}
+
+// Height return the block height of the node observed by the last health probe
+func (n *Node) Height() uint64 {
+	return atomic.LoadUint64(&n.height)
+}
+
+// healthProber probe the block height of every node periodically, a failed probe counts as a failed
+// request of the circuit breaker, and the stale or view-changing nodes are ejected until they catch up
+type healthProber struct {
+	rpc         *RPC
+	interval    time.Duration
+	maxBlockLag uint64
+	stop        chan struct{}
+	once        sync.Once
+}
+
+// StartHealthCheck probe the nodes every interval milliseconds in background, the nodes whose block
+// height lags the highest one more than maxBlockLag are not used until they catch up
+func (rpc *RPC) StartHealthCheck(interval int64, maxBlockLag uint64) *RPC {
+	rpc.StopHealthCheck()
+	prober := &healthProber{
+		rpc:         rpc,
+		interval:    time.Millisecond * time.Duration(interval),
+		maxBlockLag: maxBlockLag,
+		stop:        make(chan struct{}),
+	}
+	rpc.prober = prober
+	go prober.loop()
+	return rpc
+}
+
+// StopHealthCheck stop the background health prober, the ejected nodes are recovered
+func (rpc *RPC) StopHealthCheck() {
+	if rpc.prober == nil {
+		return
+	}
+	rpc.prober.once.Do(func() {
+		close(rpc.prober.stop)
+	})
+	rpc.prober = nil
+	for _, node := range rpc.hrm.nodes {
+		atomic.StoreInt32(&node.ejected, 0)
+	}
+}
+
+// CircuitBreaker setter, the circuit of a node is open after failureThreshold consecutive failed requests
+// and a trial request is allowed after openTimeout milliseconds, 0 failureThreshold disables it
+func (rpc *RPC) CircuitBreaker(failureThreshold int, openTimeout int64) *RPC {
+	rpc.hrm.failureThreshold = failureThreshold
+	rpc.hrm.openTimeout = openTimeout
+	return rpc
+}
+
+func (hp *healthProber) loop() {
+	ticker := time.NewTicker(hp.interval)
+	defer ticker.Stop()
+	for {
+		hp.probe()
+		select {
+		case <-hp.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe query the chain height of all nodes concurrently and eject the lagging ones
+func (hp *healthProber) probe() {
+	nodes := hp.rpc.hrm.nodes
+	heights := make([]uint64, len(nodes))
+	alive := make([]bool, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node *Node) {
+			defer wg.Done()
+			heights[i], alive[i] = hp.probeNode(node)
+		}(i, node)
+	}
+	wg.Wait()
+
+	var highest uint64
+	for i := range nodes {
+		if alive[i] && heights[i] > highest {
+			highest = heights[i]
+		}
+	}
+	for i, node := range nodes {
+		if !alive[i] {
+			continue
+		}
+		if highest-heights[i] > hp.maxBlockLag {
+			if atomic.SwapInt32(&node.ejected, 1) == 0 {
+				logger.Warningf("node %s is ejected, block height %d lags %d", node.url, heights[i], highest)
+			}
+			continue
+		}
+		if atomic.SwapInt32(&node.ejected, 0) == 1 {
+			logger.Infof("node %s caught up at block height %d", node.url, heights[i])
+		}
+	}
+}
+
+// probeNode return the chain height of node, the node is not alive if it's not reachable or in view change
+func (hp *healthProber) probeNode(node *Node) (uint64, bool) {
+	openTimeout := time.Millisecond * time.Duration(hp.rpc.hrm.openTimeout)
+	if !node.breaker.acquire(openTimeout) {
+		return 0, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), hp.interval)
+	defer cancel()
+	data, err := hp.rpc.WithContext(ctx).callWithSpecificURL(BLOCK+"getChainHeight", node.url)
+	if err != nil {
+		if isNodeFailure(err) || ctx.Err() != nil {
+			node.breaker.onFailure(hp.rpc.hrm.failureThreshold)
+			return 0, false
+		}
+		node.breaker.onSuccess()
+		if err.Code() == ConsensusStatusAbnormal || err.Code() == SystemBusyCode {
+			if atomic.SwapInt32(&node.ejected, 1) == 0 {
+				logger.Warningf("node %s is ejected: %v", node.url, err)
+			}
+		}
+		return 0, false
+	}
+	node.breaker.onSuccess()
+
+	var result string
+	if sysErr := json.Unmarshal(data, &result); sysErr != nil {
+		return 0, false
+	}
+	height, sysErr := strconv.ParseUint(result, 0, 64)
+	if sysErr != nil {
+		return 0, false
+	}
+	atomic.StoreUint64(&node.height, height)
+	return height, true
+}
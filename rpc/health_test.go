@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	cb := &circuitBreaker{}
+	timeout := 50 * time.Millisecond
+	cb.onFailure(2)
+	assert.True(t, cb.allow(timeout))
+	cb.onFailure(2)
+	assert.False(t, cb.allow(timeout))
+	assert.False(t, cb.acquire(timeout))
+
+	time.Sleep(timeout)
+	assert.True(t, cb.allow(timeout))
+	assert.True(t, cb.acquire(timeout))
+	// only one trial request in half-open state
+	assert.False(t, cb.allow(timeout))
+	cb.onFailure(2)
+	assert.False(t, cb.allow(timeout))
+
+	time.Sleep(timeout)
+	assert.True(t, cb.acquire(timeout))
+	cb.onSuccess()
+	assert.True(t, cb.allow(timeout))
+	assert.Equal(t, circuitClosed, cb.state)
+}
+
+func TestRPC_CircuitBreaker(t *testing.T) {
+	var count int32
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		// close the connection without response
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+	})
+	rpc.CircuitBreaker(2, 100000)
+
+	for i := 0; i < 2; i++ {
+		_, err := rpc.GetChainHeight()
+		assert.NotNil(t, err)
+		assert.Equal(t, GetResponseErrorCode, err.Code())
+	}
+	_, err := rpc.GetChainHeight()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "all nodes are bad")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+}
+
+func TestRPC_StartHealthCheck(t *testing.T) {
+	var genesis int32
+	newHandler := func(height string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			if strings.Contains(string(body), "getGenesisBlock") {
+				atomic.AddInt32(&genesis, 1)
+			}
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"message":"SUCCESS","result":"` + height + `"}`))
+		}
+	}
+	rpc1 := newTestRPC(t, newHandler("0x64"))
+	rpc2 := newTestRPC(t, newHandler("0x10"))
+	rpc := DefaultRPC(rpc1.hrm.nodes[0], rpc2.hrm.nodes[0])
+	rpc.StartHealthCheck(20, 10)
+	defer rpc.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&rpc.hrm.nodes[1].ejected) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, uint64(0x64), rpc.hrm.nodes[0].Height())
+	assert.Equal(t, uint64(0x10), rpc.hrm.nodes[1].Height())
+
+	// all requests are sent to the first node
+	for i := 0; i < 10; i++ {
+		result, err := rpc.GetGenesisBlock()
+		assert.Nil(t, err)
+		assert.Equal(t, "0x64", result)
+	}
+	assert.Equal(t, int32(10), atomic.LoadInt32(&genesis))
+
+	rpc.StopHealthCheck()
+	assert.Equal(t, int32(0), atomic.LoadInt32(&rpc.hrm.nodes[1].ejected))
+}
@@ -2,6 +2,7 @@ package rpc
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
@@ -12,7 +13,6 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -30,6 +30,12 @@ const (
 
 // Node is used to contain node info
 type Node struct {
+	// accessed atomically, keep them 64-bit aligned
+	inFlight    int64
+	latency     int64
+	height      uint64
+	ejected     int32
+	breaker     circuitBreaker
 	url         string
 	wsURL       string
 	status      bool
@@ -38,17 +44,19 @@ type Node struct {
 }
 
 func newNode(url string, rpcPort string, wsPort string, isHTTPS bool) (node *Node) {
-	var scheme string
+	var scheme, wsScheme string
 
 	if isHTTPS {
 		scheme = "https://"
+		wsScheme = "wss://"
 	} else {
 		scheme = "http://"
+		wsScheme = "ws://"
 	}
 
 	node = &Node{
 		url:    scheme + url + ":" + rpcPort,
-		wsURL:  "ws://" + url + ":" + wsPort,
+		wsURL:  wsScheme + url + ":" + wsPort,
 		status: true,
 	}
 	return node
@@ -74,6 +82,16 @@ type httpRequestManager struct {
 	tcm        *TCertManager
 	reConnTime int64
 	txVersion  string
+	selector   *selectorHolder
+	limiter    *RateLimiter
+
+	instrumentation Instrumentation
+	middlewares     []Middleware
+	logging         Middleware
+
+	// circuit breaker settings, openTimeout is in milliseconds
+	failureThreshold int
+	openTimeout      int64
 }
 
 // newHTTPRequestManager is used to construct httpRequestManager
@@ -106,6 +124,14 @@ func newHTTPRequestManager(cf *config.Config, confRootPath string) (hrm *httpReq
 
 	reConnTime := cf.GetReConnectTime()
 
+	loadBalance := cf.GetLoadBalance()
+	logger.Debugf("[CONFIG]: %s = %v", common.JSONRPCLoadBalance, loadBalance)
+	selector, err := NewNodeSelector(loadBalance)
+	if err != nil {
+		logger.Errorf("%v, use %s instead", err, RandomStrategy)
+		selector = &randomSelector{}
+	}
+
 	var nodes = make([]*Node, len(urls))
 
 	for i, url := range urls {
@@ -119,6 +145,28 @@ func newHTTPRequestManager(cf *config.Config, confRootPath string) (hrm *httpReq
 
 	tcm = NewTCertManager(cf.GetVipper(), confRootPath)
 
+	burst := cf.GetRateLimitBurst()
+	limiter := NewRateLimiter().
+		SetLimit(SendTxClass, RateLimit{Rate: cf.GetSendTxRateLimit(), Burst: burst}).
+		SetLimit(SimulateClass, RateLimit{Rate: cf.GetSimulateRateLimit(), Burst: burst}).
+		SetLimit(QueryClass, RateLimit{Rate: cf.GetQueryRateLimit(), Burst: burst})
+	for _, nodeLimit := range cf.GetNodeRateLimits() {
+		if nodeLimit.Index < 0 || nodeLimit.Index >= len(nodes) {
+			logger.Errorf("the rate limit of node %d is ignored, there are %d nodes", nodeLimit.Index, len(nodes))
+			continue
+		}
+		url := nodes[nodeLimit.Index].url
+		if nodeLimit.SendTx != nil {
+			limiter.SetNodeLimit(url, SendTxClass, RateLimit{Rate: *nodeLimit.SendTx, Burst: burst})
+		}
+		if nodeLimit.Simulate != nil {
+			limiter.SetNodeLimit(url, SimulateClass, RateLimit{Rate: *nodeLimit.Simulate, Burst: burst})
+		}
+		if nodeLimit.Query != nil {
+			limiter.SetNodeLimit(url, QueryClass, RateLimit{Rate: *nodeLimit.Query, Burst: burst})
+		}
+	}
+
 	txVersion := cf.GetTxVersion()
 	httpRequestManager := &httpRequestManager{
 		nodes:      nodes,
@@ -130,6 +178,12 @@ func newHTTPRequestManager(cf *config.Config, confRootPath string) (hrm *httpReq
 		isHTTP:     isHTTPS,
 		reConnTime: reConnTime,
 		txVersion:  txVersion,
+		selector:   newSelectorHolder(selector),
+		limiter:    limiter,
+		logging:    LogRequests,
+
+		failureThreshold: cf.GetHealthFailureThreshold(),
+		openTimeout:      cf.GetHealthOpenTimeout(),
 	}
 
 	if sendTcert && !cf.IsCfca() && !isFlato(txVersion) {
@@ -208,11 +262,17 @@ func defaultHTTPRequestManager() *httpRequestManager {
 		sendTcert: false,
 		tcm:       nil,
 		isHTTP:    false,
+		selector:  newSelectorHolder(&randomSelector{}),
+		limiter:   NewRateLimiter(),
+		logging:   LogRequests,
+
+		failureThreshold: DefaultFailureThreshold,
+		openTimeout:      DefaultOpenTimeout,
 	}
 }
 
-func post(url string, body []byte) (*http.Request, StdError) {
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+func post(ctx context.Context, url string, body []byte) (*http.Request, StdError) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	return req, NewGetResponseError(err)
 }
 
@@ -226,31 +286,78 @@ func addHeaders(req *http.Request, extraHeaders map[string]string) {
 
 // SyncRequest function is used to send http request
 func (hrm *httpRequestManager) SyncRequest(body []byte) ([]byte, StdError) {
-	curURL, stdErr := hrm.selectNodeURL()
+	return hrm.SyncRequestWithContext(context.Background(), body)
+}
+
+// SyncRequestWithContext is used to send http request, the request is aborted once ctx is done
+func (hrm *httpRequestManager) SyncRequestWithContext(ctx context.Context, body []byte) ([]byte, StdError) {
+	data, _, err := hrm.syncRequest(ctx, nil, body, &RequestInfo{Transport: JSONRPCTransport, Class: QueryClass})
+	return data, err
+}
+
+// syncRequest send the request to the node chosen by the NodeSelector once the rate limiter allows,
+// the node and attempts are recorded in info, and the node is returned as well, req may be nil if only the body is known
+func (hrm *httpRequestManager) syncRequest(ctx context.Context, req *JSONRequest, body []byte, info *RequestInfo) ([]byte, *Node, StdError) {
+	if ctx.Err() != nil {
+		return nil, nil, newContextError(ctx.Err())
+	}
+
+	node, stdErr := hrm.selectNode(info.Account)
 	if stdErr != nil {
 		hrm.resetNodeStatus()
-		return nil, stdErr
+		return nil, nil, stdErr
+	}
+	if stdErr = hrm.limiter.Wait(ctx, node.url, info.Class); stdErr != nil {
+		return nil, nil, stdErr
+	}
+	info.NodeURL = node.url
+	info.Attempt++
+
+	start := time.Now()
+	node.beginRequest()
+	res, err := hrm.roundTrip(ctx, newCall(req, body, node.url))
+	node.endRequest(time.Since(start))
+	err = withRequest(err, node.url, "")
+	switch {
+	case err == nil:
+		node.breaker.onSuccess()
+	case isNodeFailure(err):
+		node.breaker.onFailure(hrm.failureThreshold)
+	default:
+		node.breaker.onAbort()
 	}
-
-	res, err := hrm.SyncRequestSpecificURL(body, curURL, GENERAL, nil, nil)
 	if err != nil {
 		if strings.Contains(err.Error(), "connection refused") {
 			hrm.nodes[hrm.nodeIndex].status = false
-			return hrm.SyncRequest(body)
+			if hrm.instrumentation != nil {
+				hrm.instrumentation.Failover(ctx, node.url, err)
+			}
+			return hrm.syncRequest(ctx, req, body, info)
 		}
-		return nil, err
+		return nil, nil, err
 	}
 	hrm.resetNodeStatus()
-	return res, nil
+	return res, node, nil
 }
 
 // SyncRequestSpecificURL is used to post request to specific url
 func (hrm *httpRequestManager) SyncRequestSpecificURL(body []byte, url string, requestType RequestType, extraHeaders map[string]string, rwSeeker io.ReadWriteSeeker) ([]byte, StdError) {
+	return hrm.SyncRequestSpecificURLWithContext(context.Background(), body, url, requestType, extraHeaders, rwSeeker)
+}
+
+// SyncRequestSpecificURLWithContext is used to post request to specific url, the request is aborted once ctx is done
+func (hrm *httpRequestManager) SyncRequestSpecificURLWithContext(ctx context.Context, body []byte, url string, requestType RequestType, extraHeaders map[string]string, rwSeeker io.ReadWriteSeeker) ([]byte, StdError) {
+	return hrm.syncRequestSpecificURL(ctx, body, url, requestType, extraHeaders, nil, rwSeeker)
+}
+
+// syncRequestSpecificURL post request to specific url, extraHeaders are sent by the DOWNLOAD and UPLOAD requests,
+// callHeaders are the headers set by the middlewares, which are sent by all the requests
+func (hrm *httpRequestManager) syncRequestSpecificURL(ctx context.Context, body []byte, url string, requestType RequestType, extraHeaders, callHeaders map[string]string, rwSeeker io.ReadWriteSeeker) ([]byte, StdError) {
 	var req *http.Request
 	var stdErr StdError
 	switch requestType {
 	case DOWNLOAD:
-		req, stdErr = post(url, body)
+		req, stdErr = post(ctx, url, body)
 		if stdErr != nil {
 			return nil, stdErr
 		}
@@ -258,7 +365,7 @@ func (hrm *httpRequestManager) SyncRequestSpecificURL(body []byte, url string, r
 		req.Header.Add("content-type", "application/octet-stream")
 	case UPLOAD:
 		var err error
-		req, err = http.NewRequest(http.MethodPost, url, rwSeeker)
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, url, rwSeeker)
 		if err != nil {
 			return nil, NewSystemError(err)
 		}
@@ -267,11 +374,12 @@ func (hrm *httpRequestManager) SyncRequestSpecificURL(body []byte, url string, r
 	case GENERAL:
 		fallthrough
 	default:
-		req, stdErr = post(url, body)
+		req, stdErr = post(ctx, url, body)
 		if stdErr != nil {
 			return nil, stdErr
 		}
 	}
+	addHeaders(req, callHeaders)
 
 	if hrm.sendTcert {
 		if isFlato(hrm.txVersion) || hrm.tcm.cfca {
@@ -293,11 +401,11 @@ func (hrm *httpRequestManager) SyncRequestSpecificURL(body []byte, url string, r
 		}
 	}
 
-	logger.Debug("[URL]:", url)
-	logger.Debug("[REQUEST]:", string(body))
-
 	resp, sysErr := hrm.client.Do(req)
 	if sysErr != nil {
+		if ctx.Err() != nil {
+			return nil, newContextError(ctx.Err())
+		}
 		return nil, NewGetResponseError(sysErr)
 	}
 	defer resp.Body.Close()
@@ -324,7 +432,6 @@ func (hrm *httpRequestManager) SyncRequestSpecificURL(body []byte, url string, r
 			if err != nil {
 				return nil, NewSystemError(err)
 			}
-			logger.Debug("[RESPONSE]:", string(ret))
 			return ret, nil
 		}
 	} else if !isTemporaryError(resp.StatusCode) {
@@ -334,7 +441,11 @@ func (hrm *httpRequestManager) SyncRequestSpecificURL(body []byte, url string, r
 	// 请求异常返回，重连节点
 	hrm.ReConnectNode(hrm.nodeIndex)
 
-	return nil, NewGetResponseError(errors.New("http failed " + resp.Status))
+	return nil, &RetError{
+		code:       GetResponseErrorCode,
+		message:    "http failed " + resp.Status,
+		httpStatus: resp.StatusCode,
+	}
 }
 
 func isTemporaryError(code int) bool {
@@ -367,106 +478,91 @@ func (hrm *httpRequestManager) getTCert(url string) (string, StdError) {
 		return "", NewSystemError(sysErr)
 	}
 
-	req, stdErr := post(url, body)
+	ret, stdErr := hrm.roundTripWith(context.Background(), newCall(rawReq, body, url), RoundTripperFunc(hrm.sendTCertRequest))
 	if stdErr != nil {
 		return "", stdErr
 	}
 
-	signature, sysErr := hrm.tcm.sdkCert.Sign(body)
-	if sysErr != nil {
+	var resp *JSONResponse
+	if sysErr = json.Unmarshal(ret, &resp); sysErr != nil {
 		return "", NewSystemError(sysErr)
 	}
+
+	if resp.Code != SuccessCode {
+		return "", NewServerError(resp.Code, resp.Message)
+	}
+
+	var tcert TCertResponse
+	if err := json.Unmarshal(resp.Result, &tcert); err != nil {
+		return "", NewSystemError(err)
+	}
+	return tcert.TCert, nil
+}
+
+// sendTCertRequest post the request of getTCert signed by the ecert
+func (hrm *httpRequestManager) sendTCertRequest(ctx context.Context, call *Call) ([]byte, StdError) {
+	req, stdErr := post(ctx, call.URL, call.Body)
+	if stdErr != nil {
+		return nil, stdErr
+	}
+	addHeaders(req, call.Header)
+
+	signature, sysErr := hrm.tcm.sdkCert.Sign(call.Body)
+	if sysErr != nil {
+		return nil, NewSystemError(sysErr)
+	}
 	req.Header.Add("tcert", hrm.tcm.ecert)
 	req.Header.Add("signature", common.Bytes2Hex(signature))
-	req.Header.Add("msg", common.Bytes2Hex(body))
-
-	logger.Debug("[URL]:", url)
-	logger.Debug("[REQUEST]:", string(body))
+	req.Header.Add("msg", common.Bytes2Hex(call.Body))
 
 	resp, sysErr := hrm.client.Do(req)
 	if sysErr != nil {
-		return "", NewGetResponseError(sysErr)
+		return nil, NewGetResponseError(sysErr)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusOK {
-		ret, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return "", NewSystemError(err)
-		}
-		logger.Debug("[RESPONSE]:", string(ret))
-
-		var resp *JSONResponse
-		if sysErr = json.Unmarshal(ret, &resp); sysErr != nil {
-			return "", NewSystemError(sysErr)
-		}
-
-		if resp.Code != SuccessCode {
-			return "", NewServerError(resp.Code, resp.Message)
-		}
-
-		var tcert TCertResponse
-		if err := json.Unmarshal(resp.Result, &tcert); err != nil {
-			return "", NewSystemError(err)
-		}
-		return tcert.TCert, nil
+	if resp.StatusCode != http.StatusOK {
+		return nil, NewGetResponseError(errors.New("http failed " + resp.Status))
 	}
-
-	return "", NewGetResponseError(errors.New("http failed " + resp.Status))
+	ret, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, NewSystemError(err)
+	}
+	return ret, nil
 }
 
-func (hrm *httpRequestManager) selectNodeURL() (url string, err StdError) {
-	var tempNodes []*Node
-	for _, v := range hrm.nodes {
-		tempNodes = append(tempNodes, v)
-	}
-	sort.SliceStable(tempNodes, func(i, j int) bool {
-		if tempNodes[i].status && tempNodes[j].status {
-			return tempNodes[i].priority > tempNodes[j].priority
-		} else if tempNodes[i].status && !tempNodes[j].status {
-			return tempNodes[i].status
-		} else if !tempNodes[i].status && tempNodes[j].status {
-			return tempNodes[j].status
+// selectNode choose a node by the NodeSelector among the healthy nodes with the highest priority,
+// the nodes ejected by health check or with open circuit are skipped
+func (hrm *httpRequestManager) selectNode(account string) (*Node, StdError) {
+	openTimeout := time.Millisecond * time.Duration(hrm.openTimeout)
+	var candidates []*Node
+	for _, node := range hrm.nodes {
+		if !node.available(openTimeout) {
+			continue
 		}
-		return false
-	})
-
-	priorityNumMap := make(map[int][]*Node)
-	// for have sorted, priority in the list must be ordered
-	var priorityList []int
-	for _, v := range tempNodes {
-		if _, ok := priorityNumMap[v.priority]; !ok && v.status {
-			priorityList = append(priorityList, v.priority)
-		}
-		if v.status {
-			priorityNumMap[v.priority] = append(priorityNumMap[v.priority], v)
+		if len(candidates) == 0 || node.priority > candidates[0].priority {
+			candidates = []*Node{node}
+		} else if node.priority == candidates[0].priority {
+			candidates = append(candidates, node)
 		}
 	}
+	if len(candidates) == 0 {
+		return nil, NewGetResponseError(errors.New("all nodes are bad, please check it"))
+	}
 
-	// random with priority
-	for _, v := range priorityList {
-		curNodeList := priorityNumMap[v]
-		nodeNum := len(curNodeList)
-		randomNum := 2 * nodeNum
-		for randomNum > 0 {
-			selectedId := common.RandInt(nodeNum)
-			if curNodeList[selectedId].status {
-				hrm.nodeIndex = curNodeList[selectedId].originIndex
-				return curNodeList[selectedId].url, nil
-			}
-			randomNum--
-		}
-
-		//if random fail, try round
-		for i := 0; i < nodeNum; i++ {
-			if curNodeList[i].status {
-				hrm.nodeIndex = curNodeList[i].originIndex
-				return curNodeList[i].url, nil
-			}
+	node := hrm.selector.get().Select(candidates, account)
+	if node == nil {
+		node = candidates[0]
+	}
+	// the trial request of a half-open circuit may be taken by others meanwhile, send it anyway
+	node.breaker.acquire(openTimeout)
+	for i := range hrm.nodes {
+		if hrm.nodes[i] == node {
+			hrm.nodeIndex = i
+			break
 		}
 	}
-
-	return "", NewGetResponseError(errors.New("all nodes are bad, please check it"))
+	return node, nil
 }
 
 func (hrm *httpRequestManager) randomURL() (url string, err StdError) {
@@ -528,26 +624,15 @@ func (hrm *httpRequestManager) ReConnectNode(nodeIndex int) {
 	}
 
 	go func() {
-		request, err := post(url, body)
-		if err != nil {
-			logger.Error(err.String())
-		}
-
 		for {
-			response, err := hrm.client.Do(request)
-			if err != nil {
-				logger.Error(NewSystemError(err).String())
-			}
-
-			if response != nil && response.StatusCode == http.StatusOK {
-				b, _ := ioutil.ReadAll(response.Body)
-				logger.Debug("reconnection node body: ", string(b))
-				response.Body.Close()
+			ret, err := hrm.roundTripWith(context.Background(), newCall(req, body, url), RoundTripperFunc(hrm.sendReConnect))
+			if err == nil {
+				logger.Debug("reconnection node body: ", string(ret))
 				hrm.nodes[nodeIndex].status = true
 				logger.Info("node " + hrm.nodes[nodeIndex].url + " Reconnect Success!")
 				return
 			}
-			response.Body.Close()
+			logger.Error(err.String())
 			logger.Info("node " + hrm.nodes[nodeIndex].url + " Reconnect failed, will try one second later")
 			time.Sleep(time.Millisecond * time.Duration(hrm.reConnTime))
 		}
@@ -555,6 +640,30 @@ func (hrm *httpRequestManager) ReConnectNode(nodeIndex int) {
 
 }
 
+// sendReConnect post the reconnection request to the node, it succeeds only if the node returns 200
+func (hrm *httpRequestManager) sendReConnect(ctx context.Context, call *Call) ([]byte, StdError) {
+	request, stdErr := post(ctx, call.URL, call.Body)
+	if stdErr != nil {
+		return nil, stdErr
+	}
+	addHeaders(request, call.Header)
+
+	response, err := hrm.client.Do(request)
+	if err != nil {
+		return nil, NewGetResponseError(err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, NewGetResponseError(errors.New("http failed " + response.Status))
+	}
+	ret, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, NewSystemError(err)
+	}
+	return ret, nil
+}
+
 func isFlato(TxVersion string) bool {
 	return TxVersion != "1.0"
 }
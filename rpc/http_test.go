@@ -1,8 +1,16 @@
 package rpc
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestRPC_Reconnect(t *testing.T) {
@@ -17,3 +25,46 @@ func TestRPC_GetNode(t *testing.T) {
 	fmt.Print(rpc.GetNodes())
 
 }
+
+// newTestRPC start a json rpc server with the given handler and return a RPC bound to it
+func newTestRPC(t *testing.T, handler http.HandlerFunc) *RPC {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	assert.Nil(t, err)
+	return DefaultRPC(NewNode(host, port, port))
+}
+
+func TestRPC_WithContext_Deadline(t *testing.T) {
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := rpc.WithContext(ctx).GetChainHeight()
+	assert.NotNil(t, err)
+	assert.Equal(t, RequestTimeoutErrorCode, err.Code())
+	assert.True(t, time.Since(start) < time.Second)
+	assert.Equal(t, context.Background(), rpc.Context())
+}
+
+func TestRPC_WithContext_CancelPolling(t *testing.T) {
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":-32001,"message":"not found"}`))
+	})
+	rpc.FirstPollInterval(50).SecondPollInterval(50)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	_, err, finished := rpc.WithContext(ctx).GetTxReceiptByPolling("0x1234", false)
+	assert.True(t, finished)
+	assert.NotNil(t, err)
+	assert.Equal(t, RequestCanceledErrorCode, err.Code())
+	assert.True(t, time.Since(start) < time.Second)
+}
@@ -0,0 +1,211 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// SubmitStatus tells whether a transaction has been accepted by the chain
+type SubmitStatus int
+
+const (
+	// TxUnknown means it can not be determined whether the transaction is accepted, e.g. the network
+	// is broken or the context is done, query it by hash later before resending it
+	TxUnknown SubmitStatus = iota
+	// TxRejected means the transaction is not accepted, it's safe to modify and resend it
+	TxRejected
+	// TxPending means the transaction is accepted but its receipt is not available yet, do not resend it
+	TxPending
+	// TxCommitted means the transaction is executed and its receipt is returned
+	TxCommitted
+)
+
+func (s SubmitStatus) String() string {
+	switch s {
+	case TxRejected:
+		return "rejected"
+	case TxPending:
+		return "pending"
+	case TxCommitted:
+		return "committed"
+	default:
+		return "unknown"
+	}
+}
+
+// SubmitResult is the result of an idempotent submission
+type SubmitResult struct {
+	TxHash  string
+	Status  SubmitStatus
+	Receipt *TxReceipt
+}
+
+// Accepted report whether the transaction has been accepted by the chain, an accepted
+// transaction must not be resent even if its receipt is not got
+func (r *SubmitResult) Accepted() bool {
+	return r.Status == TxPending || r.Status == TxCommitted
+}
+
+// isBusyCode report whether the node refuses the request temporarily and it should be resent later
+func isBusyCode(code int) bool {
+	return code == SystemBusyCode ||
+		code == ConsensusStatusAbnormal ||
+		code == QPSLimit ||
+		code == DispatcherFull ||
+		code == SimulateLimit
+}
+
+// lookupTx check whether the transaction has landed by its receipt or the transaction itself
+func (rpc *RPC) lookupTx(txHash string, isPrivateTx bool) (*TxReceipt, bool, StdError) {
+	receipt, err := rpc.GetTxReceipt(txHash, isPrivateTx)
+	if err == nil {
+		return receipt, true, nil
+	}
+	if err.Code() != DataNotExistCode {
+		return nil, false, err
+	}
+	if isPrivateTx {
+		return nil, false, nil
+	}
+	if _, err = rpc.GetTransactionByHash(txHash); err != nil {
+		if err.Code() == DataNotExistCode {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return nil, true, nil
+}
+
+// callTransactionIdempotent send the transaction at most resTime times, keyed on its hash. Before every resend
+// it checks whether the former one has landed, and a duplicate transaction is regarded as accepted
+func (rpc *RPC) callTransactionIdempotent(method string, transaction *Transaction, param interface{}) (*SubmitResult, StdError) {
+	result := &SubmitResult{
+		TxHash: chPrefix(transaction.GetTransactionHash(transaction.gasLimit)),
+		Status: TxRejected,
+	}
+	if transaction.simulate {
+		receipt, err := rpc.callTransaction(method, transaction, param)
+		if err != nil {
+			return result, err
+		}
+		result.Status = TxCommitted
+		result.Receipt = receipt
+		return result, nil
+	}
+
+	req := rpc.jsonRPC(method, param)
+	req.transaction = transaction
+	req.noResend = true
+
+	var lastErr StdError
+	for i := int64(0); i < rpc.resTime; i++ {
+		if i > 0 {
+			receipt, found, err := rpc.lookupTx(result.TxHash, transaction.isPrivateTx)
+			if err != nil {
+				logger.Debugf("lookup transaction %s before resending error: %v", result.TxHash, err)
+			} else if found {
+				result.Receipt = receipt
+				return rpc.waitSubmitted(result, transaction.isPrivateTx)
+			}
+		}
+
+		data, err := rpc.callWithReq(req)
+		if err == nil {
+			var hash string
+			if sysErr := json.Unmarshal(data, &hash); sysErr != nil {
+				result.Status = TxUnknown
+				return result, NewSystemError(sysErr)
+			}
+			result.TxHash = chPrefix(hash)
+			return rpc.waitSubmitted(result, transaction.isPrivateTx)
+		}
+		lastErr = err
+
+		switch {
+		case err.Code() == DuplicateTransactionsCode:
+			return rpc.waitSubmitted(result, transaction.isPrivateTx)
+		case err.Code() == RequestTimeoutErrorCode || err.Code() == RequestCanceledErrorCode:
+			// the transaction may be sent before the context is done
+			result.Status = TxUnknown
+			return result, err
+		case isNodeFailure(err):
+			// the transaction may be received by the node before the connection is broken
+			result.Status = TxUnknown
+		case isBusyCode(err.Code()):
+			result.Status = TxRejected
+		default:
+			result.Status = TxRejected
+			return result, err
+		}
+		if waitErr := rpc.wait(rpc.firstPollInterval); waitErr != nil {
+			return result, waitErr
+		}
+	}
+
+	if receipt, found, err := rpc.lookupTx(result.TxHash, transaction.isPrivateTx); err == nil && found {
+		result.Receipt = receipt
+		return rpc.waitSubmitted(result, transaction.isPrivateTx)
+	}
+	if lastErr == nil {
+		lastErr = NewRequestTimeoutError(errors.New("request time out"))
+	}
+	return result, lastErr
+}
+
+// waitSubmitted poll the receipt of an accepted transaction, the result is pending if polling fails
+func (rpc *RPC) waitSubmitted(result *SubmitResult, isPrivateTx bool) (*SubmitResult, StdError) {
+	if result.Receipt != nil {
+		result.Status = TxCommitted
+		return result, nil
+	}
+	result.Status = TxPending
+	receipt, err, success := rpc.GetTxReceiptByPolling(result.TxHash, isPrivateTx)
+	if !success || (err != nil && (err.Code() == RequestTimeoutErrorCode || err.Code() == RequestCanceledErrorCode)) {
+		return result, err
+	}
+	result.Status = TxCommitted
+	result.Receipt = receipt
+	return result, err
+}
+
+// SendTxIdempotent send transaction and poll the receipt, it never sends the transaction twice,
+// the returned result tells whether the transaction is accepted even if an error is returned
+func (rpc *RPC) SendTxIdempotent(transaction *Transaction) (*SubmitResult, StdError) {
+	rpc.stampTxVersion(transaction)
+	method := TRANSACTION + "sendTransaction"
+	param := transaction.Serialize()
+	return rpc.callTransactionIdempotent(method, transaction, param)
+}
+
+// SignAndSendTxIdempotent sign and send transaction idempotently, see SendTxIdempotent
+func (rpc *RPC) SignAndSendTxIdempotent(transaction *Transaction, key interface{}) (*SubmitResult, StdError) {
+	transaction.txVersion = rpc.txVersion
+	transaction.Sign(key)
+	method := TRANSACTION + "sendTransaction"
+	param := transaction.Serialize()
+	return rpc.callTransactionIdempotent(method, transaction, param)
+}
+
+// InvokeContractIdempotent invoke contract idempotently, see SendTxIdempotent
+func (rpc *RPC) InvokeContractIdempotent(transaction *Transaction) (*SubmitResult, StdError) {
+	var method string
+	if transaction.isPrivateTx {
+		method = CONTRACT + "invokePrivateContract"
+	} else {
+		if !isTxVersion10(transaction.getTxVersion()) && transaction.simulate {
+			method = SIMULATE + "invokeContract"
+		} else {
+			method = CONTRACT + "invokeContract"
+		}
+	}
+	transaction.isInvoke = true
+	param := transaction.Serialize()
+	return rpc.callTransactionIdempotent(method, transaction, param)
+}
+
+// SignAndInvokeContractIdempotent sign and invoke contract idempotently, see SendTxIdempotent
+func (rpc *RPC) SignAndInvokeContractIdempotent(transaction *Transaction, key interface{}) (*SubmitResult, StdError) {
+	transaction.txVersion = rpc.txVersion
+	transaction.Sign(key)
+	return rpc.InvokeContractIdempotent(transaction)
+}
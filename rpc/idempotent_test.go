@@ -0,0 +1,88 @@
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newIdempotentTestRPC serve sendTransaction with the given handler, the receipt is found once landed is set
+func newIdempotentTestRPC(t *testing.T, landed *int32, send func(w http.ResponseWriter) bool) (*RPC, *int32) {
+	var sent int32
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		var req JSONRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch req.Method {
+		case TRANSACTION + "sendTransaction":
+			atomic.AddInt32(&sent, 1)
+			if send(w) {
+				atomic.StoreInt32(landed, 1)
+			}
+		case TRANSACTION + "getTransactionReceipt":
+			if atomic.LoadInt32(landed) == 1 {
+				_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"message":"SUCCESS","result":{"txHash":"` + req.Params[0].(string) + `","valid":true}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":-32001,"message":"not exist"}`))
+		default:
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":-32001,"message":"not exist"}`))
+		}
+	})
+	rpc.FirstPollInterval(10).SecondPollInterval(10).ResendTimes(3)
+	return rpc, &sent
+}
+
+func newIdempotentTestTx() *Transaction {
+	transaction := NewTransaction(privateKey.GetAddress().Hex()).Transfer(address, int64(0))
+	transaction.Sign(privateKey)
+	return transaction
+}
+
+func TestRPC_SendTxIdempotent_LandedBeforeBroken(t *testing.T) {
+	var landed int32
+	rpc, sent := newIdempotentTestRPC(t, &landed, func(w http.ResponseWriter) bool {
+		// the transaction is received but the connection is broken before response
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+		return true
+	})
+	transaction := newIdempotentTestTx()
+	result, err := rpc.SendTxIdempotent(transaction)
+	assert.Nil(t, err)
+	assert.Equal(t, TxCommitted, result.Status)
+	assert.True(t, result.Accepted())
+	assert.Equal(t, transaction.GetTransactionHash(transaction.gasLimit), result.TxHash)
+	assert.NotNil(t, result.Receipt)
+	assert.Equal(t, int32(1), atomic.LoadInt32(sent))
+}
+
+func TestRPC_SendTxIdempotent_Duplicate(t *testing.T) {
+	var landed int32
+	rpc, sent := newIdempotentTestRPC(t, &landed, func(w http.ResponseWriter) bool {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":-32007,"message":"duplicate transaction"}`))
+		return true
+	})
+	result, err := rpc.SendTxIdempotent(newIdempotentTestTx())
+	assert.Nil(t, err)
+	assert.Equal(t, TxCommitted, result.Status)
+	assert.Equal(t, int32(1), atomic.LoadInt32(sent))
+}
+
+func TestRPC_SendTxIdempotent_Busy(t *testing.T) {
+	var landed int32
+	rpc, sent := newIdempotentTestRPC(t, &landed, func(w http.ResponseWriter) bool {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":-32026,"message":"qps limit"}`))
+		return false
+	})
+	result, err := rpc.SendTxIdempotent(newIdempotentTestTx())
+	assert.NotNil(t, err)
+	assert.Equal(t, QPSLimit, err.Code())
+	assert.Equal(t, TxRejected, result.Status)
+	assert.False(t, result.Accepted())
+	assert.Equal(t, int32(3), atomic.LoadInt32(sent))
+}
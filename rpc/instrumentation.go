@@ -0,0 +1,114 @@
+package rpc
+
+import (
+	"context"
+	"time"
+)
+
+// transports of RequestInfo
+const (
+	JSONRPCTransport = "jsonrpc"
+	GRPCTransport    = "grpc"
+)
+
+// RequestInfo describes a request for instrumentation
+type RequestInfo struct {
+	Transport string
+	Method    string
+	Class     MethodClass
+	// Account is the sender of the transaction, it's empty for queries
+	Account string
+	// TxHash is the hash of the sent transaction, it's empty for queries
+	TxHash string
+	// NodeURL is set once the node is selected
+	NodeURL string
+	// Attempt is the number of times the request is sent, including the resends on busy and failover
+	Attempt int
+}
+
+// Instrumentation receives the events of requests, implement it to bind gosdk to a tracing or
+// metrics system such as OpenTelemetry or Prometheus, embed NoopInstrumentation to implement part of it
+type Instrumentation interface {
+	// RequestStart is called before the request is sent, the returned context is used to send
+	// the request and passed to RequestEnd, so that a span can be carried by it
+	RequestStart(ctx context.Context, info *RequestInfo) context.Context
+	// RequestEnd is called once the request is responded or failed
+	RequestEnd(ctx context.Context, info *RequestInfo, latency time.Duration, err StdError)
+	// PollingEnd is called once the receipt polling of a transaction is finished
+	PollingEnd(ctx context.Context, txHash string, rounds int, err StdError)
+	// Failover is called when a broken node is skipped and the request is sent to another node
+	Failover(ctx context.Context, nodeURL string, err StdError)
+}
+
+// NoopInstrumentation is an Instrumentation which does nothing
+type NoopInstrumentation struct{}
+
+func (NoopInstrumentation) RequestStart(ctx context.Context, info *RequestInfo) context.Context {
+	return ctx
+}
+
+func (NoopInstrumentation) RequestEnd(ctx context.Context, info *RequestInfo, latency time.Duration, err StdError) {
+}
+
+func (NoopInstrumentation) PollingEnd(ctx context.Context, txHash string, rounds int, err StdError) {}
+
+func (NoopInstrumentation) Failover(ctx context.Context, nodeURL string, err StdError) {}
+
+// instrumentationChain deliver the events to every Instrumentation in order,
+// the context returned by RequestStart is passed to the next one
+type instrumentationChain []Instrumentation
+
+// ChainInstrumentation combine multiple Instrumentation into one, nil ones are ignored
+func ChainInstrumentation(ins ...Instrumentation) Instrumentation {
+	var chain instrumentationChain
+	for _, in := range ins {
+		if in != nil {
+			chain = append(chain, in)
+		}
+	}
+	switch len(chain) {
+	case 0:
+		return nil
+	case 1:
+		return chain[0]
+	default:
+		return chain
+	}
+}
+
+func (c instrumentationChain) RequestStart(ctx context.Context, info *RequestInfo) context.Context {
+	for _, in := range c {
+		ctx = in.RequestStart(ctx, info)
+	}
+	return ctx
+}
+
+func (c instrumentationChain) RequestEnd(ctx context.Context, info *RequestInfo, latency time.Duration, err StdError) {
+	for i := len(c) - 1; i >= 0; i-- {
+		c[i].RequestEnd(ctx, info, latency, err)
+	}
+}
+
+func (c instrumentationChain) PollingEnd(ctx context.Context, txHash string, rounds int, err StdError) {
+	for _, in := range c {
+		in.PollingEnd(ctx, txHash, rounds, err)
+	}
+}
+
+func (c instrumentationChain) Failover(ctx context.Context, nodeURL string, err StdError) {
+	for _, in := range c {
+		in.Failover(ctx, nodeURL, err)
+	}
+}
+
+// Instrument set the Instrumentation of json rpc requests and receipt polling, multiple ones are chained
+func (rpc *RPC) Instrument(ins ...Instrumentation) *RPC {
+	rpc.hrm.instrumentation = ChainInstrumentation(ins...)
+	return rpc
+}
+
+// Instrument set the Instrumentation of grpc requests, multiple ones are chained
+func (g *GRPC) Instrument(ins ...Instrumentation) *GRPC {
+	g.instrumentation = ChainInstrumentation(ins...)
+	return g
+}
@@ -0,0 +1,143 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ctxKey string
+
+// recordInstrumentation record the events it receives
+type recordInstrumentation struct {
+	NoopInstrumentation
+	name   string
+	mutex  sync.Mutex
+	ends   []RequestInfo
+	errs   []StdError
+	rounds []int
+	ctxOK  bool
+}
+
+func (ri *recordInstrumentation) RequestStart(ctx context.Context, info *RequestInfo) context.Context {
+	ri.mutex.Lock()
+	defer ri.mutex.Unlock()
+	return context.WithValue(ctx, ctxKey(ri.name), true)
+}
+
+func (ri *recordInstrumentation) RequestEnd(ctx context.Context, info *RequestInfo, latency time.Duration, err StdError) {
+	ri.mutex.Lock()
+	defer ri.mutex.Unlock()
+	ri.ends = append(ri.ends, *info)
+	ri.errs = append(ri.errs, err)
+	ri.ctxOK = ctx.Value(ctxKey(ri.name)) != nil
+}
+
+func (ri *recordInstrumentation) PollingEnd(ctx context.Context, txHash string, rounds int, err StdError) {
+	ri.mutex.Lock()
+	defer ri.mutex.Unlock()
+	ri.rounds = append(ri.rounds, rounds)
+}
+
+func TestRPC_Instrument_Request(t *testing.T) {
+	var count int32
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) == 1 {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":-32026,"message":"qps limit"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":-32001,"message":"not found"}`))
+	})
+	ri := &recordInstrumentation{name: "a"}
+	rpc.Instrument(ri)
+
+	_, err := rpc.GetChainHeight()
+	assert.True(t, IsNotFound(err))
+	assert.Len(t, ri.ends, 1)
+	info := ri.ends[0]
+	assert.Equal(t, JSONRPCTransport, info.Transport)
+	assert.Equal(t, BLOCK+"getChainHeight", info.Method)
+	assert.Equal(t, QueryClass, info.Class)
+	assert.Equal(t, rpc.hrm.nodes[0].url, info.NodeURL)
+	// resent once on qps limit
+	assert.Equal(t, 2, info.Attempt)
+	assert.Equal(t, DataNotExistCode, ri.errs[0].Code())
+	assert.True(t, ri.ctxOK)
+}
+
+func TestRPC_Instrument_Polling(t *testing.T) {
+	var count int32
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		var req JSONRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if atomic.AddInt32(&count, 1) <= 2 {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":-32001,"message":"not exist"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"message":"SUCCESS","result":{"txHash":"` + req.Params[0].(string) + `","valid":true}}`))
+	})
+	ri := &recordInstrumentation{name: "a"}
+	rpc.FirstPollInterval(10).Instrument(ri)
+
+	receipt, err, success := rpc.GetTxReceiptByPolling("0x01", false)
+	assert.Nil(t, err)
+	assert.True(t, success)
+	assert.Equal(t, "0x01", receipt.TxHash)
+	assert.Equal(t, []int{3}, ri.rounds)
+	assert.Len(t, ri.ends, 3)
+}
+
+// orderInstrumentation append its name to the shared events
+type orderInstrumentation struct {
+	NoopInstrumentation
+	name   string
+	events *[]string
+}
+
+func (oi *orderInstrumentation) RequestStart(ctx context.Context, info *RequestInfo) context.Context {
+	*oi.events = append(*oi.events, oi.name+".start")
+	return context.WithValue(ctx, ctxKey(oi.name), true)
+}
+
+func (oi *orderInstrumentation) RequestEnd(ctx context.Context, info *RequestInfo, latency time.Duration, err StdError) {
+	if ctx.Value(ctxKey("a")) != nil && ctx.Value(ctxKey("b")) != nil {
+		*oi.events = append(*oi.events, oi.name+".end")
+	}
+}
+
+func TestChainInstrumentation(t *testing.T) {
+	var events []string
+	a := &orderInstrumentation{name: "a", events: &events}
+	b := &orderInstrumentation{name: "b", events: &events}
+	assert.Nil(t, ChainInstrumentation())
+	assert.Equal(t, a, ChainInstrumentation(nil, a))
+
+	chain := ChainInstrumentation(a, b)
+	ctx := chain.RequestStart(context.Background(), &RequestInfo{})
+	chain.RequestEnd(ctx, &RequestInfo{}, 0, nil)
+	// started in order and ended in reverse order, both contexts are carried to the end
+	assert.Equal(t, []string{"a.start", "b.start", "b.end", "a.end"}, events)
+}
+
+func TestRPC_Instrument_CallByPolling(t *testing.T) {
+	var count int32
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) <= 1 {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":-32001,"message":"not exist"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"message":"SUCCESS","result":{"hash":"0x01"}}`))
+	})
+	ri := &recordInstrumentation{name: "a"}
+	rpc.FirstPollInterval(10).Instrument(ri)
+
+	_, err := rpc.callByPolling(TRANSACTION+"getTransactionByHash", "0x01")
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2}, ri.rounds)
+}
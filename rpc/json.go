@@ -19,6 +19,8 @@ type JSONRequest struct {
 	Params      []interface{}   `json:"params,omitempty"`
 	Auth        *Authentication `json:"auth,omitempty"`
 	transaction *Transaction
+	// noResend return the busy errors to caller instead of resending the request
+	noResend bool
 }
 
 // Authentication contains params for api auth
@@ -36,6 +38,8 @@ type JSONResponse struct {
 	Namespace string          `json:"namespace"`
 	Code      int             `json:"code"`
 	Message   string          `json:"message"`
+	// Data is responded with the error, e.g. the revert data of a failed transaction
+	Data json.RawMessage `json:"data,omitempty"`
 }
 
 // JSONError is used to package http error info
@@ -0,0 +1,96 @@
+package rpc
+
+import (
+	"context"
+)
+
+// Call is a json rpc request passing through the middlewares, the web socket handshake passes through
+// them as well with Request and Body unset, only the Header is sent
+type Call struct {
+	// Request is the json rpc request, it's nil if the caller only has the serialized body
+	Request *JSONRequest
+	// Body is the serialized request to send, a middleware may replace it, e.g. to record or replay it
+	Body []byte
+	// URL is the url of the node
+	URL string
+	// Header is the extra http headers of the request
+	Header map[string]string
+}
+
+func newCall(req *JSONRequest, body []byte, url string) *Call {
+	return &Call{
+		Request: req,
+		Body:    body,
+		URL:     url,
+		Header:  make(map[string]string),
+	}
+}
+
+// RoundTripper send a json rpc request and return the raw JSONResponse
+type RoundTripper interface {
+	RoundTrip(ctx context.Context, call *Call) ([]byte, StdError)
+}
+
+// RoundTripperFunc is an adapter to use a function as RoundTripper
+type RoundTripperFunc func(ctx context.Context, call *Call) ([]byte, StdError)
+
+// RoundTrip call f(ctx, call)
+func (f RoundTripperFunc) RoundTrip(ctx context.Context, call *Call) ([]byte, StdError) {
+	return f(ctx, call)
+}
+
+// Middleware wraps a RoundTripper, it may modify the call before invoking next, or
+// inspect and replace the response, or return without invoking next at all
+type Middleware func(next RoundTripper) RoundTripper
+
+// Middlewares add middlewares of json rpc requests, the first one added is the outermost
+func (rpc *RPC) Middlewares(mws ...Middleware) *RPC {
+	for _, mw := range mws {
+		if mw != nil {
+			rpc.hrm.middlewares = append(rpc.hrm.middlewares, mw)
+		}
+	}
+	return rpc
+}
+
+// Logging replace the middleware logging the requests and responses, which is LogRequests by default,
+// it's the innermost one so it logs what is sent to the node, pass nil to disable the logging
+func (rpc *RPC) Logging(mw Middleware) *RPC {
+	rpc.hrm.logging = mw
+	return rpc
+}
+
+// LogRequests is the default logging middleware, it logs the url, the request and the response at debug level
+func LogRequests(next RoundTripper) RoundTripper {
+	return RoundTripperFunc(func(ctx context.Context, call *Call) ([]byte, StdError) {
+		logger.Debug("[URL]:", call.URL)
+		logger.Debug("[REQUEST]:", string(call.Body))
+		ret, err := next.RoundTrip(ctx, call)
+		if err == nil {
+			logger.Debug("[RESPONSE]:", string(ret))
+		}
+		return ret, err
+	})
+}
+
+// roundTrip send the json rpc request through the middlewares
+func (hrm *httpRequestManager) roundTrip(ctx context.Context, call *Call) ([]byte, StdError) {
+	return hrm.roundTripWith(ctx, call, RoundTripperFunc(hrm.send))
+}
+
+// roundTripWith send the json rpc request through the middlewares, the request is sent by final at last
+func (hrm *httpRequestManager) roundTripWith(ctx context.Context, call *Call, final RoundTripper) ([]byte, StdError) {
+	rt := final
+	if hrm.logging != nil {
+		rt = hrm.logging(rt)
+	}
+	for i := len(hrm.middlewares) - 1; i >= 0; i-- {
+		rt = hrm.middlewares[i](rt)
+	}
+	return rt.RoundTrip(ctx, call)
+}
+
+// send post the json rpc request to the node
+func (hrm *httpRequestManager) send(ctx context.Context, call *Call) ([]byte, StdError) {
+	return hrm.syncRequestSpecificURL(ctx, call.Body, call.URL, GENERAL, nil, call.Header, nil)
+}
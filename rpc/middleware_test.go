@@ -0,0 +1,158 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRPC_Middlewares(t *testing.T) {
+	var auth string
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"message":"SUCCESS","result":"0x1"}`))
+	})
+
+	var order []string
+	var method, raw string
+	rpc.Middlewares(func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, call *Call) ([]byte, StdError) {
+			order = append(order, "outer")
+			method = call.Request.Method
+			call.Header["Authorization"] = "Bearer token"
+			data, err := next.RoundTrip(ctx, call)
+			raw = string(data)
+			return data, err
+		})
+	}, func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, call *Call) ([]byte, StdError) {
+			order = append(order, "inner")
+			return next.RoundTrip(ctx, call)
+		})
+	})
+
+	height, err := rpc.GetChainHeight()
+	assert.Nil(t, err)
+	assert.Equal(t, "0x1", height)
+	assert.Equal(t, []string{"outer", "inner"}, order)
+	assert.Equal(t, BLOCK+"getChainHeight", method)
+	assert.Equal(t, "Bearer token", auth)
+	assert.Contains(t, raw, `"result":"0x1"`)
+}
+
+func TestRPC_Middlewares_Replay(t *testing.T) {
+	var sent int
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		sent++
+	})
+	// the response is replayed without sending the request
+	rpc.Middlewares(func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, call *Call) ([]byte, StdError) {
+			return []byte(`{"jsonrpc":"2.0","id":1,"code":0,"message":"SUCCESS","result":"0x2"}`), nil
+		})
+	})
+	height, err := rpc.GetChainHeight()
+	assert.Nil(t, err)
+	assert.Equal(t, "0x2", height)
+	assert.Equal(t, 0, sent)
+}
+
+func TestRPC_SyncRequestSpecificURL_General(t *testing.T) {
+	var params string
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		params = r.Header.Get("params")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"message":"SUCCESS","result":"0x1"}`))
+	})
+	// the extra headers are only sent by the DOWNLOAD and UPLOAD requests
+	_, err := rpc.hrm.SyncRequestSpecificURL([]byte(`{}`), rpc.hrm.nodes[0].url, GENERAL, map[string]string{"params": "0x01"}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "", params)
+}
+
+func TestRPC_Middlewares_FileManager(t *testing.T) {
+	var auth, params, content string
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		params = r.Header.Get("params")
+		b, _ := ioutil.ReadAll(r.Body)
+		content = string(b)
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"message":"SUCCESS","result":"0x1"}`))
+	})
+	var method string
+	rpc.Middlewares(func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, call *Call) ([]byte, StdError) {
+			method = call.Request.Method
+			call.Header["Authorization"] = "Bearer token"
+			return next.RoundTrip(ctx, call)
+		})
+	})
+
+	file, err := ioutil.TempFile(t.TempDir(), "upload")
+	assert.Nil(t, err)
+	defer file.Close()
+	_, err = file.WriteString("file content")
+	assert.Nil(t, err)
+	_, err = file.Seek(0, io.SeekStart)
+	assert.Nil(t, err)
+
+	ret, stdErr := rpc.callFM(FILE+"fileUpload", 1, UPLOAD, make(map[string]string), file, "0x01")
+	assert.Nil(t, stdErr)
+	assert.Equal(t, `"0x1"`, string(ret))
+	assert.Equal(t, FILE+"fileUpload", method)
+	assert.Equal(t, "Bearer token", auth)
+	assert.Contains(t, params, FILE+"fileUpload")
+	assert.Equal(t, "file content", content)
+}
+
+func TestRPC_Middlewares_ReConnectNode(t *testing.T) {
+	var auth atomic.Value
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		auth.Store(r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"message":"SUCCESS","result":[]}`))
+	})
+	rpc.Middlewares(func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, call *Call) ([]byte, StdError) {
+			call.Header["Authorization"] = "Bearer token"
+			return next.RoundTrip(ctx, call)
+		})
+	})
+
+	rpc.hrm.ReConnectNode(0)
+	assert.Eventually(t, func() bool {
+		return auth.Load() == "Bearer token"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRPC_Logging(t *testing.T) {
+	var sent string
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		sent = string(b)
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"message":"SUCCESS","result":"0x1"}`))
+	})
+	// the replaced logging middleware redacts the logged request without changing the sent one
+	var logged string
+	rpc.Logging(func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, call *Call) ([]byte, StdError) {
+			logged = strings.Replace(string(call.Body), "getChainHeight", "***", 1)
+			return next.RoundTrip(ctx, call)
+		})
+	})
+
+	_, err := rpc.GetChainHeight()
+	assert.Nil(t, err)
+	assert.Contains(t, sent, "getChainHeight")
+	assert.NotContains(t, logged, "getChainHeight")
+	assert.Contains(t, logged, "***")
+
+	rpc.Logging(nil)
+	_, err = rpc.GetChainHeight()
+	assert.Nil(t, err)
+}
@@ -214,7 +214,7 @@ func (mc *MqClient) call(id int, method string, url string, params ...interface{
 		return nil, NewSystemError(sysErr)
 	}
 
-	data, err := mc.hrm.SyncRequestSpecificURL(body, url, GENERAL, nil, nil)
+	data, err := mc.hrm.roundTrip(context.Background(), newCall(req, body, url))
 	if err != nil {
 		return nil, err
 	}
@@ -271,7 +271,7 @@ func (b *baseMq) call(id int, method string, url string, params ...interface{})
 		return nil, NewSystemError(sysErr)
 	}
 
-	data, err := b.hrm.SyncRequestSpecificURL(body, url, GENERAL, nil, nil)
+	data, err := b.hrm.roundTrip(context.Background(), newCall(req, body, url))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,186 @@
+package rpc
+
+import (
+	"errors"
+	"hash/fnv"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/meshplus/gosdk/common"
+)
+
+// load balancing strategies, used as the value of jsonRPC.loadBalance in hpc.toml
+const (
+	RandomStrategy        = "random"
+	RoundRobinStrategy    = "roundRobin"
+	LeastInFlightStrategy = "leastInFlight"
+	LatencyStrategy       = "latency"
+	StickyStrategy        = "sticky"
+)
+
+const (
+	// latencyDecay is the weight of the latest response time in the moving average of node latency
+	latencyDecay = 0.2
+)
+
+// NodeSelector decides which node a request is sent to. The candidates are the healthy
+// nodes with the highest priority, so the priority in hpc.toml is still respected,
+// account is the sender of the transaction and is empty for other requests
+type NodeSelector interface {
+	Select(candidates []*Node, account string) *Node
+}
+
+// NewNodeSelector return the built-in NodeSelector of the strategy
+func NewNodeSelector(strategy string) (NodeSelector, StdError) {
+	switch strategy {
+	case RandomStrategy, "":
+		return &randomSelector{}, nil
+	case RoundRobinStrategy:
+		return &roundRobinSelector{}, nil
+	case LeastInFlightStrategy:
+		return &leastInFlightSelector{}, nil
+	case LatencyStrategy:
+		return &latencySelector{}, nil
+	case StickyStrategy:
+		return &stickySelector{}, nil
+	default:
+		return nil, NewSystemError(errors.New("unsupported load balance strategy: " + strategy))
+	}
+}
+
+// randomSelector choose a candidate randomly, it's the default strategy
+type randomSelector struct{}
+
+func (s *randomSelector) Select(candidates []*Node, account string) *Node {
+	return candidates[common.RandInt(len(candidates))]
+}
+
+// roundRobinSelector choose the candidates in turn
+type roundRobinSelector struct {
+	counter uint64
+}
+
+func (s *roundRobinSelector) Select(candidates []*Node, account string) *Node {
+	next := atomic.AddUint64(&s.counter, 1) - 1
+	return candidates[next%uint64(len(candidates))]
+}
+
+// leastInFlightSelector choose the candidate with the fewest outstanding requests,
+// ties are broken randomly
+type leastInFlightSelector struct{}
+
+func (s *leastInFlightSelector) Select(candidates []*Node, account string) *Node {
+	var least []*Node
+	var min int64
+	for _, node := range candidates {
+		inFlight := node.InFlight()
+		if len(least) == 0 || inFlight < min {
+			least = []*Node{node}
+			min = inFlight
+		} else if inFlight == min {
+			least = append(least, node)
+		}
+	}
+	return least[common.RandInt(len(least))]
+}
+
+// latencySelector choose a candidate randomly with the weight of the reciprocal of its
+// average response time, the nodes that have not been measured are tried first
+type latencySelector struct{}
+
+func (s *latencySelector) Select(candidates []*Node, account string) *Node {
+	var unmeasured []*Node
+	weights := make([]float64, len(candidates))
+	var total float64
+	for i, node := range candidates {
+		latency := node.Latency()
+		if latency <= 0 {
+			unmeasured = append(unmeasured, node)
+			continue
+		}
+		weights[i] = 1 / float64(latency)
+		total += weights[i]
+	}
+	if len(unmeasured) > 0 {
+		return unmeasured[common.RandInt(len(unmeasured))]
+	}
+	r := rand.Float64() * total
+	for i, weight := range weights {
+		r -= weight
+		if r < 0 {
+			return candidates[i]
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+// stickySelector always send the requests of the same account to the same candidate,
+// so that the transactions of an account reach the node in order, other requests are random
+type stickySelector struct{}
+
+func (s *stickySelector) Select(candidates []*Node, account string) *Node {
+	if account == "" {
+		return candidates[common.RandInt(len(candidates))]
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(account))
+	return candidates[h.Sum32()%uint32(len(candidates))]
+}
+
+// selectorHolder share the NodeSelector between the copies of a RPC, so that it can be replaced at runtime
+type selectorHolder struct {
+	value atomic.Value
+}
+
+// selectorBox keeps the stored type of atomic.Value consistent for different selectors
+type selectorBox struct {
+	selector NodeSelector
+}
+
+func newSelectorHolder(selector NodeSelector) *selectorHolder {
+	holder := &selectorHolder{}
+	holder.set(selector)
+	return holder
+}
+
+func (h *selectorHolder) get() NodeSelector {
+	return h.value.Load().(selectorBox).selector
+}
+
+func (h *selectorHolder) set(selector NodeSelector) {
+	h.value.Store(selectorBox{selector: selector})
+}
+
+// URL return the json rpc url of the node
+func (n *Node) URL() string {
+	return n.url
+}
+
+// InFlight return the number of the requests which have been sent to the node but not responded yet
+func (n *Node) InFlight() int64 {
+	return atomic.LoadInt64(&n.inFlight)
+}
+
+// Latency return the moving average of the response time of the node, it's zero before any response
+func (n *Node) Latency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&n.latency))
+}
+
+func (n *Node) beginRequest() {
+	atomic.AddInt64(&n.inFlight, 1)
+}
+
+func (n *Node) endRequest(elapsed time.Duration) {
+	atomic.AddInt64(&n.inFlight, -1)
+	for {
+		old := atomic.LoadInt64(&n.latency)
+		latency := int64(elapsed)
+		if old > 0 {
+			latency = old + int64(float64(latency-old)*latencyDecay)
+		}
+		if atomic.CompareAndSwapInt64(&n.latency, old, latency) {
+			return
+		}
+	}
+}
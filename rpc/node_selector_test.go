@@ -0,0 +1,102 @@
+package rpc
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSelectorTestNodes(num int) []*Node {
+	nodes := make([]*Node, num)
+	for i := range nodes {
+		nodes[i] = NewNode("localhost", string(rune('1'+i)), "")
+	}
+	return nodes
+}
+
+func TestNewNodeSelector(t *testing.T) {
+	for _, strategy := range []string{RandomStrategy, RoundRobinStrategy, LeastInFlightStrategy, LatencyStrategy, StickyStrategy} {
+		selector, err := NewNodeSelector(strategy)
+		assert.Nil(t, err)
+		assert.NotNil(t, selector)
+	}
+	_, err := NewNodeSelector("unknown")
+	assert.NotNil(t, err)
+}
+
+func TestRoundRobinSelector(t *testing.T) {
+	nodes := newSelectorTestNodes(3)
+	selector, _ := NewNodeSelector(RoundRobinStrategy)
+	for i := 0; i < 6; i++ {
+		assert.Equal(t, nodes[i%3], selector.Select(nodes, ""))
+	}
+}
+
+func TestLeastInFlightSelector(t *testing.T) {
+	nodes := newSelectorTestNodes(3)
+	nodes[0].beginRequest()
+	nodes[2].beginRequest()
+	selector, _ := NewNodeSelector(LeastInFlightStrategy)
+	assert.Equal(t, nodes[1], selector.Select(nodes, ""))
+	nodes[0].endRequest(time.Millisecond)
+	assert.Equal(t, int64(0), nodes[0].InFlight())
+}
+
+func TestLatencySelector(t *testing.T) {
+	nodes := newSelectorTestNodes(2)
+	selector, _ := NewNodeSelector(LatencyStrategy)
+	nodes[0].beginRequest()
+	nodes[0].endRequest(time.Millisecond)
+	// unmeasured node is tried first
+	assert.Equal(t, nodes[1], selector.Select(nodes, ""))
+
+	nodes[1].beginRequest()
+	nodes[1].endRequest(time.Second)
+	assert.Equal(t, int64(0), nodes[0].InFlight())
+	assert.Equal(t, int64(0), nodes[1].InFlight())
+	count := 0
+	for i := 0; i < 1000; i++ {
+		if selector.Select(nodes, "") == nodes[0] {
+			count++
+		}
+	}
+	assert.True(t, count > 900)
+}
+
+func TestStickySelector(t *testing.T) {
+	nodes := newSelectorTestNodes(4)
+	selector, _ := NewNodeSelector(StickyStrategy)
+	account := "0x000f1a7a08ccc48e5d30f80850cf1cf283aa3abd"
+	node := selector.Select(nodes, account)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, node, selector.Select(nodes, account))
+	}
+}
+
+func TestRPC_SetNodeSelector(t *testing.T) {
+	var first, second int32
+	rpc1 := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&first, 1)
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"message":"SUCCESS","result":"0x1"}`))
+	})
+	rpc2 := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&second, 1)
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"message":"SUCCESS","result":"0x1"}`))
+	})
+	rpc := DefaultRPC(rpc1.hrm.nodes[0], rpc2.hrm.nodes[0])
+	selector, _ := NewNodeSelector(RoundRobinStrategy)
+	proxy := rpc.WithContext(rpc.Context())
+	rpc.SetNodeSelector(selector)
+
+	for i := 0; i < 4; i++ {
+		_, err := proxy.GetChainHeight()
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&first))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&second))
+	assert.Equal(t, int64(0), rpc.hrm.nodes[0].InFlight())
+	assert.True(t, rpc.hrm.nodes[0].Latency() > 0)
+}
@@ -0,0 +1,279 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/meshplus/gosdk/account"
+)
+
+// TxKind is the kind of a signed transaction, it decides the json rpc method to submit it
+type TxKind string
+
+const (
+	// TxKindTransfer transfer or plain transaction, submitted by SendTx
+	TxKindTransfer TxKind = "transfer"
+	// TxKindDeploy deploy contract transaction, submitted by DeployContract
+	TxKindDeploy TxKind = "deploy"
+	// TxKindInvoke invoke contract transaction, submitted by InvokeContract
+	TxKindInvoke TxKind = "invoke"
+	// TxKindMaintain maintain contract transaction, submitted by MaintainContract
+	TxKindMaintain TxKind = "maintain"
+	// TxKindDID did transaction
+	TxKindDID TxKind = "did"
+)
+
+// OfflineBuilder builds and signs transactions without a node, e.g. on an air-gapped machine.
+// The tx version, chain id and gas price an RPC queries from the node are given explicitly
+type OfflineBuilder struct {
+	txVersion string
+	chainID   string
+	gasPrice  int64
+}
+
+// NewOfflineBuilder create an OfflineBuilder, txVersion is what GetTxVersion returns on the target chain,
+// chainID is what GetNodeChainID returns and is needed by did accounts only,
+// gasPrice is what GetGasPrice returns
+func NewOfflineBuilder(txVersion, chainID string, gasPrice int64) (*OfflineBuilder, error) {
+	if txVersion == "" {
+		return nil, errors.New("tx version is empty")
+	}
+	if gasPrice < 0 {
+		return nil, fmt.Errorf("invalid gas price %d", gasPrice)
+	}
+	return &OfflineBuilder{txVersion: txVersion, chainID: chainID, gasPrice: gasPrice}, nil
+}
+
+// TxVersion return the tx version of the builder
+func (b *OfflineBuilder) TxVersion() string {
+	return b.txVersion
+}
+
+// ChainID return the chain id of the builder
+func (b *OfflineBuilder) ChainID() string {
+	return b.chainID
+}
+
+// GasPrice return the gas price of the builder
+func (b *OfflineBuilder) GasPrice() int64 {
+	return b.gasPrice
+}
+
+// NewTransaction return an empty transaction with the tx version and gas price of the builder
+func (b *OfflineBuilder) NewTransaction(from string) *Transaction {
+	t := NewTransaction(from)
+	t.txVersion = b.txVersion
+	t.gasPrice = b.gasPrice
+	return t
+}
+
+// NewPrivateTransaction return an empty private transaction with the tx version and gas price of the builder
+func (b *OfflineBuilder) NewPrivateTransaction(from string, participants []string) *Transaction {
+	t := NewPrivateTransaction(from, participants)
+	t.txVersion = b.txVersion
+	t.gasPrice = b.gasPrice
+	t.gasLimit = DefaultTxGasLimit
+	return t
+}
+
+// NewDIDAccount return the did account of the key on the chain of the builder
+func (b *OfflineBuilder) NewDIDAccount(key account.Key, suffix string) (*account.DIDKey, error) {
+	if b.chainID == "" {
+		return nil, errors.New("chain id is empty")
+	}
+	return account.NewDIDAccount(key, b.chainID, suffix), nil
+}
+
+// Sign sign the transaction with key, an account.Key or an account.Signer, and return the portable signed transaction
+func (b *OfflineBuilder) Sign(t *Transaction, key interface{}) (*SignedTransaction, error) {
+	t.txVersion = b.txVersion
+	t.gasPrice = b.gasPrice
+	t.Sign(key)
+	if t.signature == "" {
+		return nil, errors.New("sign transaction failed")
+	}
+	return NewSignedTransaction(t, b.chainID)
+}
+
+// SignedTransaction is a portable signed transaction, Tx is the json of Transaction.Serialize
+// and the other fields are the metadata needed to rebuild and submit it
+type SignedTransaction struct {
+	Kind         TxKind          `json:"kind"`
+	TxVersion    string          `json:"txVersion"`
+	ChainID      string          `json:"chainID,omitempty"`
+	Hash         string          `json:"hash"`
+	IsPrivate    bool            `json:"isPrivate,omitempty"`
+	Participants []string        `json:"participants,omitempty"`
+	IsByName     bool            `json:"isByName,omitempty"`
+	Tx           json.RawMessage `json:"tx"`
+}
+
+// signedTxJSON is the json of Transaction.Serialize
+type signedTxJSON struct {
+	From                string       `json:"from"`
+	To                  *string      `json:"to"`
+	Timestamp           int64        `json:"timestamp"`
+	Nonce               int64        `json:"nonce"`
+	Simulate            bool         `json:"simulate"`
+	Type                string       `json:"type"`
+	Value               *int64       `json:"value"`
+	Payload             string       `json:"payload"`
+	Signature           string       `json:"signature"`
+	Opcode              int64        `json:"opcode"`
+	Extra               *string      `json:"extra"`
+	ExtraIDInt64        []int64      `json:"extraIdInt64"`
+	ExtraIDString       []string     `json:"extraIdString"`
+	CName               string       `json:"cName"`
+	OptionExtra         string       `json:"optionExtra"`
+	Participant         *Participant `json:"participant"`
+	GasPrice            int64        `json:"gasPrice"`
+	GasLimit            int64        `json:"gasLimit"`
+	ExpirationTimestamp int64        `json:"expirationTimestamp"`
+	TxVersion           string       `json:"txVersion"`
+}
+
+// NewSignedTransaction return the portable form of the signed transaction
+func NewSignedTransaction(t *Transaction, chainID string) (*SignedTransaction, error) {
+	if t.signature == "" {
+		return nil, errors.New("transaction is not signed")
+	}
+	tx, err := json.Marshal(t.Serialize())
+	if err != nil {
+		return nil, err
+	}
+	return &SignedTransaction{
+		Kind:         kindOf(t),
+		TxVersion:    t.txVersion,
+		ChainID:      chainID,
+		Hash:         t.GetTransactionHash(t.gasLimit),
+		IsPrivate:    t.isPrivateTx,
+		Participants: t.participants,
+		IsByName:     t.isByName,
+		Tx:           tx,
+	}, nil
+}
+
+// ParseSignedTransaction parse the json of a SignedTransaction
+func ParseSignedTransaction(data []byte) (*SignedTransaction, error) {
+	var st SignedTransaction
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, err
+	}
+	if len(st.Tx) == 0 {
+		return nil, errors.New("signed transaction has no tx")
+	}
+	return &st, nil
+}
+
+// Marshal return the json of the signed transaction
+func (st *SignedTransaction) Marshal() ([]byte, error) {
+	return json.Marshal(st)
+}
+
+// Transaction rebuild the signed transaction, which can be submitted by SendTx, DeployContract,
+// InvokeContract or MaintainContract according to Kind
+func (st *SignedTransaction) Transaction() (*Transaction, error) {
+	var tj signedTxJSON
+	if err := json.Unmarshal(st.Tx, &tj); err != nil {
+		return nil, err
+	}
+	if tj.Signature == "" {
+		return nil, errors.New("transaction is not signed")
+	}
+	t := &Transaction{
+		from:                tj.From,
+		timestamp:           tj.Timestamp,
+		nonce:               tj.Nonce,
+		simulate:            tj.Simulate,
+		vmType:              tj.Type,
+		payload:             tj.Payload,
+		signature:           tj.Signature,
+		opcode:              tj.Opcode,
+		extraIdInt64:        tj.ExtraIDInt64,
+		extraIdString:       tj.ExtraIDString,
+		cName:               tj.CName,
+		optionExtra:         tj.OptionExtra,
+		participant:         tj.Participant,
+		gasPrice:            tj.GasPrice,
+		gasLimit:            tj.GasLimit,
+		expirationTimestamp: tj.ExpirationTimestamp,
+		txVersion:           tj.TxVersion,
+		isPrivateTx:         st.IsPrivate,
+		participants:        st.Participants,
+		isByName:            st.IsByName,
+		offline:             true,
+	}
+	// deploy and by name transactions don't serialize to, which is 0x0 as NewTransaction sets
+	t.to = "0x0"
+	if tj.To != nil {
+		t.to = *tj.To
+	}
+	if tj.Value != nil {
+		t.value = *tj.Value
+		t.isValue = true
+	}
+	if tj.Extra != nil {
+		t.extra = *tj.Extra
+		t.hasExtra = true
+	}
+	switch st.Kind {
+	case TxKindTransfer:
+	case TxKindDeploy:
+		t.isDeploy = true
+	case TxKindInvoke:
+		t.isInvoke = true
+	case TxKindMaintain:
+		t.isMaintain = true
+	case TxKindDID:
+		t.isDID = true
+	default:
+		return nil, fmt.Errorf("unknown transaction kind %s", st.Kind)
+	}
+	if hash := t.GetTransactionHash(t.gasLimit); st.Hash != "" && hash != st.Hash {
+		return nil, fmt.Errorf("transaction hash mismatched, expect %s, got %s", st.Hash, hash)
+	}
+	return t, nil
+}
+
+func kindOf(t *Transaction) TxKind {
+	switch {
+	case t.isDeploy:
+		return TxKindDeploy
+	case t.isInvoke:
+		return TxKindInvoke
+	case t.isMaintain:
+		return TxKindMaintain
+	case t.isDID:
+		return TxKindDID
+	default:
+		return TxKindTransfer
+	}
+}
+
+// SendSignedTransaction submit the transaction signed offline by the json rpc method of its kind
+func (rpc *RPC) SendSignedTransaction(st *SignedTransaction) (*TxReceipt, StdError) {
+	if st.TxVersion != rpc.txVersion {
+		logger.Warningf("the transaction is signed with tx version %s but the node is %s", st.TxVersion, rpc.txVersion)
+	}
+	t, err := st.Transaction()
+	if err != nil {
+		return nil, NewSystemError(err)
+	}
+	switch st.Kind {
+	case TxKindDeploy:
+		return rpc.DeployContract(t)
+	case TxKindInvoke:
+		return rpc.InvokeContract(t)
+	case TxKindMaintain:
+		return rpc.MaintainContract(t)
+	case TxKindDID:
+		method := DID + "sendDIDTransaction"
+		if t.simulate {
+			return rpc.callTransaction(method, t, t.Serialize())
+		}
+		return rpc.callTransactionByPolling(method, t, t.Serialize())
+	default:
+		return rpc.SendTx(t)
+	}
+}
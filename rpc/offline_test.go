@@ -0,0 +1,159 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/meshplus/gosdk/account"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOfflineBuilder_SignAndRebuild(t *testing.T) {
+	key, err := account.NewAccountFromPriv("a1fd6ed6225e76aac3884b5420c8cdbb4fde1db01e9ef773415b8f2b5a9b77b4")
+	assert.Nil(t, err)
+	builder, err := NewOfflineBuilder("3.6", "chainID", 100)
+	assert.Nil(t, err)
+
+	txs := map[TxKind]*Transaction{
+		TxKindTransfer: builder.NewTransaction(key.GetAddress().Hex()).Transfer("0x0000000000000000000000000000000000000001", 10),
+		TxKindDeploy:   builder.NewTransaction(key.GetAddress().Hex()).Deploy("6080604052"),
+		TxKindInvoke:   builder.NewTransaction(key.GetAddress().Hex()).Invoke("0x0000000000000000000000000000000000000002", []byte("payload of the invocation")),
+	}
+	for kind, tx := range txs {
+		st, err := builder.Sign(tx, key)
+		assert.Nil(t, err)
+		assert.Equal(t, kind, st.Kind)
+		assert.Equal(t, "3.6", st.TxVersion)
+		data, err := st.Marshal()
+		assert.Nil(t, err)
+
+		parsed, err := ParseSignedTransaction(data)
+		assert.Nil(t, err)
+		rebuilt, err := parsed.Transaction()
+		assert.Nil(t, err)
+		assert.Equal(t, tx.Serialize(), rebuilt.Serialize())
+		assert.Equal(t, needHashString(tx), needHashString(rebuilt))
+		assert.Equal(t, st.Hash, rebuilt.GetTransactionHash(rebuilt.gasLimit))
+		assert.Equal(t, int64(100), rebuilt.gasPrice)
+	}
+
+	tx := builder.NewTransaction(key.GetAddress().Hex()).Transfer("0x0000000000000000000000000000000000000001", 10)
+	_, err = NewSignedTransaction(tx, "")
+	assert.NotNil(t, err)
+
+	// a tampered transaction is rejected
+	st, err := builder.Sign(tx, key)
+	assert.Nil(t, err)
+	var fields map[string]interface{}
+	assert.Nil(t, json.Unmarshal(st.Tx, &fields))
+	fields["value"] = 11
+	st.Tx, _ = json.Marshal(fields)
+	_, err = st.Transaction()
+	assert.NotNil(t, err)
+
+	_, err = NewOfflineBuilder("", "", 0)
+	assert.NotNil(t, err)
+}
+
+func TestRPC_SendSignedTransaction(t *testing.T) {
+	var method string
+	var params []map[string]interface{}
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var req struct {
+			Method string                   `json:"method"`
+			Params []map[string]interface{} `json:"params"`
+		}
+		_ = json.Unmarshal(body, &req)
+		if req.Method != CONTRACT+"deployContract" && req.Method != SIMULATE+"deployContract" {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":-32601,"message":"method not found"}`))
+			return
+		}
+		method, params = req.Method, req.Params
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"message":"SUCCESS","result":{"txHash":"0x1234","contractAddress":"0x5678"}}`))
+	})
+
+	key, err := account.NewAccountFromPriv("a1fd6ed6225e76aac3884b5420c8cdbb4fde1db01e9ef773415b8f2b5a9b77b4")
+	assert.Nil(t, err)
+	builder, err := NewOfflineBuilder("3.6", "", 0)
+	assert.Nil(t, err)
+	st, err := builder.Sign(builder.NewTransaction(key.GetAddress().Hex()).Deploy("6080604052").Simulate(true), key)
+	assert.Nil(t, err)
+	data, _ := st.Marshal()
+
+	// the online process only has the json
+	parsed, err := ParseSignedTransaction(data)
+	assert.Nil(t, err)
+	receipt, stdErr := rpc.SendSignedTransaction(parsed)
+	assert.Nil(t, stdErr)
+	assert.Equal(t, "0x5678", receipt.ContractAddress)
+	assert.Equal(t, SIMULATE+"deployContract", method)
+	assert.Len(t, params, 1)
+	var signed map[string]interface{}
+	assert.Nil(t, json.Unmarshal(st.Tx, &signed))
+	assert.Equal(t, signed["signature"], params[0]["signature"])
+	assert.Equal(t, "3.6", params[0]["txVersion"])
+}
+
+func TestRPC_SendSignedTransaction_AsyncAndIdempotent(t *testing.T) {
+	var mutex sync.Mutex
+	var params []map[string]interface{}
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.Unmarshal(body, &req)
+		switch req.Method {
+		case TRANSACTION + "sendTransaction":
+			var param map[string]interface{}
+			_ = json.Unmarshal(req.Params[0], &param)
+			mutex.Lock()
+			params = append(params, param)
+			mutex.Unlock()
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"message":"SUCCESS","result":"0x1234"}`))
+		case TRANSACTION + "getTransactionReceipt":
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"message":"SUCCESS","result":{"txHash":` + string(req.Params[0]) + `,"valid":true}}`))
+		default:
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":-32001,"message":"not exist"}`))
+		}
+	})
+	rpc.FirstPollInterval(10).SecondPollInterval(10)
+
+	key, err := account.NewAccountFromPriv("a1fd6ed6225e76aac3884b5420c8cdbb4fde1db01e9ef773415b8f2b5a9b77b4")
+	assert.Nil(t, err)
+	builder, err := NewOfflineBuilder("3.6", "", 0)
+	assert.Nil(t, err)
+	st, err := builder.Sign(builder.NewTransaction(key.GetAddress().Hex()).Transfer("0x0000000000000000000000000000000000000001", 10), key)
+	assert.Nil(t, err)
+	var signed map[string]interface{}
+	assert.Nil(t, json.Unmarshal(st.Tx, &signed))
+
+	// the tx version of the node differs, the signed one is sent by both paths
+	rpc.txVersion = "2.5"
+	tx, err := st.Transaction()
+	assert.Nil(t, err)
+	future, stdErr := rpc.SendTxAsync(tx)
+	assert.Nil(t, stdErr)
+	_, stdErr = future.Wait(context.Background())
+	assert.Nil(t, stdErr)
+
+	tx, err = st.Transaction()
+	assert.Nil(t, err)
+	result, stdErr := rpc.SendTxIdempotent(tx)
+	assert.Nil(t, stdErr)
+	assert.Equal(t, TxCommitted, result.Status)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Len(t, params, 2)
+	for _, param := range params {
+		assert.Equal(t, signed["signature"], param["signature"])
+		assert.Equal(t, "3.6", param["txVersion"])
+	}
+}
@@ -34,6 +34,11 @@ func NewPrivateTransaction(from string, participants []string) *Transaction {
 
 // GenSinPub is used to signature needString
 func GenSinPub(key account.Key, needHashString string) string {
+	return genSinPub(key, needHashString)
+}
+
+// genSinPub is the same as GenSinPub but the key may be an account.Signer as well
+func genSinPub(key interface{}, needHashString string) string {
 	sig, err := sign(key, needHashString, false, false)
 	if err != nil {
 		logger.Error("ecdsa signature error")
@@ -44,6 +49,11 @@ func GenSinPub(key account.Key, needHashString string) string {
 
 // PreSign is used to constructor extra field in private transaction
 func (t *Transaction) PreSign(key account.Key) {
+	t.preSign(key)
+}
+
+// preSign is the same as PreSign but the key may be an account.Signer as well
+func (t *Transaction) preSign(key interface{}) {
 	sha3ToHex := func(value []byte) string {
 		h := sha3.NewKeccak256()
 		_, _ = h.Write(value)
@@ -90,7 +100,7 @@ func (t *Transaction) PreSign(key account.Key) {
 	t.extra = pubTxExtra.Stringify()
 	t.payload = ""
 
-	pubSig := GenSinPub(key, needHashString(t))
+	pubSig := genSinPub(key, needHashString(t))
 
 	// --- create sig_pri ---
 	extraBytesRaw := []byte(t.extra)
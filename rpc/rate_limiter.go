@@ -0,0 +1,221 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MethodClass classifies the requests for rate limiting
+type MethodClass string
+
+const (
+	// SendTxClass is the requests which send transactions
+	SendTxClass MethodClass = "sendTx"
+	// SimulateClass is the requests which simulate transactions
+	SimulateClass MethodClass = "simulate"
+	// QueryClass is the other requests
+	QueryClass MethodClass = "query"
+)
+
+const (
+	// minBackoff and maxBackoff bound the pause of a bucket after the node rejects it by QPSLimit or SimulateLimit
+	minBackoff = 10 * time.Millisecond
+	maxBackoff = 5 * time.Second
+	// minAdaptiveRate is the lowest rate a limited bucket backs off to
+	minAdaptiveRate = 1
+	// recoverRatio is the ratio of configured rate a bucket recovers by every successful request
+	recoverRatio = 0.05
+)
+
+// methodClass return the class of the json rpc request
+func methodClass(req *JSONRequest) MethodClass {
+	if req.transaction != nil {
+		if req.transaction.simulate {
+			return SimulateClass
+		}
+		return SendTxClass
+	}
+	if strings.HasPrefix(req.Method, SIMULATE) {
+		return SimulateClass
+	}
+	return QueryClass
+}
+
+// RateLimit is the limit of a token bucket, Rate tokens are put per second and at most Burst tokens are kept,
+// zero Rate means unlimited
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimiter is a client side token bucket limiter for each node and method class, it backs
+// off adaptively once the node rejects the requests by QPSLimit or SimulateLimit
+type RateLimiter struct {
+	mutex      sync.Mutex
+	limits     map[MethodClass]RateLimit
+	nodeLimits map[string]map[MethodClass]RateLimit
+	buckets    map[string]map[MethodClass]*tokenBucket
+}
+
+// NewRateLimiter return a RateLimiter which is unlimited for every class until the node rejects the requests
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		limits:     make(map[MethodClass]RateLimit),
+		nodeLimits: make(map[string]map[MethodClass]RateLimit),
+		buckets:    make(map[string]map[MethodClass]*tokenBucket),
+	}
+}
+
+// SetLimit set the limit of the class for every node which has no specific limit
+func (rl *RateLimiter) SetLimit(class MethodClass, limit RateLimit) *RateLimiter {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	rl.limits[class] = limit
+	for node, buckets := range rl.buckets {
+		if _, ok := rl.nodeLimits[node][class]; !ok {
+			delete(buckets, class)
+		}
+	}
+	return rl
+}
+
+// SetNodeLimit set the limit of the class for the node, node is the json rpc url of the node, e.g. http://localhost:8081,
+// the grpc requests sent to the same node share the limit
+func (rl *RateLimiter) SetNodeLimit(node string, class MethodClass, limit RateLimit) *RateLimiter {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	if rl.nodeLimits[node] == nil {
+		rl.nodeLimits[node] = make(map[MethodClass]RateLimit)
+	}
+	rl.nodeLimits[node][class] = limit
+	delete(rl.buckets[node], class)
+	return rl
+}
+
+func (rl *RateLimiter) bucket(node string, class MethodClass) *tokenBucket {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	if rl.buckets[node] == nil {
+		rl.buckets[node] = make(map[MethodClass]*tokenBucket)
+	}
+	b, ok := rl.buckets[node][class]
+	if !ok {
+		limit, ok := rl.nodeLimits[node][class]
+		if !ok {
+			limit = rl.limits[class]
+		}
+		b = newTokenBucket(limit)
+		rl.buckets[node][class] = b
+	}
+	return b
+}
+
+// Wait block until a request of the class can be sent to the node, or ctx is done
+func (rl *RateLimiter) Wait(ctx context.Context, node string, class MethodClass) StdError {
+	b := rl.bucket(node, class)
+	for {
+		delay := b.reserve(time.Now())
+		if delay <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return newContextError(ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// Backoff is called when the node rejects a request of the class by QPSLimit or SimulateLimit
+func (rl *RateLimiter) Backoff(node string, class MethodClass) {
+	rl.bucket(node, class).backoff(time.Now())
+}
+
+// Recover is called when a request of the class is accepted by the node
+func (rl *RateLimiter) Recover(node string, class MethodClass) {
+	rl.bucket(node, class).recover()
+}
+
+// tokenBucket is a token bucket whose rate is decreased on rejection and recovered on success
+type tokenBucket struct {
+	mutex   sync.Mutex
+	limit   RateLimit
+	rate    float64
+	tokens  float64
+	last    time.Time
+	pause   time.Duration
+	pauseTo time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	if limit.Burst <= 0 {
+		limit.Burst = 1
+	}
+	return &tokenBucket{
+		limit:  limit,
+		rate:   limit.Rate,
+		tokens: float64(limit.Burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve take a token and return zero, or return how long to wait for the next token
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if now.Before(b.pauseTo) {
+		return b.pauseTo.Sub(now)
+	}
+	if b.rate <= 0 {
+		return 0
+	}
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > float64(b.limit.Burst) {
+		b.tokens = float64(b.limit.Burst)
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// backoff pause the bucket exponentially and halve the rate of a limited bucket
+func (b *tokenBucket) backoff(now time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.pause < minBackoff {
+		b.pause = minBackoff
+	} else if b.pause < maxBackoff {
+		b.pause *= 2
+		if b.pause > maxBackoff {
+			b.pause = maxBackoff
+		}
+	}
+	b.pauseTo = now.Add(b.pause)
+	if b.rate > 0 {
+		b.rate /= 2
+		if b.rate < minAdaptiveRate {
+			b.rate = minAdaptiveRate
+		}
+		b.tokens = 0
+	}
+}
+
+// recover reset the pause and increase the rate towards the configured one
+func (b *tokenBucket) recover() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.pause = 0
+	if b.rate > 0 && b.rate < b.limit.Rate {
+		b.rate += b.limit.Rate * recoverRatio
+		if b.rate > b.limit.Rate {
+			b.rate = b.limit.Rate
+		}
+	}
+}
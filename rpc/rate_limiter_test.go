@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/meshplus/gosdk/grpc/pool"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_Wait(t *testing.T) {
+	limiter := NewRateLimiter().SetLimit(SendTxClass, RateLimit{Rate: 50, Burst: 1})
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		assert.Nil(t, limiter.Wait(context.Background(), "node1", SendTxClass))
+	}
+	// the first token is in the bucket, the others are put every 20ms
+	assert.True(t, time.Since(start) >= 90*time.Millisecond)
+
+	// query and other nodes are not limited
+	start = time.Now()
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, limiter.Wait(context.Background(), "node1", QueryClass))
+		assert.Nil(t, limiter.Wait(context.Background(), "node2", SimulateClass))
+	}
+	assert.True(t, time.Since(start) < 50*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	limiter.SetNodeLimit("node2", QueryClass, RateLimit{Rate: 0.1, Burst: 1})
+	assert.Nil(t, limiter.Wait(ctx, "node2", QueryClass))
+	err := limiter.Wait(ctx, "node2", QueryClass)
+	assert.NotNil(t, err)
+	assert.Equal(t, RequestTimeoutErrorCode, err.Code())
+}
+
+func TestRateLimiter_Backoff(t *testing.T) {
+	limiter := NewRateLimiter().SetLimit(SendTxClass, RateLimit{Rate: 100, Burst: 10})
+	limiter.Backoff("node1", SendTxClass)
+	limiter.Backoff("node1", SendTxClass)
+	b := limiter.bucket("node1", SendTxClass)
+	assert.Equal(t, float64(25), b.rate)
+	assert.Equal(t, 2*minBackoff, b.pause)
+	assert.True(t, b.reserve(time.Now()) > 0)
+
+	limiter.Recover("node1", SendTxClass)
+	assert.Equal(t, float64(30), b.rate)
+	assert.Equal(t, time.Duration(0), b.pause)
+}
+
+func TestRPC_RateLimiter_QPSLimit(t *testing.T) {
+	var count int32
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) <= 2 {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":-32026,"message":"qps limit"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"message":"SUCCESS","result":"0x1"}`))
+	})
+	start := time.Now()
+	height, err := rpc.GetChainHeight()
+	assert.Nil(t, err)
+	assert.Equal(t, "0x1", height)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&count))
+	// resent after backing off 10ms and 20ms
+	assert.True(t, time.Since(start) >= 3*minBackoff)
+}
+
+func TestGRPCNodeURLs(t *testing.T) {
+	cf := pool.NewConfigWithPath("../conf")
+	nodes := []*Node{newNode("localhost", "8081", "10001", false), newNode("localhost", "8082", "10002", false)}
+	urls := grpcNodeURLs(cf, nodes)
+	// the grpc requests of a node are limited by the url of its json rpc requests
+	assert.Equal(t, nodes[0].url, urls["localhost:11001"])
+	assert.Equal(t, nodes[0].url, urls["127.0.0.1:11001"])
+	assert.Equal(t, nodes[1].url, urls["127.0.0.1:11002"])
+	_, ok := urls["127.0.0.1:11003"]
+	assert.False(t, ok)
+}
@@ -0,0 +1,479 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// receiptBatchSize is the max number of hashes queried by one getBatchReceipt request
+	receiptBatchSize = 100
+	// maxReceiptBackoff bound the polling interval which is doubled by the failures of getBatchReceipt
+	maxReceiptBackoff = 5 * time.Second
+)
+
+// ReceiptFuture is the handle of a sent transaction whose receipt is resolved
+// in the background, it is returned by the *Async methods of RPC
+type ReceiptFuture struct {
+	// rpc is the RPC which the receipt is queried by, it carries the context and the nodes of the caller
+	rpc         *RPC
+	txHash      string
+	isPrivateTx bool
+
+	mutex     sync.Mutex
+	done      chan struct{}
+	receipt   *TxReceipt
+	err       StdError
+	callbacks []func(*TxReceipt, StdError)
+
+	// polling state, only accessed by receiptPoller
+	created  time.Time
+	nextPoll time.Time
+	rounds   int
+}
+
+func newReceiptFuture(rpc *RPC, txHash string, isPrivateTx bool) *ReceiptFuture {
+	now := time.Now()
+	return &ReceiptFuture{
+		rpc:         rpc,
+		txHash:      chPrefix(txHash),
+		isPrivateTx: isPrivateTx,
+		done:        make(chan struct{}),
+		created:     now,
+		nextPoll:    now,
+	}
+}
+
+// newResolvedReceiptFuture return a future which is already completed with the given result
+func newResolvedReceiptFuture(receipt *TxReceipt, err StdError) *ReceiptFuture {
+	var txHash string
+	if receipt != nil {
+		txHash = receipt.TxHash
+	}
+	future := newReceiptFuture(nil, txHash, false)
+	future.resolve(receipt, err)
+	return future
+}
+
+// Hash return the transaction hash tracked by the future
+func (f *ReceiptFuture) Hash() string {
+	return f.txHash
+}
+
+// Done return a channel that is closed once the receipt is resolved or the polling is failed
+func (f *ReceiptFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait block until the receipt is resolved or ctx is done, a done ctx only
+// stops the waiting, the receipt is still tracked in the background
+func (f *ReceiptFuture) Wait(ctx context.Context) (*TxReceipt, StdError) {
+	select {
+	case <-f.done:
+		return f.receipt, f.err
+	case <-ctx.Done():
+		return nil, newContextError(ctx.Err())
+	}
+}
+
+// OnComplete register a callback which is called in a new goroutine once the future is completed,
+// if the future has been completed the callback is called immediately
+func (f *ReceiptFuture) OnComplete(callback func(*TxReceipt, StdError)) {
+	f.mutex.Lock()
+	select {
+	case <-f.done:
+		f.mutex.Unlock()
+		go callback(f.receipt, f.err)
+		return
+	default:
+	}
+	f.callbacks = append(f.callbacks, callback)
+	f.mutex.Unlock()
+}
+
+// watchContext fail the future once the context of its rpc is done, onCancel is called after that
+func (f *ReceiptFuture) watchContext(onCancel func()) {
+	ctx := f.rpc.Context()
+	if ctx.Done() == nil {
+		return
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			f.resolve(nil, newContextError(ctx.Err()))
+			onCancel()
+		case <-f.done:
+		}
+	}()
+}
+
+func (f *ReceiptFuture) resolve(receipt *TxReceipt, err StdError) {
+	f.mutex.Lock()
+	select {
+	case <-f.done:
+		f.mutex.Unlock()
+		return
+	default:
+	}
+	f.receipt = receipt
+	f.err = err
+	close(f.done)
+	callbacks := f.callbacks
+	f.callbacks = nil
+	f.mutex.Unlock()
+
+	for _, callback := range callbacks {
+		go callback(receipt, err)
+	}
+}
+
+// receiptPoller is shared by all futures of a RPC and the proxies of it, it batches the
+// outstanding hashes through getBatchReceipt and exits when nothing is pending
+type receiptPoller struct {
+	rpc     *RPC
+	mutex   sync.Mutex
+	pending map[pendingKey]*ReceiptFuture
+	running bool
+	// wake interrupt the waiting of the loop once a future is tracked or canceled
+	wake chan struct{}
+	// batchFailures is the number of consecutive failed getBatchReceipt requests of each rpc
+	batchFailures map[*RPC]int
+}
+
+// pendingKey identify a tracked hash, the proxies of WithContext and BindNodes track the same hash separately
+type pendingKey struct {
+	rpc    *RPC
+	txHash string
+}
+
+func newReceiptPoller(rpc *RPC) *receiptPoller {
+	return &receiptPoller{
+		rpc:           rpc,
+		pending:       make(map[pendingKey]*ReceiptFuture),
+		wake:          make(chan struct{}, 1),
+		batchFailures: make(map[*RPC]int),
+	}
+}
+
+// track add the future to the pending set and start the polling goroutine if needed
+func (rp *receiptPoller) track(future *ReceiptFuture) {
+	rp.mutex.Lock()
+	defer rp.mutex.Unlock()
+	key := pendingKey{rpc: future.rpc, txHash: future.txHash}
+	if exist, ok := rp.pending[key]; ok {
+		// the same hash is tracked once, forward the result to the new future
+		exist.OnComplete(future.resolve)
+		return
+	}
+	rp.pending[key] = future
+	if !rp.running {
+		rp.running = true
+		go rp.loop()
+		return
+	}
+	// the loop may be waiting for the futures polled later
+	rp.wakeUp()
+}
+
+// wakeUp interrupt the waiting of the loop
+func (rp *receiptPoller) wakeUp() {
+	select {
+	case rp.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (rp *receiptPoller) loop() {
+	for {
+		rp.mutex.Lock()
+		if len(rp.pending) == 0 {
+			rp.running = false
+			rp.mutex.Unlock()
+			return
+		}
+		now := time.Now()
+		var public, private, canceled []*ReceiptFuture
+		for key, future := range rp.pending {
+			select {
+			case <-future.done:
+				// canceled by the context
+				delete(rp.pending, key)
+				canceled = append(canceled, future)
+				continue
+			default:
+			}
+			if now.Before(future.nextPoll) {
+				continue
+			}
+			if future.isPrivateTx {
+				private = append(private, future)
+			} else {
+				public = append(public, future)
+			}
+		}
+		rp.mutex.Unlock()
+
+		for _, future := range canceled {
+			rp.pollingEnd(future, future.err)
+		}
+		rp.pollFutures(public, private)
+		rp.schedule(append(public, private...))
+
+		timer := time.NewTimer(rp.nextWait())
+		select {
+		case <-timer.C:
+		case <-rp.wake:
+			timer.Stop()
+		}
+	}
+}
+
+// nextWait return how long to wait until the earliest polling time of the pending futures,
+// which follows the polling settings of the rpc of each future
+func (rp *receiptPoller) nextWait() time.Duration {
+	rp.mutex.Lock()
+	defer rp.mutex.Unlock()
+	var next time.Time
+	for _, future := range rp.pending {
+		if next.IsZero() || future.nextPoll.Before(next) {
+			next = future.nextPoll
+		}
+	}
+	if wait := time.Until(next); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// pollBatch query the receipts of futures by one request, fall back to query
+// one by one if the batch query is failed
+func (rp *receiptPoller) pollBatch(futures []*ReceiptFuture) {
+	hashes := make([]string, 0, len(futures))
+	for _, future := range futures {
+		hashes = append(hashes, future.txHash)
+	}
+	rpc := futures[0].rpc
+	receipts, err := rpc.GetBatchReceipt(hashes)
+	rp.mutex.Lock()
+	if err != nil {
+		rp.batchFailures[rpc]++
+	} else {
+		delete(rp.batchFailures, rpc)
+	}
+	rp.mutex.Unlock()
+	if err != nil {
+		// the futures are polled later and later by schedule until the batch query recovers
+		logger.Debugf("get batch receipt failed, query one by one: %v", err)
+		for _, future := range futures {
+			rp.pollOne(future)
+		}
+		return
+	}
+	found := make(map[string]TxReceipt, len(receipts))
+	for _, receipt := range receipts {
+		found[chPrefix(receipt.TxHash)] = receipt
+	}
+	for _, future := range futures {
+		if receipt, ok := found[future.txHash]; ok {
+			receipt.PrivTxHash = future.txHash
+			rp.complete(future, &receipt, nil)
+		}
+	}
+}
+
+func (rp *receiptPoller) pollOne(future *ReceiptFuture) {
+	receipt, err := future.rpc.GetTxReceipt(future.txHash, future.isPrivateTx)
+	if err != nil {
+		if err.Code() != DataNotExistCode && err.Code() != SystemBusyCode {
+			rp.complete(future, nil, err)
+		}
+		return
+	}
+	rp.complete(future, receipt, nil)
+}
+
+// schedule set the next polling time of the unresolved futures, the interval follows the
+// first and second polling settings of rpc, and the future fails once both are exhausted,
+// the interval of the public futures backs off while the batch query of their rpc fails
+func (rp *receiptPoller) schedule(futures []*ReceiptFuture) {
+	now := time.Now()
+	for _, future := range futures {
+		select {
+		case <-future.done:
+			continue
+		default:
+		}
+		rpc := future.rpc
+		firstPhase := time.Millisecond * time.Duration(rpc.firstPollTime*rpc.firstPollInterval)
+		secondPhase := time.Millisecond * time.Duration(rpc.secondPollTime*rpc.secondPollInterval)
+		elapsed := now.Sub(future.created)
+		var interval time.Duration
+		switch {
+		case elapsed < firstPhase:
+			interval = time.Millisecond * time.Duration(rpc.firstPollInterval)
+		case elapsed < firstPhase+secondPhase:
+			interval = time.Millisecond * time.Duration(rpc.secondPollInterval)
+		default:
+			rp.complete(future, nil, NewRequestTimeoutError(errors.New("polling failure")))
+			continue
+		}
+		if !future.isPrivateTx {
+			interval = rp.backoff(rpc, interval)
+		}
+		future.nextPoll = now.Add(interval)
+	}
+}
+
+// backoff double the interval for every consecutive failure of the batch query of rpc, up to maxReceiptBackoff
+func (rp *receiptPoller) backoff(rpc *RPC, interval time.Duration) time.Duration {
+	rp.mutex.Lock()
+	failures := rp.batchFailures[rpc]
+	rp.mutex.Unlock()
+	backoff := interval
+	for i := 0; i < failures && backoff < maxReceiptBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxReceiptBackoff && interval < maxReceiptBackoff {
+		backoff = maxReceiptBackoff
+	}
+	return backoff
+}
+
+// pollFutures query the receipts of public futures by batches of the same rpc and private futures one by one
+func (rp *receiptPoller) pollFutures(public, private []*ReceiptFuture) {
+	var rpcs []*RPC
+	byRPC := make(map[*RPC][]*ReceiptFuture)
+	for _, future := range public {
+		future.rounds++
+		if _, ok := byRPC[future.rpc]; !ok {
+			rpcs = append(rpcs, future.rpc)
+		}
+		byRPC[future.rpc] = append(byRPC[future.rpc], future)
+	}
+	for _, future := range private {
+		future.rounds++
+	}
+	for _, rpc := range rpcs {
+		futures := byRPC[rpc]
+		for start := 0; start < len(futures); start += receiptBatchSize {
+			end := start + receiptBatchSize
+			if end > len(futures) {
+				end = len(futures)
+			}
+			rp.pollBatch(futures[start:end])
+		}
+	}
+	for _, future := range private {
+		rp.pollOne(future)
+	}
+}
+
+// pollNow query the receipts of futures immediately, the unresolved ones are left to the polling loop
+func (rp *receiptPoller) pollNow(futures []*ReceiptFuture) {
+	var public, private []*ReceiptFuture
+	for _, future := range futures {
+		if future.isPrivateTx {
+			private = append(private, future)
+		} else {
+			public = append(public, future)
+		}
+	}
+	rp.pollFutures(public, private)
+	for _, future := range futures {
+		select {
+		case <-future.done:
+		default:
+			rp.track(future)
+		}
+	}
+}
+
+func (rp *receiptPoller) complete(future *ReceiptFuture, receipt *TxReceipt, err StdError) {
+	rp.mutex.Lock()
+	delete(rp.pending, pendingKey{rpc: future.rpc, txHash: future.txHash})
+	rp.mutex.Unlock()
+	rp.pollingEnd(future, err)
+	future.resolve(receipt, err)
+}
+
+// pollingEnd report the end of polling the future to the instrumentation
+func (rp *receiptPoller) pollingEnd(future *ReceiptFuture, err StdError) {
+	if future.rpc.hrm.instrumentation != nil {
+		future.rpc.hrm.instrumentation.PollingEnd(future.rpc.Context(), future.txHash, future.rounds, err)
+	}
+}
+
+// GetTxReceiptAsync track the receipt of a sent transaction in the background, the receipt
+// is confirmed by the block events if a receipt tracker is enabled, otherwise by polling through rpc,
+// the future fails once the context of rpc is done
+func (rpc *RPC) GetTxReceiptAsync(txHash string, isPrivateTx bool) *ReceiptFuture {
+	future := newReceiptFuture(rpc, txHash, isPrivateTx)
+	future.watchContext(rpc.poller.wakeUp)
+	if rpc.tracker != nil {
+		rpc.tracker.track(future)
+	} else {
+		rpc.poller.track(future)
+	}
+	return future
+}
+
+// sendTransactionAsync send the transaction and return a future of its receipt without waiting,
+// a simulate transaction is executed synchronously and returned as a completed future
+func (rpc *RPC) sendTransactionAsync(method string, transaction *Transaction, param interface{}) (*ReceiptFuture, StdError) {
+	if transaction.simulate {
+		return newResolvedReceiptFuture(rpc.callTransaction(method, transaction, param)), nil
+	}
+	data, err := rpc.callWithTransaction(method, transaction, param)
+	if err != nil {
+		return nil, err
+	}
+	var hash string
+	if sysErr := json.Unmarshal(data, &hash); sysErr != nil {
+		return nil, NewSystemError(sysErr)
+	}
+	return rpc.GetTxReceiptAsync(hash, transaction.isPrivateTx), nil
+}
+
+// SendTxAsync send transaction and return a future of the receipt immediately
+func (rpc *RPC) SendTxAsync(transaction *Transaction) (*ReceiptFuture, StdError) {
+	rpc.stampTxVersion(transaction)
+	method := TRANSACTION + "sendTransaction"
+	param := transaction.Serialize()
+	return rpc.sendTransactionAsync(method, transaction, param)
+}
+
+// SignAndSendTxAsync sign and send transaction, return a future of the receipt immediately
+func (rpc *RPC) SignAndSendTxAsync(transaction *Transaction, key interface{}) (*ReceiptFuture, StdError) {
+	transaction.txVersion = rpc.txVersion
+	transaction.Sign(key)
+	method := TRANSACTION + "sendTransaction"
+	param := transaction.Serialize()
+	return rpc.sendTransactionAsync(method, transaction, param)
+}
+
+// InvokeContractAsync invoke contract and return a future of the receipt immediately
+func (rpc *RPC) InvokeContractAsync(transaction *Transaction) (*ReceiptFuture, StdError) {
+	var method string
+	if transaction.isPrivateTx {
+		method = CONTRACT + "invokePrivateContract"
+	} else {
+		if !isTxVersion10(transaction.getTxVersion()) && transaction.simulate {
+			method = SIMULATE + "invokeContract"
+		} else {
+			method = CONTRACT + "invokeContract"
+		}
+	}
+	transaction.isInvoke = true
+	param := transaction.Serialize()
+	return rpc.sendTransactionAsync(method, transaction, param)
+}
+
+// SignAndInvokeContractAsync sign and invoke contract, return a future of the receipt immediately
+func (rpc *RPC) SignAndInvokeContractAsync(transaction *Transaction, key interface{}) (*ReceiptFuture, StdError) {
+	transaction.txVersion = rpc.txVersion
+	transaction.Sign(key)
+	return rpc.InvokeContractAsync(transaction)
+}
@@ -0,0 +1,149 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRPC_GetTxReceiptAsync(t *testing.T) {
+	var batchCalls int32
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		var req JSONRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		assert.Equal(t, TRANSACTION+"getBatchReceipt", req.Method)
+		// receipts are available from the second round
+		if atomic.AddInt32(&batchCalls, 1) < 2 {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"result":[]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"result":[{"txHash":"0x01","valid":true},{"txHash":"0x02","valid":true}]}`))
+	})
+	rpc.FirstPollInterval(10)
+
+	f1 := rpc.GetTxReceiptAsync("0x01", false)
+	f2 := rpc.GetTxReceiptAsync("02", false)
+	assert.Equal(t, "0x02", f2.Hash())
+
+	called := make(chan string, 1)
+	f2.OnComplete(func(receipt *TxReceipt, err StdError) {
+		called <- receipt.TxHash
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	receipt, err := f1.Wait(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "0x01", receipt.TxHash)
+	assert.Equal(t, "0x02", <-called)
+	<-f2.Done()
+	// both hashes are resolved by the same batch requests
+	assert.Equal(t, int32(2), atomic.LoadInt32(&batchCalls))
+}
+
+func TestRPC_GetTxReceiptAsync_Timeout(t *testing.T) {
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"code":%d,"message":"not exist"}`, DataNotExistCode)))
+	})
+	rpc.FirstPollInterval(10).FirstPollTime(2).SecondPollInterval(10).SecondPollTime(2)
+
+	future := rpc.GetTxReceiptAsync("0x01", false)
+	_, err := future.Wait(context.Background())
+	assert.NotNil(t, err)
+	assert.Equal(t, RequestTimeoutErrorCode, err.Code())
+
+	future.OnComplete(func(receipt *TxReceipt, err StdError) {
+		assert.Nil(t, receipt)
+	})
+}
+
+func TestRPC_GetTxReceiptAsync_WithContext(t *testing.T) {
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"code":%d,"message":"not exist"}`, DataNotExistCode)))
+	})
+	rpc.FirstPollInterval(1000).SecondPollInterval(1000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := rpc.WithContext(ctx).GetTxReceiptAsync("0x01", false)
+	// the same hash tracked by another rpc is not affected by the canceled one
+	pending := rpc.GetTxReceiptAsync("0x01", false)
+
+	start := time.Now()
+	cancel()
+	_, err := canceled.Wait(context.Background())
+	assert.NotNil(t, err)
+	assert.Equal(t, RequestCanceledErrorCode, err.Code())
+	assert.True(t, time.Since(start) < time.Second)
+
+	select {
+	case <-pending.Done():
+		t.Fatal("the future of the other rpc is resolved")
+	default:
+	}
+}
+
+func TestRPC_GetTxReceiptAsync_PollInterval(t *testing.T) {
+	var batchCalls int32
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&batchCalls, 1) < 3 {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"result":[]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"result":[{"txHash":"0x01","valid":true}]}`))
+	})
+	rpc.FirstPollInterval(1000)
+	// the future is polled by the interval of the proxy rather than the root rpc
+	proxy := rpc.WithContext(context.Background()).FirstPollInterval(10)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	receipt, err := proxy.GetTxReceiptAsync("0x01", false).Wait(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "0x01", receipt.TxHash)
+}
+
+func TestRPC_GetTxReceiptAsync_CancelPollingEnd(t *testing.T) {
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"result":[]}`))
+	})
+	ri := &recordInstrumentation{name: "a"}
+	rpc.FirstPollInterval(1000).Instrument(ri)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	future := rpc.WithContext(ctx).GetTxReceiptAsync("0x01", false)
+	cancel()
+	<-future.Done()
+	assert.Eventually(t, func() bool {
+		ri.mutex.Lock()
+		defer ri.mutex.Unlock()
+		return len(ri.rounds) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRPC_GetTxReceiptAsync_BatchBackoff(t *testing.T) {
+	var oneCalls int32
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		var req JSONRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Method == TRANSACTION+"getBatchReceipt" {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":-32601,"message":"method not found"}`))
+			return
+		}
+		atomic.AddInt32(&oneCalls, 1)
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"code":%d,"message":"not exist"}`, DataNotExistCode)))
+	})
+	rpc.FirstPollInterval(10).FirstPollTime(100)
+
+	rpc.GetTxReceiptAsync("0x01", false)
+	time.Sleep(300 * time.Millisecond)
+	// polled after 0, 20, 60, 140 and 300 milliseconds rather than every 10 milliseconds
+	assert.True(t, atomic.LoadInt32(&oneCalls) <= 6)
+	assert.Equal(t, 5*time.Second, rpc.poller.backoff(rpc, 4*time.Second))
+	assert.Equal(t, 10*time.Second, rpc.poller.backoff(rpc, 10*time.Second))
+}
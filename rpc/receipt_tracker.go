@@ -0,0 +1,226 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// recentTxLimit is the number of recently committed tx hashes kept by ReceiptTracker,
+	// in case a transaction is committed before it is tracked
+	recentTxLimit = 10000
+)
+
+// ReceiptTracker confirms the receipts of sent transactions by subscribing the new blocks
+// of a node once, the pending hashes are matched against the transactions of each block.
+// It falls back to http polling while the web socket connection is broken and resubscribes
+// every reConnTime milliseconds.
+type ReceiptTracker struct {
+	rpc       *RPC
+	wsCli     *WebSocketClient
+	nodeIndex int
+
+	mutex      sync.Mutex
+	pending    map[string]*ReceiptFuture
+	recent     map[string]struct{}
+	recentList []string
+	subID      SubscriptionID
+	subscribed bool
+	closed     bool
+}
+
+// EnableReceiptTracker subscribe the new blocks of the specific node, after that the receipts of
+// the *Async methods of rpc are confirmed by block events instead of polling
+// note: nodeIndex start from 1
+func (rpc *RPC) EnableReceiptTracker(nodeIndex int) (*ReceiptTracker, StdError) {
+	rt := &ReceiptTracker{
+		rpc: rpc,
+		wsCli: &WebSocketClient{
+			conns: make(map[int]*connectionWrapper, len(rpc.hrm.nodes)),
+			hrm:   &rpc.hrm,
+		},
+		nodeIndex: nodeIndex,
+		pending:   make(map[string]*ReceiptFuture),
+		recent:    make(map[string]struct{}),
+	}
+	if err := rt.subscribe(); err != nil {
+		return nil, err
+	}
+	rpc.tracker = rt
+	return rt, nil
+}
+
+func (rt *ReceiptTracker) subscribe() StdError {
+	filter := NewBlockEventFilter()
+	filter.SetBlockInfo(true)
+	subID, err := rt.wsCli.Subscribe(rt.nodeIndex, filter, rt)
+	if err != nil {
+		return err
+	}
+	rt.mutex.Lock()
+	rt.subID = subID
+	rt.subscribed = true
+	rt.mutex.Unlock()
+	return nil
+}
+
+// Track confirm the receipt of a sent transaction by block events
+func (rt *ReceiptTracker) Track(txHash string, isPrivateTx bool) *ReceiptFuture {
+	future := newReceiptFuture(rt.rpc, txHash, isPrivateTx)
+	rt.track(future)
+	return future
+}
+
+func (rt *ReceiptTracker) track(future *ReceiptFuture) {
+	rt.mutex.Lock()
+	// private transactions are not visible in block events
+	if rt.closed || !rt.subscribed || future.isPrivateTx {
+		rt.mutex.Unlock()
+		rt.rpc.poller.track(future)
+		return
+	}
+	if _, ok := rt.recent[future.txHash]; ok {
+		rt.mutex.Unlock()
+		go rt.rpc.poller.pollNow([]*ReceiptFuture{future})
+		return
+	}
+	if exist, ok := rt.pending[future.txHash]; ok {
+		rt.mutex.Unlock()
+		exist.OnComplete(future.resolve)
+		return
+	}
+	rt.pending[future.txHash] = future
+	rt.mutex.Unlock()
+
+	// fail the future as polling does if its block never arrives
+	budget := rt.rpc.firstPollTime*rt.rpc.firstPollInterval + rt.rpc.secondPollTime*rt.rpc.secondPollInterval
+	time.AfterFunc(time.Millisecond*time.Duration(budget), func() {
+		rt.mutex.Lock()
+		_, ok := rt.pending[future.txHash]
+		delete(rt.pending, future.txHash)
+		rt.mutex.Unlock()
+		if ok {
+			future.resolve(nil, NewRequestTimeoutError(errors.New("polling failure")))
+		}
+	})
+}
+
+// Close unsubscribe the block events, the pending transactions are confirmed by polling
+func (rt *ReceiptTracker) Close() StdError {
+	rt.mutex.Lock()
+	rt.closed = true
+	subscribed := rt.subscribed
+	rt.subscribed = false
+	rt.mutex.Unlock()
+	if rt.rpc.tracker == rt {
+		rt.rpc.tracker = nil
+	}
+	rt.fallback()
+	if subscribed {
+		return rt.wsCli.UnSubscribe(rt.subID)
+	}
+	return nil
+}
+
+// fallback hand all pending transactions over to polling
+func (rt *ReceiptTracker) fallback() {
+	rt.mutex.Lock()
+	pending := rt.pending
+	rt.pending = make(map[string]*ReceiptFuture)
+	rt.mutex.Unlock()
+	for _, future := range pending {
+		rt.rpc.poller.track(future)
+	}
+}
+
+// resubscribe try to subscribe the block events every reConnTime milliseconds until success or closed
+func (rt *ReceiptTracker) resubscribe() {
+	for {
+		time.Sleep(time.Millisecond * time.Duration(rt.rpc.reConnTime))
+		rt.mutex.Lock()
+		closed := rt.closed
+		rt.mutex.Unlock()
+		if closed {
+			return
+		}
+		if err := rt.subscribe(); err != nil {
+			logger.Debugf("receipt tracker resubscribe node%d failed: %v", rt.nodeIndex, err)
+			continue
+		}
+		logger.Infof("receipt tracker resubscribe node%d success", rt.nodeIndex)
+		return
+	}
+}
+
+// OnSubscribe implements WsEventHandler
+func (rt *ReceiptTracker) OnSubscribe() {}
+
+// OnUnSubscribe implements WsEventHandler
+func (rt *ReceiptTracker) OnUnSubscribe() {}
+
+// OnClose implements WsEventHandler, the tracker falls back to polling and resubscribes
+func (rt *ReceiptTracker) OnClose() {
+	rt.mutex.Lock()
+	closed := rt.closed
+	rt.subscribed = false
+	rt.mutex.Unlock()
+	if closed {
+		return
+	}
+	logger.Warningf("web socket of node%d closed, receipt tracker falls back to polling", rt.nodeIndex)
+	rt.fallback()
+	go rt.resubscribe()
+}
+
+// OnMessage implements WsEventHandler, it confirms the pending transactions of the block
+func (rt *ReceiptTracker) OnMessage(data []byte) {
+	var block BlockRaw
+	if err := json.Unmarshal(data, &block); err != nil {
+		logger.Errorf("receipt tracker decode block event error: %v", err)
+		return
+	}
+	hashes := make([]string, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		hashes = append(hashes, tx.Hash)
+	}
+
+	rt.mutex.Lock()
+	hasPending := len(rt.pending) > 0
+	rt.mutex.Unlock()
+	// invalid transactions are not packed in the block but they have receipts too
+	if number, err := strconv.ParseUint(block.Number, 0, 64); err == nil && hasPending {
+		invalidTxs, stdErr := rt.rpc.GetInvalidTransactionsByBlkNum(number)
+		if stdErr != nil {
+			logger.Debugf("receipt tracker get invalid transactions of block %d error: %v", number, stdErr)
+		}
+		for _, tx := range invalidTxs {
+			hashes = append(hashes, tx.Hash)
+		}
+	}
+
+	var matched []*ReceiptFuture
+	rt.mutex.Lock()
+	for _, hash := range hashes {
+		hash = chPrefix(hash)
+		if future, ok := rt.pending[hash]; ok {
+			matched = append(matched, future)
+			delete(rt.pending, hash)
+		}
+		rt.recent[hash] = struct{}{}
+		rt.recentList = append(rt.recentList, hash)
+	}
+	if overflow := len(rt.recentList) - recentTxLimit; overflow > 0 {
+		for _, hash := range rt.recentList[:overflow] {
+			delete(rt.recent, hash)
+		}
+		rt.recentList = append([]string(nil), rt.recentList[overflow:]...)
+	}
+	rt.mutex.Unlock()
+
+	if len(matched) > 0 {
+		rt.rpc.poller.pollNow(matched)
+	}
+}
@@ -0,0 +1,101 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTrackerTestRPC start a server which serves both json rpc and web socket, the
+// blocks sent to the returned channel are pushed to the block subscription
+func newTrackerTestRPC(t *testing.T, receiptReady *int32) (*RPC, chan<- string) {
+	blocks := make(chan string, 10)
+	upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if !assert.Nil(t, err) {
+				return
+			}
+			defer conn.Close()
+			_, _, _ = conn.ReadMessage()
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","code":0,"message":"SUCCESS","result":"0xsub01"}`))
+			for block := range blocks {
+				if block == "drop" {
+					return
+				}
+				_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","code":0,"result":{"event":"block","subscription":"0xsub01","data":`+block+`}}`))
+			}
+			return
+		}
+		var req JSONRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch req.Method {
+		case TRANSACTION + "getInvalidTransactionsByBlockNumber":
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"result":[]}`))
+		case TRANSACTION + "getBatchReceipt":
+			if atomic.LoadInt32(receiptReady) == 0 {
+				_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"result":[]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"result":[{"txHash":"0x01","valid":true},{"txHash":"0x02","valid":true}]}`))
+		}
+	})
+	t.Cleanup(func() { close(blocks) })
+	return rpc, blocks
+}
+
+func TestReceiptTracker_BlockEvent(t *testing.T) {
+	ready := int32(1)
+	rpc, blocks := newTrackerTestRPC(t, &ready)
+	// polling alone would never resolve the receipt in time
+	rpc.FirstPollInterval(time.Hour.Milliseconds())
+
+	tracker, err := rpc.EnableReceiptTracker(1)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer tracker.Close()
+
+	future := rpc.GetTxReceiptAsync("0x01", false)
+	blocks <- `{"number":"0x5","transactions":[{"hash":"0x01"}]}`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	receipt, err := future.Wait(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "0x01", receipt.TxHash)
+
+	// the hash is committed before being tracked
+	receipt, err = tracker.Track("0x01", false).Wait(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "0x01", receipt.TxHash)
+}
+
+func TestReceiptTracker_FallbackToPolling(t *testing.T) {
+	ready := int32(0)
+	rpc, blocks := newTrackerTestRPC(t, &ready)
+	rpc.FirstPollInterval(10).ReConnTime(time.Hour.Milliseconds())
+
+	tracker, err := rpc.EnableReceiptTracker(1)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer tracker.Close()
+
+	future := tracker.Track("0x02", false)
+	blocks <- "drop"
+	atomic.StoreInt32(&ready, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	receipt, err := future.Wait(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "0x02", receipt.TxHash)
+}
@@ -1,6 +1,7 @@
 package rpc
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -61,6 +62,13 @@ const (
 	DefaultSecondPollInterval = 1000
 	DefaultSecondPollTime     = 10
 	DefaultReConnectTime      = 10000
+	DefaultFailureThreshold   = 0
+	DefaultOpenTimeout        = 10000
+	DefaultWsMinBackoff       = 500
+	DefaultWsMaxBackoff       = 30000
+	DefaultWsFailoverAfter    = 3
+	DefaultStreamBatchSize    = 100
+	DefaultWsQueueSize        = 1024
 	DefaultTxVersion          = "3.0"
 )
 
@@ -99,6 +107,10 @@ type RPC struct {
 	gasPrice           int64
 	im                 *inspectorManager
 	config             *config.Config
+	ctx                context.Context
+	poller             *receiptPoller
+	tracker            *ReceiptTracker
+	prober             *healthProber
 }
 
 type inspectorManager struct {
@@ -166,8 +178,12 @@ func NewRPCWithPath(confRootPath string) *RPC {
 		im:                 im,
 		config:             cf,
 	}
+	rpc.poller = newReceiptPoller(rpc)
 
 	rpc.initGlobal()
+	if cf.IsHealthEnable() {
+		rpc.StartHealthCheck(cf.GetHealthInterval(), cf.GetHealthMaxBlockLag())
+	}
 	return rpc
 }
 
@@ -251,8 +267,10 @@ func DefaultRPC(nodes ...*Node) *RPC {
 		hrm:                *defaultHTTPRequestManager(),
 		txVersion:          DefaultTxVersion,
 		config:             config.Default(),
+		im:                 &inspectorManager{},
 	}
 	rpc.hrm.nodes = nodes
+	rpc.poller = newReceiptPoller(rpc)
 
 	return rpc
 }
@@ -265,6 +283,7 @@ func (rpc *RPC) Namespace(ns string) *RPC {
 
 // Close close release goroutine and http connection
 func (rpc *RPC) Close() {
+	rpc.StopHealthCheck()
 	rpc.hrm.client.CloseIdleConnections()
 }
 
@@ -315,6 +334,7 @@ func (rpc *RPC) Https(tlscaPath, tlspeerCertPath, tlspeerPrivPath string) *RPC {
 
 	for i := 0; i < len(rpc.hrm.nodes); i++ {
 		rpc.hrm.nodes[i].url = "https://" + strings.Split(rpc.hrm.nodes[i].url, "//")[1]
+		rpc.hrm.nodes[i].wsURL = "wss://" + strings.Split(rpc.hrm.nodes[i].wsURL, "//")[1]
 	}
 
 	return rpc
@@ -349,6 +369,25 @@ func (rpc *RPC) Tcert(cfca bool, sdkcertPath, sdkcertPrivPath, uniquePubPath, un
 	return rpc
 }
 
+// SetNodeSelector replace the load balancing strategy of json rpc requests, it takes effect
+// immediately for rpc and the instances generated by it
+func (rpc *RPC) SetNodeSelector(selector NodeSelector) *RPC {
+	if selector == nil {
+		selector = &randomSelector{}
+	}
+	rpc.hrm.selector.set(selector)
+	return rpc
+}
+
+// SetRateLimiter replace the client side rate limiter of json rpc requests
+func (rpc *RPC) SetRateLimiter(limiter *RateLimiter) *RPC {
+	if limiter == nil {
+		limiter = NewRateLimiter()
+	}
+	rpc.hrm.limiter = limiter
+	return rpc
+}
+
 // BindNodes generate a new RPC instance that bind with given indexes
 func (rpc *RPC) BindNodes(nodeIndexes ...int) (*RPC, error) {
 	if len(nodeIndexes) == 0 {
@@ -368,6 +407,38 @@ func (rpc *RPC) BindNodes(nodeIndexes ...int) (*RPC, error) {
 	return &proxy, nil
 }
 
+// WithContext generate a new RPC instance that carries the given ctx, every request of the
+// returned RPC, including the receipt polling, is aborted once ctx is canceled or its deadline exceeded
+func (rpc *RPC) WithContext(ctx context.Context) *RPC {
+	if ctx == nil {
+		panic("nil context")
+	}
+	proxy := *rpc
+	proxy.ctx = ctx
+	return &proxy
+}
+
+// Context return the context of rpc, it's context.Background() if WithContext has not been called
+func (rpc *RPC) Context() context.Context {
+	if rpc.ctx != nil {
+		return rpc.ctx
+	}
+	return context.Background()
+}
+
+// wait sleep for the polling interval, it returns early with an error once the context is done
+func (rpc *RPC) wait(pollingInterval int64) StdError {
+	ctx := rpc.Context()
+	timer := time.NewTimer(time.Millisecond * time.Duration(pollingInterval))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return newContextError(ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
+
 // package method name and params to JsonRequest
 func (rpc *RPC) jsonRPC(method string, params ...interface{}) *JSONRequest {
 	req := &JSONRequest{
@@ -412,23 +483,53 @@ func (rpc *RPC) callWithTransaction(method string, transaction *Transaction, par
 
 // callWithReq is a function to get response origin data
 func (rpc *RPC) callWithReq(req *JSONRequest) (json.RawMessage, StdError) {
+	info := &RequestInfo{
+		Transport: JSONRPCTransport,
+		Method:    req.Method,
+		Class:     methodClass(req),
+	}
+	ins := rpc.hrm.instrumentation
+	if ins == nil {
+		return rpc.sendReq(rpc.Context(), req, info)
+	}
+	if req.transaction != nil {
+		info.TxHash = req.transaction.GetTransactionHash(req.transaction.gasLimit)
+	}
+	ctx := ins.RequestStart(rpc.Context(), info)
+	start := time.Now()
+	data, err := rpc.sendReq(ctx, req, info)
+	ins.RequestEnd(ctx, info, time.Since(start), err)
+	return data, err
+}
+
+// sendReq send the request and resend it while the node is busy, the attempts are recorded in info
+func (rpc *RPC) sendReq(ctx context.Context, req *JSONRequest, info *RequestInfo) (json.RawMessage, StdError) {
 	body, sysErr := json.Marshal(req)
 	if sysErr != nil {
 		return nil, NewSystemError(sysErr)
 	}
 
-	data, err := rpc.hrm.SyncRequest(body)
+	if req.transaction != nil {
+		info.Account = req.transaction.from
+	}
+	class := info.Class
+	data, node, err := rpc.hrm.syncRequest(ctx, req, body, info)
 	if err != nil {
-		return nil, err
+		return nil, withRequest(err, "", req.Method)
 	}
 
 	var resp *JSONResponse
 	if sysErr = json.Unmarshal(data, &resp); sysErr != nil {
 		return nil, NewSystemError(sysErr)
 	}
+	if resp.Code == QPSLimit || resp.Code == SimulateLimit {
+		rpc.hrm.limiter.Backoff(node.url, class)
+	} else {
+		rpc.hrm.limiter.Recover(node.url, class)
+	}
 
 	if resp.Code != SuccessCode {
-		if req.transaction != nil && (resp.Code == InvalidSignature || (resp.Code == InvalidParams && strings.Contains(strings.ToLower(resp.Message), "version"))) {
+		if req.transaction != nil && !req.transaction.offline && (resp.Code == InvalidSignature || (resp.Code == InvalidParams && strings.Contains(strings.ToLower(resp.Message), "version"))) {
 			preTxVersion := TxVersion
 			rpc.initGlobal()
 			if req.transaction.txVersion != TxVersion && preTxVersion != TxVersion {
@@ -438,13 +539,16 @@ func (rpc *RPC) callWithReq(req *JSONRequest) (json.RawMessage, StdError) {
 				return rpc.call(req.Method, req.transaction.Serialize())
 			}
 		}
-		if resp.Code == ConsensusStatusAbnormal ||
+		if !req.noResend && (resp.Code == ConsensusStatusAbnormal ||
 			resp.Code == QPSLimit ||
 			resp.Code == DispatcherFull ||
-			resp.Code == SimulateLimit {
-			return rpc.callWithReq(req)
+			resp.Code == SimulateLimit) {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, newContextError(ctxErr)
+			}
+			return rpc.sendReq(ctx, req, info)
 		}
-		return nil, NewServerError(resp.Code, resp.Message)
+		return nil, withRequest(withData(NewServerError(resp.Code, resp.Message), resp.Data), node.url, req.Method)
 	}
 
 	return resp.Result, nil
@@ -459,9 +563,9 @@ func (rpc *RPC) callWithSpecificURL(method string, url string, params ...interfa
 		return nil, NewSystemError(sysErr)
 	}
 
-	data, err := rpc.hrm.SyncRequestSpecificURL(body, url, GENERAL, nil, nil)
+	data, err := rpc.hrm.roundTrip(rpc.Context(), newCall(req, body, url))
 	if err != nil {
-		return nil, err
+		return nil, withRequest(err, url, method)
 	}
 
 	var resp *JSONResponse
@@ -470,48 +574,71 @@ func (rpc *RPC) callWithSpecificURL(method string, url string, params ...interfa
 	}
 
 	if resp.Code != SuccessCode {
-		return nil, NewServerError(resp.Code, resp.Message)
+		return nil, withRequest(withData(NewServerError(resp.Code, resp.Message), resp.Data), url, method)
 	}
 
 	return resp.Result, nil
 }
 
+// callByPolling call the method until the data exists, Instrumentation.PollingEnd is emitted once
+// the polling is finished, the txHash of it is the first param if the param is a string
 func (rpc *RPC) callByPolling(method string, params ...interface{}) (json.RawMessage, StdError) {
 	req := rpc.jsonRPC(method, params...)
+	resp, err, rounds := rpc.pollWithReq(req)
+	if rpc.hrm.instrumentation != nil {
+		var txHash string
+		if len(params) > 0 {
+			txHash, _ = params[0].(string)
+		}
+		rpc.hrm.instrumentation.PollingEnd(rpc.Context(), txHash, rounds, err)
+	}
+	return resp, err
+}
+
+// pollWithReq poll req by the two phases, the number of polling rounds is returned as well
+func (rpc *RPC) pollWithReq(req *JSONRequest) (json.RawMessage, StdError, int) {
+	var rounds int
 	for i := int64(0); i < rpc.resTime; i++ {
-		resp, err := rpc.callWithReqByPolling(req, rpc.firstPollTime, rpc.firstPollInterval)
+		resp, err, n := rpc.callWithReqByPolling(req, rpc.firstPollTime, rpc.firstPollInterval)
+		rounds += n
 		if err != nil {
-			return nil, err
+			return nil, err, rounds
 		}
 		if resp != nil {
-			return resp, nil
+			return resp, nil, rounds
 		}
-		resp, err = rpc.callWithReqByPolling(req, rpc.secondPollTime, rpc.secondPollInterval)
+		resp, err, n = rpc.callWithReqByPolling(req, rpc.secondPollTime, rpc.secondPollInterval)
+		rounds += n
 		if err != nil {
-			return nil, err
+			return nil, err, rounds
 		}
 		if resp != nil {
-			return resp, nil
+			return resp, nil, rounds
 		}
 	}
-	return nil, NewRequestTimeoutError(errors.New("request time out"))
+	return nil, NewRequestTimeoutError(errors.New("request time out")), rounds
 }
 
-func (rpc *RPC) callWithReqByPolling(req *JSONRequest, pollingTime int64, pollingInterval int64) (json.RawMessage, StdError) {
+// callWithReqByPolling poll req in a phase, the number of polling rounds is returned as well
+func (rpc *RPC) callWithReqByPolling(req *JSONRequest, pollingTime int64, pollingInterval int64) (json.RawMessage, StdError, int) {
+	var rounds int
 	for j := int64(0); j < pollingTime; j++ {
+		rounds++
 		resp, err := rpc.callWithReq(req)
 		if err != nil {
 			if err.Code() == BalanceInsufficientCode {
-				return nil, err
+				return nil, err, rounds
 			} else if err.Code() != DataNotExistCode && err.Code() != SystemBusyCode {
-				return nil, err
+				return nil, err, rounds
+			}
+			if waitErr := rpc.wait(pollingInterval); waitErr != nil {
+				return nil, waitErr, rounds
 			}
-			time.Sleep(time.Millisecond * time.Duration(pollingInterval))
 		} else {
-			return resp, nil
+			return resp, nil, rounds
 		}
 	}
-	return nil, nil
+	return nil, nil, rounds
 }
 
 // Call call and get tx receipt directly without polling
@@ -629,39 +756,55 @@ func (rpc *RPC) GetGasPrice() (int64, error) {
 
 // GetTxReceiptByPolling get tx receipt by polling
 func (rpc *RPC) GetTxReceiptByPolling(txHash string, isPrivateTx bool) (*TxReceipt, StdError, bool) {
+	txHash = chPrefix(txHash)
+	receipt, err, success, rounds := rpc.pollTxReceipt(txHash, isPrivateTx)
+	if rpc.hrm.instrumentation != nil {
+		rpc.hrm.instrumentation.PollingEnd(rpc.Context(), txHash, rounds, err)
+	}
+	return receipt, err, success
+}
+
+// pollTxReceipt get tx receipt by polling, the number of polling rounds is returned as well
+func (rpc *RPC) pollTxReceipt(txHash string, isPrivateTx bool) (*TxReceipt, StdError, bool, int) {
 	var (
 		err     StdError
 		receipt *TxReceipt
+		rounds  int
 	)
-	txHash = chPrefix(txHash)
 
 	for j := int64(0); j < rpc.firstPollTime; j++ {
+		rounds++
 		receipt, err = rpc.GetTxReceipt(txHash, isPrivateTx)
 		if err != nil {
 			if err.Code() == BalanceInsufficientCode {
-				return nil, err, true
+				return nil, err, true, rounds
 			} else if err.Code() != DataNotExistCode && err.Code() != SystemBusyCode {
-				return nil, err, true
+				return nil, err, true, rounds
+			}
+			if waitErr := rpc.wait(rpc.firstPollInterval); waitErr != nil {
+				return nil, waitErr, true, rounds
 			}
-			time.Sleep(time.Millisecond * time.Duration(rpc.firstPollInterval))
 		} else {
-			return receipt, nil, true
+			return receipt, nil, true, rounds
 		}
 	}
 	for j := int64(0); j < rpc.secondPollTime; j++ {
+		rounds++
 		receipt, err = rpc.GetTxReceipt(txHash, isPrivateTx)
 		if err != nil {
 			if err.Code() == BalanceInsufficientCode {
-				return nil, err, true
+				return nil, err, true, rounds
 			} else if err.Code() != DataNotExistCode && err.Code() != SystemBusyCode {
-				return nil, err, true
+				return nil, err, true, rounds
+			}
+			if waitErr := rpc.wait(rpc.secondPollInterval); waitErr != nil {
+				return nil, waitErr, true, rounds
 			}
-			time.Sleep(time.Millisecond * time.Duration(rpc.secondPollInterval))
 		} else {
-			return receipt, nil, true
+			return receipt, nil, true, rounds
 		}
 	}
-	return nil, NewGetResponseError(errors.New("polling failure")), false
+	return nil, NewGetResponseError(errors.New("polling failure")), false, rounds
 }
 
 /*---------------------------------- node ----------------------------------*/
@@ -1887,10 +2030,18 @@ func (rpc *RPC) GetTxConfirmedReceipt(txHash string) (*TxReceipt, StdError) {
 	return &txr, nil
 }
 
+// stampTxVersion set the tx version of the node to the transaction,
+// the version of a transaction signed offline is part of the signature so it's kept
+func (rpc *RPC) stampTxVersion(transaction *Transaction) {
+	if !transaction.offline {
+		transaction.txVersion = rpc.txVersion
+	}
+}
+
 // SendTx 同步发送交易
 // Deprecated: use SignAndSendTx instead
 func (rpc *RPC) SendTx(transaction *Transaction) (*TxReceipt, StdError) {
-	transaction.txVersion = rpc.txVersion
+	rpc.stampTxVersion(transaction)
 	method := TRANSACTION + "sendTransaction"
 	param := transaction.Serialize()
 	if transaction.simulate {
@@ -1899,7 +2050,7 @@ func (rpc *RPC) SendTx(transaction *Transaction) (*TxReceipt, StdError) {
 	return rpc.callTransactionByPolling(method, transaction, param)
 }
 
-// SignAndSendTx 同步发送交易并签名
+// SignAndSendTx 同步发送交易并签名, key is an account.Key or an account.Signer
 func (rpc *RPC) SignAndSendTx(transaction *Transaction, key interface{}) (*TxReceipt, StdError) {
 	transaction.txVersion = rpc.txVersion
 	transaction.Sign(key)
@@ -2432,6 +2583,22 @@ func (rpc *RPC) GetWebSocketClient() *WebSocketClient {
 	return globalWebSocketClient
 }
 
+// GetLogs returns the logs in the block range of filter which match its addresses and topics
+func (rpc *RPC) GetLogs(filter *LogsFilter) ([]TxLog, StdError) {
+	method := SUB + "getLogs"
+
+	data, stdErr := rpc.call(method, filter.Serialize())
+	if stdErr != nil {
+		return nil, stdErr
+	}
+
+	var logs []TxLog
+	if sysErr := json.Unmarshal(data, &logs); sysErr != nil {
+		return nil, NewSystemError(sysErr)
+	}
+	return logs, nil
+}
+
 /*---------------------------------- mq ----------------------------------*/
 
 // GetMqClient 获取mq客户端
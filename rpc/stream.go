@@ -0,0 +1,543 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// streamRetryInterval is the interval to query again after backfilling failed
+const streamRetryInterval = time.Second
+
+var errStreamClosed = errors.New("stream closed")
+
+// CheckpointStore persists the last block number delivered by a stream, so that
+// the stream resumes from the next block after restart
+type CheckpointStore interface {
+	// Load return the checkpoint of the named stream, ok is false if it's never saved
+	Load(name string) (height uint64, ok bool, err error)
+	// Save is called once all the events of the block are delivered
+	Save(name string, height uint64) error
+}
+
+// MemoryCheckpointStore is a CheckpointStore in memory
+type MemoryCheckpointStore struct {
+	mutex   sync.Mutex
+	heights map[string]uint64
+}
+
+// NewMemoryCheckpointStore return an empty MemoryCheckpointStore
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{heights: make(map[string]uint64)}
+}
+
+// Load implements CheckpointStore
+func (ms *MemoryCheckpointStore) Load(name string) (uint64, bool, error) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	height, ok := ms.heights[name]
+	return height, ok, nil
+}
+
+// Save implements CheckpointStore
+func (ms *MemoryCheckpointStore) Save(name string, height uint64) error {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	ms.heights[name] = height
+	return nil
+}
+
+// streamItem is the events of a block
+type streamItem struct {
+	height uint64
+	block  *Block
+	logs   []TxLog
+}
+
+// streamEvent is sent by the web socket handler to the delivering goroutine
+type streamEvent struct {
+	items       []streamItem
+	reconnected bool
+}
+
+// stream delivers the events of each block in order from a block number. It backfills the missed
+// blocks by http queries, switches to the live events of web socket once caught up, and backfills
+// again if the live events skip some blocks or the connection is recovered.
+type stream struct {
+	rpc       *RPC
+	wsCli     *WebSocketClient
+	nodeIndex int
+	filter    EventFilter
+	name      string
+	store     CheckpointStore
+	batch     uint64
+	// next is the block number to deliver, it's only accessed by the delivering goroutine once started
+	next uint64
+	// height is the last block number delivered, accessed atomically
+	height uint64
+
+	fetch   func(from, to uint64) ([]streamItem, StdError)
+	decode  func(data []byte) ([]streamItem, error)
+	deliver func(item streamItem) error
+	// undelivered return the part of a live item of the last delivered block which is not delivered yet,
+	// it's nil if a block is delivered by one item
+	undelivered func(item streamItem) (streamItem, bool)
+
+	events    chan streamEvent
+	closeCh   chan struct{}
+	closeOnce sync.Once
+	done      chan struct{}
+	err       error
+}
+
+func newStream(rpc *RPC, nodeIndex int, from uint64, filter EventFilter) *stream {
+	if from == 0 {
+		from = 1
+	}
+	wsCli := &WebSocketClient{
+		conns: make(map[int]*connectionWrapper, len(rpc.hrm.nodes)),
+		hrm:   &rpc.hrm,
+	}
+	return &stream{
+		rpc:       rpc,
+		wsCli:     wsCli.EnableReconnect(DefaultReconnectPolicy()),
+		nodeIndex: nodeIndex,
+		filter:    filter,
+		batch:     DefaultStreamBatchSize,
+		next:      from,
+		events:    make(chan streamEvent, 64),
+		closeCh:   make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// start load the checkpoint, subscribe the live events and start delivering
+func (s *stream) start() StdError {
+	if s.store != nil {
+		height, ok, err := s.store.Load(s.name)
+		if err != nil {
+			return NewSystemError(err)
+		}
+		if ok {
+			s.next = height + 1
+		}
+	}
+	atomic.StoreUint64(&s.height, s.next-1)
+	// the live events are buffered while backfilling
+	if _, err := s.wsCli.SubscribeOrdered(s.nodeIndex, s.filter, s, DefaultDeliveryOptions()); err != nil {
+		return err
+	}
+	go s.run()
+	return nil
+}
+
+// Close stop delivering and close the web socket connection
+func (s *stream) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closeCh)
+		//nolint
+		s.wsCli.CloseConn(s.nodeIndex)
+	})
+}
+
+// Done is closed once the stream is stopped by Close or the error of handler
+func (s *stream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err return the error of handler which stops the stream
+func (s *stream) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Height return the last block number delivered
+func (s *stream) Height() uint64 {
+	return atomic.LoadUint64(&s.height)
+}
+
+func (s *stream) run() {
+	defer close(s.done)
+	err := s.catchUp()
+	for err == nil {
+		select {
+		case <-s.closeCh:
+			err = errStreamClosed
+		case event := <-s.events:
+			if event.reconnected {
+				err = s.catchUp()
+				continue
+			}
+			for _, item := range event.items {
+				if err = s.handle(item); err != nil {
+					break
+				}
+			}
+		}
+	}
+	if err != errStreamClosed {
+		s.err = err
+		s.Close()
+	}
+}
+
+// handle deliver a live item, the blocks skipped are backfilled first and the delivered ones are dropped
+func (s *stream) handle(item streamItem) error {
+	if item.height < s.next {
+		if s.undelivered == nil || item.height+1 != s.next {
+			return nil
+		}
+		rest, ok := s.undelivered(item)
+		if !ok {
+			return nil
+		}
+		return s.deliverItem(rest)
+	}
+	if item.height > s.next {
+		if err := s.backfill(s.next, item.height-1); err != nil {
+			return err
+		}
+	}
+	return s.deliverItem(item)
+}
+
+// catchUp backfill the blocks up to the chain height
+func (s *stream) catchUp() error {
+	for {
+		height, err := s.rpc.GetChainHeight()
+		if err == nil {
+			latest, sysErr := strconv.ParseUint(height, 0, 64)
+			if sysErr == nil {
+				return s.backfill(s.next, latest)
+			}
+			err = NewSystemError(sysErr)
+		}
+		logger.Warningf("stream get chain height failed: %v", err)
+		if !s.sleep() {
+			return errStreamClosed
+		}
+	}
+}
+
+// backfill deliver the blocks in [from, to] by batches
+func (s *stream) backfill(from, to uint64) error {
+	for from <= to {
+		end := from + s.batch - 1
+		if end > to {
+			end = to
+		}
+		items, err := s.fetch(from, end)
+		if err != nil {
+			logger.Warningf("stream backfill blocks [%d, %d] failed: %v", from, end, err)
+			if !s.sleep() {
+				return errStreamClosed
+			}
+			continue
+		}
+		for _, item := range items {
+			if item.height < s.next {
+				continue
+			}
+			if err := s.deliverItem(item); err != nil {
+				return err
+			}
+		}
+		// the blocks without events are done as well
+		if err := s.advance(end); err != nil {
+			return err
+		}
+		from = end + 1
+	}
+	return nil
+}
+
+func (s *stream) deliverItem(item streamItem) error {
+	select {
+	case <-s.closeCh:
+		return errStreamClosed
+	default:
+	}
+	if err := s.deliver(item); err != nil {
+		return err
+	}
+	return s.advance(item.height)
+}
+
+// advance save the checkpoint once all events of the block are delivered
+func (s *stream) advance(height uint64) error {
+	if height < s.next {
+		return nil
+	}
+	s.next = height + 1
+	atomic.StoreUint64(&s.height, height)
+	if s.store != nil {
+		return s.store.Save(s.name, height)
+	}
+	return nil
+}
+
+// sleep wait for retrying, it returns false if the stream is closed meanwhile
+func (s *stream) sleep() bool {
+	timer := time.NewTimer(streamRetryInterval)
+	defer timer.Stop()
+	select {
+	case <-s.closeCh:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (s *stream) send(event streamEvent) {
+	select {
+	case s.events <- event:
+	case <-s.closeCh:
+	}
+}
+
+// OnSubscribe implements WsEventHandler
+func (s *stream) OnSubscribe() {}
+
+// OnUnSubscribe implements WsEventHandler
+func (s *stream) OnUnSubscribe() {}
+
+// OnClose implements WsEventHandler, it's called once reconnecting gives up
+func (s *stream) OnClose() {
+	select {
+	case <-s.closeCh:
+		return
+	default:
+	}
+	logger.Errorf("web socket of stream closed, the following blocks are not delivered until it's restarted")
+}
+
+// OnReconnect implements WsReconnectHandler, the blocks during the reconnection are backfilled
+func (s *stream) OnReconnect(nodeIndex int) {
+	s.send(streamEvent{reconnected: true})
+}
+
+// OnMessage implements WsEventHandler
+func (s *stream) OnMessage(data []byte) {
+	items, err := s.decode(data)
+	if err != nil {
+		logger.Errorf("stream decode event error: %v", err)
+		return
+	}
+	s.send(streamEvent{items: items})
+}
+
+// BlockStream delivers the blocks from a block number in order without gaps, the blocks are
+// queried by GetBlocksWithLimit before switching to the block events of web socket
+type BlockStream struct {
+	*stream
+}
+
+// NewBlockStream return a BlockStream of the node which delivers the blocks from the block number to
+// handler, the stream stops once handler returns an error, and the block is delivered again after restart.
+// note: nodeIndex start from 1
+func (rpc *RPC) NewBlockStream(nodeIndex int, from uint64, handler func(block *Block) error) *BlockStream {
+	filter := NewBlockEventFilter()
+	filter.SetBlockInfo(true)
+	bs := &BlockStream{stream: newStream(rpc, nodeIndex, from, filter)}
+	bs.fetch = func(from, to uint64) ([]streamItem, StdError) {
+		blocks, err := rpc.getBlockRange(from, to, bs.batch)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]streamItem, 0, len(blocks))
+		for _, block := range blocks {
+			items = append(items, streamItem{height: block.Number, block: block})
+		}
+		return items, nil
+	}
+	bs.decode = func(data []byte) ([]streamItem, error) {
+		var blockRaw BlockRaw
+		if err := json.Unmarshal(data, &blockRaw); err != nil {
+			return nil, err
+		}
+		block, err := blockRaw.ToBlock()
+		if err != nil {
+			return nil, err
+		}
+		return []streamItem{{height: block.Number, block: block}}, nil
+	}
+	bs.deliver = func(item streamItem) error {
+		return handler(item.block)
+	}
+	return bs
+}
+
+// Checkpoint resume the stream from the block after the one saved in store by name,
+// the block number of NewBlockStream is used if nothing is saved
+func (bs *BlockStream) Checkpoint(name string, store CheckpointStore) *BlockStream {
+	bs.name = name
+	bs.store = store
+	return bs
+}
+
+// BatchSize set the number of blocks queried at a time while backfilling
+func (bs *BlockStream) BatchSize(size uint64) *BlockStream {
+	if size > 0 {
+		bs.batch = size
+	}
+	return bs
+}
+
+// Start subscribe the block events and start delivering
+func (bs *BlockStream) Start() StdError {
+	return bs.start()
+}
+
+// LogStream delivers the logs which match the filter block by block from a block number in order without
+// gaps, the logs are queried by GetLogs before switching to the log events of web socket. If the logs of a
+// block are pushed in several events by node, the ones not delivered yet are delivered again with the block number.
+type LogStream struct {
+	*stream
+	// delivered is the logs of the last delivered block, keyed by the tx hash and the log index
+	delivered map[logKey]struct{}
+}
+
+// logKey identify a log in a block
+type logKey struct {
+	txHash string
+	index  uint64
+}
+
+// NewLogStream return a LogStream of the node which delivers the logs from the block number to handler,
+// FromBlock and ToBlock of filter are ignored. The stream stops once handler returns an error, and the
+// logs of the block are delivered again after restart.
+// note: nodeIndex start from 1
+func (rpc *RPC) NewLogStream(nodeIndex int, from uint64, filter *LogsFilter, handler func(blockNumber uint64, logs []TxLog) error) *LogStream {
+	live := *filter
+	live.FromBlock, live.ToBlock = 0, 0
+	ls := &LogStream{stream: newStream(rpc, nodeIndex, from, &live)}
+	ls.fetch = func(from, to uint64) ([]streamItem, StdError) {
+		query := live
+		query.FromBlock, query.ToBlock = from, to
+		logs, err := rpc.GetLogs(&query)
+		if err != nil {
+			return nil, err
+		}
+		return groupLogs(logs), nil
+	}
+	ls.decode = func(data []byte) ([]streamItem, error) {
+		logs, err := decodeLogs(data)
+		if err != nil {
+			return nil, err
+		}
+		return groupLogs(logs), nil
+	}
+	ls.deliver = func(item streamItem) error {
+		if item.height != ls.next-1 {
+			// a new block, it's not advanced yet
+			ls.delivered = make(map[logKey]struct{}, len(item.logs))
+		}
+		if err := handler(item.height, item.logs); err != nil {
+			return err
+		}
+		for _, log := range item.logs {
+			ls.delivered[logKey{txHash: log.TxHash, index: log.Index}] = struct{}{}
+		}
+		return nil
+	}
+	ls.undelivered = func(item streamItem) (streamItem, bool) {
+		if ls.delivered == nil {
+			// the block is delivered before restart
+			return streamItem{}, false
+		}
+		rest := streamItem{height: item.height}
+		for _, log := range item.logs {
+			if _, ok := ls.delivered[logKey{txHash: log.TxHash, index: log.Index}]; !ok {
+				rest.logs = append(rest.logs, log)
+			}
+		}
+		return rest, len(rest.logs) > 0
+	}
+	return ls
+}
+
+// Checkpoint resume the stream from the block after the one saved in store by name,
+// the block number of NewLogStream is used if nothing is saved
+func (ls *LogStream) Checkpoint(name string, store CheckpointStore) *LogStream {
+	ls.name = name
+	ls.store = store
+	return ls
+}
+
+// BatchSize set the number of blocks queried at a time while backfilling
+func (ls *LogStream) BatchSize(size uint64) *LogStream {
+	if size > 0 {
+		ls.batch = size
+	}
+	return ls
+}
+
+// Start subscribe the log events and start delivering
+func (ls *LogStream) Start() StdError {
+	return ls.start()
+}
+
+// groupLogs group the logs by block number in ascending order
+func groupLogs(logs []TxLog) []streamItem {
+	sort.SliceStable(logs, func(i, j int) bool {
+		return logs[i].BlockNumber < logs[j].BlockNumber
+	})
+	var items []streamItem
+	for _, log := range logs {
+		if len(items) == 0 || items[len(items)-1].height != log.BlockNumber {
+			items = append(items, streamItem{height: log.BlockNumber})
+		}
+		items[len(items)-1].logs = append(items[len(items)-1].logs, log)
+	}
+	return items
+}
+
+// getBlockRange query the blocks in [from, to] by pages in ascending order, GetBlocks
+// is used instead if the node does not support GetBlocksWithLimit
+func (rpc *RPC) getBlockRange(from, to uint64, pageSize uint64) ([]*Block, StdError) {
+	var blocks []*Block
+	for from <= to {
+		page, err := rpc.GetBlocksWithLimit(from, to, false, &Metadata{PageSize: int32(pageSize)})
+		if err != nil {
+			if err.Code() == MethodNotExistOrInvalidCode {
+				return rpc.GetBlocks(from, to, false)
+			}
+			return nil, err
+		}
+		data, sysErr := json.Marshal(page.Data)
+		if sysErr != nil {
+			return nil, NewSystemError(sysErr)
+		}
+		var blockRaws []BlockRaw
+		if sysErr = json.Unmarshal(data, &blockRaws); sysErr != nil {
+			return nil, NewSystemError(sysErr)
+		}
+		last := from - 1
+		for _, v := range blockRaws {
+			block, stdErr := v.ToBlock()
+			if stdErr != nil {
+				return nil, stdErr
+			}
+			blocks = append(blocks, block)
+			if block.Number > last {
+				last = block.Number
+			}
+		}
+		if !page.HasMore || last < from {
+			break
+		}
+		from = last + 1
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].Number < blocks[j].Number
+	})
+	return blocks, nil
+}
@@ -0,0 +1,194 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// newStreamTestRPC start a node whose chain height is 5, the events sent to the returned channel are pushed
+// to the subscription, the queried block ranges are recorded
+func newStreamTestRPC(t *testing.T) (*RPC, chan<- string, func() []string) {
+	events := make(chan string, 10)
+	var (
+		mutex   sync.Mutex
+		queries []string
+	)
+	upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+	rpc := newTestRPC(t, func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			_, _, _ = conn.ReadMessage()
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","code":0,"message":"SUCCESS","result":"0xsub01"}`))
+			for event := range events {
+				_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","code":0,"result":{"event":"block","subscription":"0xsub01","data":`+event+`}}`))
+			}
+			return
+		}
+		var req JSONRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch req.Method {
+		case BLOCK + "getChainHeight":
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"result":"0x5"}`))
+		case BLOCK + "getBlocksWithLimit":
+			param := req.Params[0].(map[string]interface{})
+			from, to := uint64(param["from"].(float64)), uint64(param["to"].(float64))
+			mutex.Lock()
+			queries = append(queries, fmt.Sprintf("%d-%d", from, to))
+			mutex.Unlock()
+			var blocks []string
+			for i := from; i <= to; i++ {
+				blocks = append(blocks, testBlock(i))
+			}
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"result":{"hasmore":false,"data":[` + strings.Join(blocks, ",") + `]}}`))
+		case SUB + "getLogs":
+			param := req.Params[0].(map[string]interface{})
+			mutex.Lock()
+			queries = append(queries, fmt.Sprintf("%v-%v", param["fromBlock"], param["toBlock"]))
+			mutex.Unlock()
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"code":0,"result":[{"address":"0x01","blockNumber":4,"txHash":"0xa","index":0},{"address":"0x01","blockNumber":4,"txHash":"0xa","index":1}]}`))
+		}
+	})
+	t.Cleanup(func() { close(events) })
+	return rpc, events, func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string(nil), queries...)
+	}
+}
+
+func testBlock(number uint64) string {
+	return fmt.Sprintf(`{"number":"0x%x","avgTime":"0x0","txcounts":"0x0"}`, number)
+}
+
+func TestBlockStream(t *testing.T) {
+	rpc, events, queries := newStreamTestRPC(t)
+	store := NewMemoryCheckpointStore()
+	_ = store.Save("indexer", 2)
+
+	delivered := make(chan uint64, 10)
+	stream := rpc.NewBlockStream(1, 1, func(block *Block) error {
+		delivered <- block.Number
+		return nil
+	}).Checkpoint("indexer", store).BatchSize(2)
+	assert.Nil(t, stream.Start())
+	defer stream.Close()
+
+	// block 5 is delivered by backfilling already, block 6 is skipped by the live events
+	events <- testBlock(5)
+	events <- testBlock(7)
+	events <- testBlock(8)
+
+	var numbers []uint64
+	for len(numbers) < 6 {
+		select {
+		case number := <-delivered:
+			numbers = append(numbers, number)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+	}
+	assert.Equal(t, []uint64{3, 4, 5, 6, 7, 8}, numbers)
+	assert.Equal(t, []string{"3-4", "5-5", "6-6"}, queries())
+	assert.Equal(t, uint64(8), stream.Height())
+	height, ok, _ := store.Load("indexer")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(8), height)
+}
+
+func TestBlockStream_HandlerError(t *testing.T) {
+	rpc, _, _ := newStreamTestRPC(t)
+	store := NewMemoryCheckpointStore()
+	stream := rpc.NewBlockStream(1, 3, func(block *Block) error {
+		if block.Number == 4 {
+			return errors.New("index failed")
+		}
+		return nil
+	}).Checkpoint("indexer", store)
+	assert.Nil(t, stream.Start())
+
+	select {
+	case <-stream.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+	assert.EqualError(t, stream.Err(), "index failed")
+	// block 4 is delivered again after restart
+	height, _, _ := store.Load("indexer")
+	assert.Equal(t, uint64(3), height)
+}
+
+func TestLogStream(t *testing.T) {
+	rpc, events, queries := newStreamTestRPC(t)
+	type delivery struct {
+		number uint64
+		count  int
+	}
+	delivered := make(chan delivery, 10)
+	stream := rpc.NewLogStream(1, 3, NewLogsFilter().AddAddress("0x01"), func(blockNumber uint64, logs []TxLog) error {
+		delivered <- delivery{number: blockNumber, count: len(logs)}
+		return nil
+	})
+	assert.Nil(t, stream.Start())
+	defer stream.Close()
+
+	events <- `[{"address":"0x01","blockNumber":4,"txHash":"0xa","index":0}]`
+	events <- `[{"address":"0x01","blockNumber":6,"txHash":"0xb","index":0}]`
+
+	var deliveries []delivery
+	for len(deliveries) < 2 {
+		select {
+		case d := <-delivered:
+			deliveries = append(deliveries, d)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+	}
+	// the logs of block 4 are delivered once by backfilling
+	assert.Equal(t, []delivery{{number: 4, count: 2}, {number: 6, count: 1}}, deliveries)
+	assert.Equal(t, []string{"3-5"}, queries())
+	assert.Eventually(t, func() bool { return stream.Height() == 6 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestLogStream_SameHeight(t *testing.T) {
+	rpc, events, _ := newStreamTestRPC(t)
+	delivered := make(chan []string, 10)
+	stream := rpc.NewLogStream(1, 3, NewLogsFilter().AddAddress("0x01"), func(blockNumber uint64, logs []TxLog) error {
+		var keys []string
+		for _, log := range logs {
+			keys = append(keys, fmt.Sprintf("%d-%s-%d", blockNumber, log.TxHash, log.Index))
+		}
+		delivered <- keys
+		return nil
+	})
+	assert.Nil(t, stream.Start())
+	defer stream.Close()
+
+	// the logs of block 6 are pushed by two events, the repeated one is dropped
+	events <- `[{"address":"0x01","blockNumber":6,"txHash":"0xb","index":0}]`
+	events <- `[{"address":"0x01","blockNumber":6,"txHash":"0xb","index":0},{"address":"0x01","blockNumber":6,"txHash":"0xc","index":0}]`
+
+	var deliveries [][]string
+	for len(deliveries) < 3 {
+		select {
+		case keys := <-delivered:
+			deliveries = append(deliveries, keys)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+	}
+	assert.Equal(t, [][]string{{"4-0xa-0", "4-0xa-1"}, {"6-0xb-0"}, {"6-0xc-0"}}, deliveries)
+	assert.Eventually(t, func() bool { return stream.Height() == 6 }, 5*time.Second, 10*time.Millisecond)
+}
@@ -103,6 +103,8 @@ type Transaction struct {
 	optionExtra  string
 	participant  *Participant
 	account      interface{}
+	// offline is true if the transaction is rebuilt from a SignedTransaction
+	offline bool
 }
 
 func (t *Transaction) GetFrom() string {
@@ -347,7 +349,7 @@ func (t *Transaction) DeployStringArgs(abiString string, args ...interface{}) *T
 
 // Invoke add transaction isInvoke
 func (t *Transaction) Invoke(to string, payload []byte) *Transaction {
-	if string(payload[0:8]) == "fefffbce" {
+	if len(payload) >= 8 && string(payload[0:8]) == "fefffbce" {
 		t.payload = chPrefix("fefffbce" + common.Bytes2Hex(payload[8:]))
 	} else {
 		t.payload = chPrefix(common.Bytes2Hex(payload))
@@ -363,7 +365,7 @@ func (t *Transaction) InvokeSql(to string, payload []byte) *Transaction {
 
 // Invoke add transaction isInvoke
 func (t *Transaction) InvokeByName(name string, payload []byte) *Transaction {
-	if string(payload[0:8]) == "fefffbce" {
+	if len(payload) >= 8 && string(payload[0:8]) == "fefffbce" {
 		t.payload = chPrefix("fefffbce" + common.Bytes2Hex(payload[8:]))
 	} else {
 		t.payload = chPrefix(common.Bytes2Hex(payload))
@@ -768,7 +770,7 @@ func writeBaseFiled(buffer *strings.Builder, t *Transaction, payload string, has
 	buffer.WriteString(t.vmType)
 }
 
-// Sign support ecdsa\SM2\Ed25519 signature
+// Sign support ecdsa\SM2\Ed25519 signature, key is an account.Key or an account.Signer which keeps the private key out of process
 func (t *Transaction) Sign(key interface{}) {
 	t.sign(key, false)
 }
@@ -783,12 +785,13 @@ func (t *Transaction) SignWithBatchFlag(key interface{}) {
 func (t *Transaction) sign(key interface{}, batch bool) {
 	t.account = key
 	if t.isPrivateTx {
-		K, ok := key.(account.Key)
-		if !ok {
+		_, isKey := key.(account.Key)
+		_, isSigner := key.(account.Signer)
+		if !isKey && !isSigner {
 			logger.Error("invalid key type")
 			return
 		}
-		t.PreSign(K)
+		t.preSign(key)
 	}
 	_, isPKIAccount := key.(*account.PKIKey)
 	if isPKIAccount {
@@ -927,6 +930,18 @@ func (t *Transaction) SetSignature(signature string) {
 	t.signature = signature
 }
 
+// GetNeedHashString return the string the signers sign, the parties of a threshold account
+// sign it with account.ThresholdShare.PartialSign
+func (t *Transaction) GetNeedHashString() string {
+	return needHashString(t)
+}
+
+// SetThresholdSignature set the ed25519 signature combined by account.CombineThresholdSignatures
+// in the format Sign produces, groupPub is the public key of the threshold account
+func (t *Transaction) SetThresholdSignature(groupPub, sig []byte) {
+	t.signature = common.ToHex(joinSignature(account.AlgoED25519, groupPub, sig, false, false))
+}
+
 func (t *Transaction) SetOpcode(opcode int64) {
 	t.opcode = opcode
 }
@@ -2,7 +2,9 @@ package rpc
 
 import (
 	"github.com/magiconair/properties/assert"
+	gm "github.com/meshplus/crypto-gm"
 	"github.com/meshplus/gosdk/account"
+	"github.com/meshplus/gosdk/common"
 	"github.com/meshplus/gosdk/common/hexutil"
 	"github.com/meshplus/gosdk/kvsql"
 	"testing"
@@ -130,3 +132,76 @@ func TestAddKVSQLType(t *testing.T) {
 	rs := kvsql.DecodeRecordSet(b)
 	t.Log(rs)
 }
+
+func TestTransaction_SignWithSigner(t *testing.T) {
+	js, err := account.NewAccountED25519("")
+	assert.Equal(t, err, nil)
+	key, err := account.GenKeyFromAccountJson(js, "")
+	assert.Equal(t, err, nil)
+	signer, err := account.NewLocalSigner(key.(*account.ED25519Key))
+	assert.Equal(t, err, nil)
+
+	// ed25519 is deterministic, the signer gives the same signature as the key
+	tx := NewTransaction(key.(*account.ED25519Key).GetAddress().Hex()).Transfer("0x0000000000000000000000000000000000000001", int64(1))
+	tx.Sign(key)
+	byKey := tx.GetSignature()
+	tx.SetSignature("")
+	tx.Sign(signer)
+	assert.Equal(t, tx.GetSignature(), byKey)
+
+	cred := NewDIDCredential("type", "issuer", "holder", "", 1, 2)
+	assert.Equal(t, cred.Sign(signer), nil)
+	assert.Equal(t, cred.SignType, ALGOTYPE_ED)
+}
+
+func TestTransaction_SignWithSM2Signer(t *testing.T) {
+	js, _ := account.NewAccountSm2("")
+	key, _ := account.GenKeyFromAccountJson(js, "")
+	sm2Key := key.(*account.SM2Key)
+	signer, err := account.NewLocalSigner(sm2Key)
+	assert.Equal(t, err, nil)
+
+	tx := NewTransaction(sm2Key.GetAddress().Hex()).Transfer("0x0000000000000000000000000000000000000001", int64(1))
+	tx.Sign(signer)
+	sig := common.FromHex(tx.GetSignature())
+	pub, _ := sm2Key.PublicBytes()
+	assert.Equal(t, sig[0], byte(0x01))
+	assert.Equal(t, sig[1:1+len(pub)], pub)
+	valid, _ := sm2Key.PublicKey.Verify(nil, sig[1+len(pub):], gm.HashBeforeSM2(&sm2Key.PublicKey, []byte(needHashString(tx))))
+	assert.Equal(t, valid, true)
+}
+
+func TestTransaction_SetThresholdSignature(t *testing.T) {
+	shares, err := account.NewThresholdED25519(2, 3)
+	assert.Equal(t, err, nil)
+	tx := NewTransaction(shares[0].GetAddress().Hex()).Transfer("0x0000000000000000000000000000000000000001", int64(1))
+
+	// the parties sign the need hash string of the transaction
+	msg := []byte(tx.GetNeedHashString())
+	n1, c1, _ := shares[0].Commit()
+	n3, c3, _ := shares[2].Commit()
+	commitments := []*account.ThresholdCommitment{c1, c3}
+	p1, err := shares[0].PartialSign(n1, msg, commitments)
+	assert.Equal(t, err, nil)
+	p3, err := shares[2].PartialSign(n3, msg, commitments)
+	assert.Equal(t, err, nil)
+	sig, err := account.CombineThresholdSignatures(shares[0].PublicKey, msg, commitments, []*account.PartialSignature{p1, p3})
+	assert.Equal(t, err, nil)
+	tx.SetThresholdSignature(shares[0].PublicKey, sig)
+	combined := tx.GetSignature()
+
+	// the in process signer gives the same format
+	signer, _ := account.NewThresholdSigner(shares[1], shares[2])
+	tx.Sign(signer)
+	assert.Equal(t, len(tx.GetSignature()), len(combined))
+	assert.Equal(t, tx.GetSignature()[:2+2+64], combined[:2+2+64])
+	assert.Equal(t, combined[:4], "0x02")
+}
+
+func TestTransaction_InvokeShortPayload(t *testing.T) {
+	// the selector of a method without arguments
+	tx := NewTransaction("0x0000000000000000").Invoke("0x0000000000000001", []byte{0x6d, 0x4c, 0xe6, 0x3c})
+	assert.Equal(t, tx.GetPayload(), "0x6d4ce63c")
+	tx = NewTransaction("0x0000000000000000").InvokeByName("counter", []byte{0x6d, 0x4c, 0xe6, 0x3c})
+	assert.Equal(t, tx.GetPayload(), "0x6d4ce63c")
+}
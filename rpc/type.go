@@ -1,6 +1,9 @@
 package rpc
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/hashicorp/go-uuid"
 	"github.com/meshplus/gosdk/account"
@@ -13,11 +16,12 @@ import (
 
 // reponse codes
 const (
-	SystemErrorCode         = -9996
-	AsnycRequestErrorCode   = -9997
-	RequestTimeoutErrorCode = -9998
-	GetResponseErrorCode    = -9999
-	SuccessCode             = 0
+	RequestCanceledErrorCode = -9995
+	SystemErrorCode          = -9996
+	AsnycRequestErrorCode    = -9997
+	RequestTimeoutErrorCode  = -9998
+	GetResponseErrorCode     = -9999
+	SuccessCode              = 0
 	//InvalidJSONCode             = -32700
 	//InvalidRequestCode          = -32600
 	MethodNotExistOrInvalidCode = -32601
@@ -637,10 +641,17 @@ type StdError interface {
 	Code() int
 }
 
-// RetError is packaged ret code and message
+// RetError is packaged ret code and message, the cause and the request
+// which produces the error are kept as well
 type RetError struct {
 	code    int
 	message string
+
+	cause      error
+	httpStatus int
+	nodeURL    string
+	method     string
+	data       json.RawMessage
 }
 
 func (re *RetError) String() string {
@@ -672,6 +683,7 @@ func NewSystemError(e error) StdError {
 	return &RetError{
 		code:    SystemErrorCode,
 		message: e.Error(),
+		cause:   e,
 	}
 }
 
@@ -683,6 +695,7 @@ func NewRequestTimeoutError(e error) StdError {
 	return &RetError{
 		code:    RequestTimeoutErrorCode,
 		message: e.Error(),
+		cause:   e,
 	}
 }
 
@@ -694,14 +707,32 @@ func NewGetResponseError(e error) StdError {
 	return &RetError{
 		code:    GetResponseErrorCode,
 		message: e.Error(),
+		cause:   e,
+	}
+}
+
+// newContextError is used to construct StdError when the context of a request is done,
+// a deadline is reported as request timeout and any other reason as request canceled
+func newContextError(e error) StdError {
+	if e == nil {
+		return nil
+	}
+	if errors.Is(e, context.DeadlineExceeded) {
+		return NewRequestTimeoutError(e)
+	}
+	return &RetError{
+		code:    RequestCanceledErrorCode,
+		message: e.Error(),
+		cause:   e,
 	}
 }
 
 // NewHttpResponseError is used to construct StdError by HTTP error
 func NewHttpResponseError(code int, msg string) StdError {
 	return &RetError{
-		code:    code,
-		message: msg,
+		code:       code,
+		message:    msg,
+		httpStatus: code,
 	}
 }
 
@@ -803,19 +834,31 @@ func (credential *DIDCredential) needHashString() string {
 	return res
 }
 
+// Sign sign the credential with an account.Key, an *account.DIDKey or an account.Signer
 func (credential *DIDCredential) Sign(key interface{}) error {
 	hashStr := credential.needHashString()
 	_, isDIDAccount := key.(*account.DIDKey)
 	if isDIDAccount {
 		key = key.(*account.DIDKey).GetNormalKey()
 	}
-	switch key.(type) {
-	case account.SM2Key:
+	switch k := key.(type) {
+	case account.SM2Key, *account.SM2Key:
 		credential.SignType = ALGOTYPE_SM2
-	case account.ECDSAKey:
+	case account.ECDSAKey, *account.ECDSAKey:
 		credential.SignType = ALGOTYPE_EC
-	case account.ED25519Key:
+	case account.ED25519Key, *account.ED25519Key:
 		credential.SignType = ALGOTYPE_ED
+	case account.Signer:
+		switch k.Algorithm() {
+		case account.AlgoSM2:
+			credential.SignType = ALGOTYPE_SM2
+		case account.AlgoECDSA, account.AlgoECDSAR1:
+			credential.SignType = ALGOTYPE_EC
+		case account.AlgoED25519:
+			credential.SignType = ALGOTYPE_ED
+		default:
+			return fmt.Errorf("can't use other key type")
+		}
 	default:
 		return fmt.Errorf("can't use other key type")
 	}
@@ -8,7 +8,6 @@ import (
 	"errors"
 	"fmt"
 	gm "github.com/meshplus/crypto-gm"
-	"github.com/meshplus/crypto-standard/asym"
 	"github.com/meshplus/crypto-standard/hash"
 	"github.com/meshplus/gosdk/account"
 	"github.com/meshplus/gosdk/common"
@@ -40,7 +39,11 @@ func sign(key interface{}, needHash string, batch bool, isKPIAccount bool) (stri
 	//return common.ToHex(sig), nil
 }
 
+// SignWithDID use key to sign need hash string, the key is an account.Key or an account.Signer
 func SignWithDID(key interface{}, needHash string, batch, isKPIAccount, isDID bool) (string, error) {
+	if signer, ok := key.(account.Signer); ok {
+		return signWithSigner(signer, needHash, isDID)
+	}
 	h, err := getHash(key, needHash)
 	if err != nil {
 		return "", err
@@ -101,6 +104,13 @@ func getHash(key interface{}, needHash string) ([]byte, error) {
 
 // genSignature get a signature of gm or ecdsa
 func genSignature(key account.Key, hash []byte, batch, isPKIAccount, isDID bool) ([]byte, StdError) {
+	algo, err := account.AlgorithmOf(key)
+	if err != nil {
+		return nil, NewSystemError(errors.New("signature type error"))
+	}
+	if algo == account.AlgoED25519 && isPKIAccount {
+		return nil, NewSystemError(errors.New("it doesn't support ed25519 cert"))
+	}
 	var r []byte
 	var e error
 	if sm2key, ok := key.(*account.SM2Key); batch && ok {
@@ -112,40 +122,64 @@ func genSignature(key account.Key, hash []byte, batch, isPKIAccount, isDID bool)
 		return nil, NewSystemError(errors.New("signature error:" + e.Error()))
 	}
 	pub, _ := key.PublicBytes()
-	switch key.(type) {
-	case *account.ECDSAKey:
-		logger.Debug("sign type : ecdsa")
-		if isPKIAccount {
-			return bytes.Join([][]byte{{0x04}, r}, nil), nil
-		}
-		if key.(*account.ECDSAKey).AlgorithmType() == asym.AlgoP256R1 {
-			return bytes.Join([][]byte{{0x05}, pub, r}, nil), nil
-		}
+	return joinSignature(algo, pub, r, isPKIAccount, isDID), nil
+}
+
+// joinSignature prefix the raw signature with the algorithm flag and the public key
+func joinSignature(algo account.SignAlgo, pub, r []byte, isPKIAccount, isDID bool) []byte {
+	logger.Debug("sign type : " + string(algo))
+	if isPKIAccount {
+		return bytes.Join([][]byte{{0x04}, r}, nil)
+	}
+	switch algo {
+	case account.AlgoECDSAR1:
+		return bytes.Join([][]byte{{0x05}, pub, r}, nil)
+	case account.AlgoSM2:
 		if isDID {
-			return bytes.Join([][]byte{{0x86}, pub, r}, nil), nil
-		}
-		return append([]byte{0x00}, r...), nil
-	case *account.SM2Key:
-		logger.Debug("sign type : sm2")
-		if isPKIAccount {
-			return bytes.Join([][]byte{{0x04}, r}, nil), nil
+			return bytes.Join([][]byte{{0x81}, pub, r}, nil)
 		}
+		return bytes.Join([][]byte{{0x01}, pub, r}, nil)
+	case account.AlgoED25519:
 		if isDID {
-			return bytes.Join([][]byte{{0x81}, pub, r}, nil), nil
-		}
-		return bytes.Join([][]byte{{0x01}, pub, r}, nil), nil
-	case *account.ED25519Key:
-		logger.Debug("sign type : ed25519")
-		if isPKIAccount {
-			return nil, NewSystemError(errors.New("it doesn't support ed25519 cert"))
+			return bytes.Join([][]byte{{0x82}, pub, r}, nil)
 		}
+		return bytes.Join([][]byte{{0x02}, pub, r}, nil)
+	default:
 		if isDID {
-			return bytes.Join([][]byte{{0x82}, pub, r}, nil), nil
+			return bytes.Join([][]byte{{0x86}, pub, r}, nil)
 		}
-		return bytes.Join([][]byte{{0x02}, pub, r}, nil), nil
+		return append([]byte{0x00}, r...)
+	}
+}
+
+// signWithSigner hash the need hash string by the algorithm of the signer and let it sign
+func signWithSigner(signer account.Signer, needHash string, isDID bool) (string, error) {
+	pub, err := signer.PublicBytes()
+	if err != nil {
+		return "", err
+	}
+	var h []byte
+	switch signer.Algorithm() {
+	case account.AlgoECDSA, account.AlgoECDSAR1:
+		h, _ = hash.NewHasher(hash.KECCAK_256).Hash([]byte(needHash))
+	case account.AlgoSM2:
+		pubKey := new(gm.SM2PublicKey)
+		if err = pubKey.FromBytes(pub, 0); err != nil {
+			return "", err
+		}
+		h = gm.HashBeforeSM2(pubKey, []byte(needHash))
+	case account.AlgoED25519:
+		h = []byte(needHash)
 	default:
-		return nil, NewSystemError(errors.New("signature type error"))
+		logger.Error("unsupported sign type")
+		return "", errors.New("unsupported sign type")
+	}
+	r, err := signer.Sign(h)
+	if err != nil {
+		logger.Error("signer signature error")
+		return "", errors.New("gen signature error: " + err.Error())
 	}
+	return common.ToHex(joinSignature(signer.Algorithm(), pub, r, false, isDID)), nil
 }
 
 // DecompressFromJar encode jarcode for deploy or upgrade
@@ -1,6 +1,7 @@
 package rpc
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -51,20 +52,57 @@ type WsEventHandler interface {
 }
 
 type connectionWrapper struct {
-	id            int
-	conn          *websocket.Conn
-	mutex         sync.Mutex // use to sync conn
+	// id is the index of node subscribed by user, node is the index of node connected,
+	// they differ once the connection fails over to another node
+	id       int
+	node     int
+	conn     *websocket.Conn
+	mutex    sync.Mutex // use to sync conn
+	subMutex sync.Mutex // use to send one subscription request at a time
+	subIDCh  chan SubscriptionID
+	// done is closed once the connection is broken or closed
+	done   chan struct{}
+	broken bool
+
+	hubMutex      sync.RWMutex // use to sync the following fields
 	handler       WsEventHandler
-	subIDCh       chan SubscriptionID
 	eventHub      map[SubscriptionID]WsEventHandler
 	subscriptions []Subscription
+	records       map[SubscriptionID]*subscriptionRecord
+}
+
+// subscriptionRecord is kept to replay the subscription after reconnecting
+type subscriptionRecord struct {
+	// id is returned to user, serverID is the id given by the current connection
+	id      SubscriptionID
+	method  string
+	params  []interface{}
+	event   EventType
+	handler WsEventHandler
+
+	// mutex sync serverID, which is replaced once the subscription is replayed
+	mutex    sync.Mutex
+	serverID SubscriptionID
+}
+
+func (record *subscriptionRecord) getServerID() SubscriptionID {
+	record.mutex.Lock()
+	defer record.mutex.Unlock()
+	return record.serverID
+}
+
+func (record *subscriptionRecord) setServerID(serverID SubscriptionID) {
+	record.mutex.Lock()
+	defer record.mutex.Unlock()
+	record.serverID = serverID
 }
 
 // WebSocketClient control the all APIs web socket related APIs
 type WebSocketClient struct {
-	conns   map[int]*connectionWrapper
-	hrm     *httpRequestManager
-	rwMutex sync.RWMutex // use to sync conns
+	conns     map[int]*connectionWrapper
+	hrm       *httpRequestManager
+	rwMutex   sync.RWMutex // use to sync conns
+	reconnect *ReconnectPolicy
 }
 
 // WebSocketNotification represents the notification data structure
@@ -108,14 +146,78 @@ func (wscli *WebSocketClient) getConn(nodeIndex int) (*websocket.Conn, StdError)
 		}
 	}
 
-	conn, resp, err := websocket.DefaultDialer.Dial(nodeURL, header)
-	if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
-		return nil, NewSystemError(errors.New("get webSocket connection error"))
+	// the handshake passes through the middlewares to carry the same headers as the http requests
+	var conn *websocket.Conn
+	dialer := wscli.hrm.wsDialer()
+	_, stdErr := wscli.hrm.roundTripWith(context.Background(), newCall(nil, nil, nodeURL), RoundTripperFunc(func(ctx context.Context, call *Call) ([]byte, StdError) {
+		for k, v := range call.Header {
+			header.Set(k, v)
+		}
+		c, resp, err := dialer.DialContext(ctx, call.URL, header)
+		if err != nil || resp.StatusCode != http.StatusSwitchingProtocols {
+			if err == nil {
+				_ = c.Close()
+			}
+			logger.Errorf("dial web socket %s error: %v", call.URL, err)
+			return nil, NewSystemError(errors.New("get webSocket connection error"))
+		}
+		conn = c
+		return nil, nil
+	}))
+	if stdErr != nil {
+		return nil, stdErr
+	}
+	if conn == nil {
+		return nil, NewSystemError(errors.New("web socket handshake is intercepted by middleware"))
 	}
 
 	return conn, nil
 }
 
+// wsDialer return the web socket dialer which shares the tls config, proxy and dialer of the http client,
+// so the connection is mutual tls as well once the peer cert is configured
+func (hrm *httpRequestManager) wsDialer() *websocket.Dialer {
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 45 * time.Second,
+	}
+	transport := http.DefaultTransport
+	if hrm.client != nil && hrm.client.Transport != nil {
+		transport = hrm.client.Transport
+	}
+	if tr, ok := transport.(*http.Transport); ok {
+		dialer.Proxy = tr.Proxy
+		dialer.NetDialContext = tr.DialContext
+		dialer.TLSClientConfig = tr.TLSClientConfig
+	}
+	return dialer
+}
+
+// dial connect the node and start listening, id is the index of node subscribed by user
+func (wscli *WebSocketClient) dial(id, node int) (*connectionWrapper, StdError) {
+	conn, stdErr := wscli.getConn(node)
+	if stdErr != nil {
+		return nil, stdErr
+	}
+	wrapper := &connectionWrapper{
+		id:            id,
+		node:          node,
+		conn:          conn,
+		subIDCh:       make(chan SubscriptionID),
+		done:          make(chan struct{}),
+		eventHub:      make(map[SubscriptionID]WsEventHandler),
+		subscriptions: make([]Subscription, 0),
+		records:       make(map[SubscriptionID]*subscriptionRecord),
+	}
+	// init when connect success
+	conn.SetCloseHandler(wscli.getCloseHandler(wrapper))
+	conn.SetPingHandler(wscli.pingHandler)
+	conn.SetPongHandler(wscli.pongHandler)
+
+	go wscli.listen(wrapper)
+	return wrapper, nil
+}
+
 func (wscli *WebSocketClient) listen(wrapper *connectionWrapper) {
 	// get a copy of connection, in case get nil pointer panic
 	conn := wrapper.conn
@@ -123,11 +225,18 @@ func (wscli *WebSocketClient) listen(wrapper *connectionWrapper) {
 	go func(conn *websocket.Conn) {
 		t := time.NewTicker(1 * time.Minute)
 		defer t.Stop()
-		for range t.C {
+		for {
+			select {
+			case <-wrapper.done:
+				return
+			case <-t.C:
+			}
+			wrapper.mutex.Lock()
 			err := conn.WriteControl(websocket.PingMessage, []byte("heart beat"), time.Now().Add(5*time.Second))
+			wrapper.mutex.Unlock()
 			if err != nil {
 				if !websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure) {
-					logger.Errorf("web socket of node%d encountered an error: %v", wrapper.id, err)
+					logger.Errorf("web socket of node%d encountered an error: %v", wrapper.node, err)
 				}
 				if _, ok := err.(*websocket.CloseError); ok {
 					return
@@ -141,19 +250,19 @@ func (wscli *WebSocketClient) listen(wrapper *connectionWrapper) {
 		msgType, data, err := conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure) {
-				logger.Errorf("web socket of node%d encountered an error: %v", wrapper.id, err)
-			}
-			if _, ok := err.(*websocket.CloseError); ok {
-				wscli.clearConn(wrapper.id)
-				return
+				logger.Errorf("web socket of node%d encountered an error: %v", wrapper.node, err)
 			}
+			// the connection is cleared already if it is closed by CloseConn,
+			// otherwise the connection is broken and user should be notified as well
+			wscli.onBroken(wrapper)
+			return
 		}
 
 		jsonResponse := &JSONResponse{}
 
 		err = json.Unmarshal(data, jsonResponse)
 		if err != nil {
-			logger.Errorf("web socket of node%d encountered an error: %v", wrapper.id, err)
+			logger.Errorf("web socket of node%d encountered an error: %v", wrapper.node, err)
 			break
 		}
 
@@ -170,16 +279,25 @@ func (wscli *WebSocketClient) listen(wrapper *connectionWrapper) {
 
 						err := json.Unmarshal(jsonResponse.Result, &subID)
 						if err != nil {
-							logger.Errorf("web socket of node%d encountered an error: %v", wrapper.id, err)
+							logger.Errorf("web socket of node%d encountered an error: %v", wrapper.node, err)
 							break
 						}
 
+						// register the handler in the listening goroutine, so that the event hub
+						// is updated before the following messages are handled
+						wrapper.hubMutex.Lock()
+						handler := wrapper.handler
+						wrapper.eventHub[subID] = handler
+						wrapper.hubMutex.Unlock()
 						go func() {
 							// inform user of the subID
-							wrapper.subIDCh <- SubscriptionID(subID)
-							wrapper.eventHub[SubscriptionID(subID)] = wrapper.handler
+							select {
+							case wrapper.subIDCh <- subID:
+							case <-wrapper.done:
+								return
+							}
 							// notify user
-							wrapper.eventHub[SubscriptionID(subID)].OnSubscribe()
+							handler.OnSubscribe()
 						}()
 					}
 				}
@@ -188,16 +306,22 @@ func (wscli *WebSocketClient) listen(wrapper *connectionWrapper) {
 				var notification WebSocketNotification
 				err := json.Unmarshal(jsonResponse.Result, &notification)
 				if err != nil {
-					logger.Errorf("web socket of node %d encountered an error: %v", wrapper.id, err)
+					logger.Errorf("web socket of node %d encountered an error: %v", wrapper.node, err)
 					break
 				}
+				wrapper.hubMutex.RLock()
+				handler, ok := wrapper.eventHub[notification.Subscription]
+				wrapper.hubMutex.RUnlock()
 				// if the callback has been removed, try to unsubscribe again
-				if _, ok := wrapper.eventHub[notification.Subscription]; !ok {
+				if !ok {
 					//nolint
-					go wscli.UnSubscribe(notification.Subscription)
+					go wscli.unsubscribe(wrapper, notification.Subscription)
+				} else if d, ok := handler.(*dispatcher); ok {
+					// ordered subscription, it may block reading until the queue has room
+					d.push(notification.Data)
 				} else {
 					// notify user
-					go wrapper.eventHub[notification.Subscription].OnMessage(notification.Data)
+					go handler.OnMessage(notification.Data)
 				}
 			}
 		default:
@@ -211,27 +335,74 @@ func (wscli *WebSocketClient) checkIndex(index int) bool {
 	return 0 <= index && index <= len(wscli.hrm.nodes)
 }
 
+// clearConn close the connection and drop the subscriptions, the caller should hold rwMutex
 func (wscli *WebSocketClient) clearConn(nodeIndex int) {
-	if wscli.conns[nodeIndex] != nil {
+	if wrapper := wscli.conns[nodeIndex]; wrapper != nil {
+		wrapper.hubMutex.Lock()
 		// clear subIDs
-		wscli.conns[nodeIndex].subscriptions = make([]Subscription, 0)
-		// close channel
-		close(wscli.conns[nodeIndex].subIDCh)
+		wrapper.subscriptions = make([]Subscription, 0)
+		wrapper.records = make(map[SubscriptionID]*subscriptionRecord)
+		wrapper.hubMutex.Unlock()
+		wrapper.close()
 		// clear the connection
 		wscli.conns[nodeIndex] = nil
 	}
 }
 
-func (wscli *WebSocketClient) getCloseHandler(wrapper *connectionWrapper) func(code int, text string) error {
-	return func(code int, text string) error {
-		// notify user
-		for _, h := range wrapper.eventHub {
-			h.OnClose()
-		}
+// close mark the connection as broken and wake up the waiting subscription requests
+func (wrapper *connectionWrapper) close() {
+	if !wrapper.broken {
+		wrapper.broken = true
+		close(wrapper.done)
+		_ = wrapper.conn.Close()
+	}
+}
+
+// onBroken is called once the connection is closed by node or broken, the connection is redialed
+// if reconnect is enabled, otherwise the handlers are notified and the connection is cleared
+func (wscli *WebSocketClient) onBroken(wrapper *connectionWrapper) {
+	wscli.rwMutex.Lock()
+	if wrapper.broken {
+		wscli.rwMutex.Unlock()
+		return
+	}
+	if wscli.conns[wrapper.id] != wrapper {
+		// the connection is dialed by reconnecting and not installed yet
+		wrapper.close()
+		wscli.rwMutex.Unlock()
+		return
+	}
+	if wscli.reconnect != nil {
+		// keep the broken connection until it is replaced, so that its subscriptions can be replayed
+		wrapper.close()
+		wscli.rwMutex.Unlock()
+		go wscli.redial(wrapper, *wscli.reconnect)
+		return
+	}
+	handlers := wrapper.handlers()
+	wscli.clearConn(wrapper.id)
+	wscli.rwMutex.Unlock()
+
+	// notify user
+	for _, h := range handlers {
+		h.OnClose()
+	}
+}
 
-		// clear the connection and callback
-		wscli.clearConn(wrapper.id)
+// handlers return the handlers of all subscriptions
+func (wrapper *connectionWrapper) handlers() []WsEventHandler {
+	wrapper.hubMutex.RLock()
+	defer wrapper.hubMutex.RUnlock()
+	handlers := make([]WsEventHandler, 0, len(wrapper.eventHub))
+	for _, h := range wrapper.eventHub {
+		handlers = append(handlers, h)
+	}
+	return handlers
+}
 
+func (wscli *WebSocketClient) getCloseHandler(wrapper *connectionWrapper) func(code int, text string) error {
+	return func(code int, text string) error {
+		wscli.onBroken(wrapper)
 		return nil
 	}
 }
@@ -262,38 +433,47 @@ func (wscli *WebSocketClient) subscribe(nodeIndex int, eventHandler WsEventHandl
 		return "", NewSystemError(fmt.Errorf("node index out of range, suppose to be in [0, %d)", len(wscli.hrm.nodes)))
 	}
 
-	var (
-		stdErr StdError
-		conn   *websocket.Conn
-	)
+	var stdErr StdError
 	nodeIndex = nodeIndex - 1
 
-	// lock conns
-	wscli.rwMutex.Lock()
-	defer wscli.rwMutex.Unlock()
-
 	// lazy init
-	if wscli.conns[nodeIndex] == nil {
-		wscli.conns[nodeIndex] = &connectionWrapper{
-			mutex:         sync.Mutex{},
-			subIDCh:       make(chan SubscriptionID),
-			id:            nodeIndex,
-			subscriptions: make([]Subscription, 0),
-			eventHub:      make(map[SubscriptionID]WsEventHandler),
-		}
-		if wscli.conns[nodeIndex].conn, stdErr = wscli.getConn(nodeIndex); stdErr != nil {
+	wscli.rwMutex.Lock()
+	wrapper := wscli.conns[nodeIndex]
+	if wrapper == nil {
+		if wrapper, stdErr = wscli.dial(nodeIndex, nodeIndex); stdErr != nil {
+			wscli.rwMutex.Unlock()
 			return "", stdErr
 		}
-		// init when connect success
-		wscli.conns[nodeIndex].conn.SetCloseHandler(wscli.getCloseHandler(wscli.conns[nodeIndex]))
-		wscli.conns[nodeIndex].conn.SetPingHandler(wscli.pingHandler)
-		wscli.conns[nodeIndex].conn.SetPongHandler(wscli.pongHandler)
+		wscli.conns[nodeIndex] = wrapper
+	}
+	wscli.rwMutex.Unlock()
 
-		go wscli.listen(wscli.conns[nodeIndex])
+	wrapper.subMutex.Lock()
+	defer wrapper.subMutex.Unlock()
+
+	subID, stdErr := wscli.request(wrapper, eventHandler, method, params)
+	if stdErr != nil {
+		return "", stdErr
 	}
 
-	conn = wscli.conns[nodeIndex].conn
+	// store eventType=>subID
+	wrapper.hubMutex.Lock()
+	wrapper.subscriptions = append(wrapper.subscriptions, Subscription{Event: eventType, SubscriptionID: subID})
+	wrapper.records[subID] = &subscriptionRecord{
+		id:       subID,
+		serverID: subID,
+		method:   method,
+		params:   params,
+		event:    eventType,
+		handler:  eventHandler,
+	}
+	wrapper.hubMutex.Unlock()
 
+	return subID, nil
+}
+
+// request send a subscription request and wait for the subscription id, the caller should hold subMutex
+func (wscli *WebSocketClient) request(wrapper *connectionWrapper, eventHandler WsEventHandler, method string, params []interface{}) (SubscriptionID, StdError) {
 	jsonReq := JSONRequest{
 		Method:    method,
 		Version:   JSONRPCVersion,
@@ -310,27 +490,30 @@ func (wscli *WebSocketClient) subscribe(nodeIndex int, eventHandler WsEventHandl
 	logger.Debugf("[WEB SOCKET REQUEST]: %s", string(req))
 
 	// before request
-	wscli.conns[nodeIndex].handler = eventHandler
+	wrapper.hubMutex.Lock()
+	wrapper.handler = eventHandler
+	wrapper.hubMutex.Unlock()
+
+	select {
+	case <-wrapper.done:
+		return "", NewSystemError(fmt.Errorf("the connection of the node%d is closed, please try again", wrapper.node))
+	default:
+	}
 
 	// lock conn
-	wscli.conns[nodeIndex].mutex.Lock()
-	err = conn.WriteMessage(websocket.TextMessage, req)
+	wrapper.mutex.Lock()
+	err = wrapper.conn.WriteMessage(websocket.TextMessage, req)
+	wrapper.mutex.Unlock()
 	if err != nil {
-		wscli.conns[nodeIndex].mutex.Unlock()
 		return "", NewSystemError(err)
 	}
-	wscli.conns[nodeIndex].mutex.Unlock()
 
-	subID, ok := <-wscli.conns[nodeIndex].subIDCh
-	if !ok {
-		return "", NewSystemError(fmt.Errorf("the connection of the node%d is closed, please try again", nodeIndex))
+	select {
+	case subID := <-wrapper.subIDCh:
+		return subID, nil
+	case <-wrapper.done:
+		return "", NewSystemError(fmt.Errorf("the connection of the node%d is closed, please try again", wrapper.node))
 	}
-
-	// store eventType=>subID
-	wscli.conns[nodeIndex].subscriptions =
-		append(wscli.conns[nodeIndex].subscriptions, Subscription{Event: eventType, SubscriptionID: subID})
-
-	return subID, nil
 }
 
 // CloseConn is used to close the connection of a specific node
@@ -345,18 +528,25 @@ func (wscli *WebSocketClient) CloseConn(nodeIndex int) StdError {
 	wscli.rwMutex.Lock()
 	defer wscli.rwMutex.Unlock()
 
-	// try to close connection
-	if wscli.conns[nodeIndex] != nil {
-
-		wscli.conns[nodeIndex].mutex.Lock()
-		err := wscli.conns[nodeIndex].conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(1000, "ok"))
-		if err != nil {
-			wscli.conns[nodeIndex].mutex.Unlock()
-			return NewSystemError(err)
+	// try to close connection, a broken one which is reconnecting is just cleared
+	if wrapper := wscli.conns[nodeIndex]; wrapper != nil {
+		if !wrapper.broken {
+			wrapper.mutex.Lock()
+			err := wrapper.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(1000, "ok"))
+			wrapper.mutex.Unlock()
+			if err != nil {
+				return NewSystemError(err)
+			}
 		}
-		wscli.conns[nodeIndex].mutex.Unlock()
 
+		handlers := wrapper.handlers()
 		wscli.clearConn(nodeIndex)
+		// the ordered subscriptions are closed and their pending events are dropped
+		for _, h := range handlers {
+			if d, ok := h.(*dispatcher); ok {
+				d.control(dispatchItem{kind: dispatchClose}, true)
+			}
+		}
 	}
 
 	return nil
@@ -365,12 +555,57 @@ func (wscli *WebSocketClient) CloseConn(nodeIndex int) StdError {
 // UnSubscribe is used to unsubscribe a event by subID and user will not be notified by
 // the event once the method called
 func (wscli *WebSocketClient) UnSubscribe(id SubscriptionID) StdError {
+	wscli.rwMutex.RLock()
+	defer wscli.rwMutex.RUnlock()
+
+	for _, wrapper := range wscli.conns {
+		if wrapper == nil {
+			continue
+		}
+		wrapper.hubMutex.RLock()
+		record, ok := wrapper.records[id]
+		wrapper.hubMutex.RUnlock()
+		if !ok {
+			continue
+		}
+		serverID := record.getServerID()
+
+		// try to unsubscribe, the subscription of a broken connection is not replayed any more
+		if !wrapper.broken {
+			if err := wscli.unsubscribe(wrapper, serverID); err != nil {
+				return err
+			}
+		}
+
+		wrapper.hubMutex.Lock()
+		// clear callback
+		delete(wrapper.eventHub, serverID)
+		delete(wrapper.records, id)
+		// clear subID
+		for i := range wrapper.subscriptions {
+			if wrapper.subscriptions[i].SubscriptionID == id {
+				wrapper.subscriptions = append(wrapper.subscriptions[:i], wrapper.subscriptions[i+1:]...)
+				break
+			}
+		}
+		wrapper.hubMutex.Unlock()
+
+		// notify user
+		go record.handler.OnUnSubscribe()
+		return nil
+	}
+
+	return nil
+}
+
+// unsubscribe send the unsubscription request of the id given by the connection
+func (wscli *WebSocketClient) unsubscribe(wrapper *connectionWrapper, serverID SubscriptionID) StdError {
 	jsonReq := &JSONRequest{
 		ID:        1,
 		Namespace: wscli.hrm.namespace,
 		Version:   JSONRPCVersion,
 		Method:    "sub_unsubscribe",
-		Params:    []interface{}{id},
+		Params:    []interface{}{serverID},
 	}
 
 	req, err := json.Marshal(jsonReq)
@@ -378,40 +613,13 @@ func (wscli *WebSocketClient) UnSubscribe(id SubscriptionID) StdError {
 		return NewSystemError(err)
 	}
 
-	for k := range wscli.conns {
-		for k1 := range wscli.conns[k].eventHub {
-			if k1 == id {
-				logger.Debugf("[WEB SOCKET REQUEST]: %s", string(req))
-
-				wscli.rwMutex.RLock()
-				defer wscli.rwMutex.RUnlock()
-
-				// try to unsubscribe
-				if wscli.conns[k] != nil {
-					wscli.conns[k].mutex.Lock()
-					if err := wscli.conns[k].conn.WriteMessage(websocket.TextMessage, req); err != nil {
-						wscli.conns[k].mutex.Unlock()
-						return NewSystemError(err)
-					}
-					wscli.conns[k].mutex.Unlock()
+	logger.Debugf("[WEB SOCKET REQUEST]: %s", string(req))
 
-					// notify user
-					go wscli.conns[k].eventHub[id].OnUnSubscribe()
-					// clear callback
-					delete(wscli.conns[k].eventHub, id)
-					// clear subID
-					for i := range wscli.conns[k].subscriptions {
-						if wscli.conns[k].subscriptions[i].SubscriptionID == id {
-							wscli.conns[k].subscriptions = append(wscli.conns[k].subscriptions[:i], wscli.conns[k].subscriptions[i+1:]...)
-							break
-						}
-					}
-				}
-				return nil
-			}
-		}
+	wrapper.mutex.Lock()
+	defer wrapper.mutex.Unlock()
+	if err := wrapper.conn.WriteMessage(websocket.TextMessage, req); err != nil {
+		return NewSystemError(err)
 	}
-
 	return nil
 }
 
@@ -431,7 +639,10 @@ func (wscli *WebSocketClient) GetAllSubscription(nodeIndex int) ([]Subscription,
 		return make([]Subscription, 0), nil
 	}
 
-	return wscli.conns[nodeIndex].subscriptions, nil
+	wrapper := wscli.conns[nodeIndex]
+	wrapper.hubMutex.RLock()
+	defer wrapper.hubMutex.RUnlock()
+	return append([]Subscription(nil), wrapper.subscriptions...), nil
 }
 
 func (wscli *WebSocketClient) pingHandler(appData string) error {
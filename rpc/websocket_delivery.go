@@ -0,0 +1,353 @@
+package rpc
+
+import (
+	"errors"
+	"sync"
+)
+
+// OverflowPolicy decides what to do once the delivery queue of a subscription is full
+type OverflowPolicy int
+
+const (
+	// BlockOnOverflow stops reading the connection until the queue has room,
+	// the other subscriptions of the connection are blocked as well
+	BlockOnOverflow OverflowPolicy = iota
+	// DropOldestOnOverflow drops the oldest event in the queue
+	DropOldestOnOverflow
+	// CloseOnOverflow unsubscribes the subscription, the handler is notified by ErrSubscriptionOverflow
+	CloseOnOverflow
+)
+
+// ErrSubscriptionOverflow is the error of a subscription closed by CloseOnOverflow
+var ErrSubscriptionOverflow = errors.New("web socket subscription queue overflow")
+
+// ErrSubscriptionClosed is the error of a channel subscription whose connection is closed
+var ErrSubscriptionClosed = errors.New("web socket connection closed")
+
+// DeliveryOptions is the options of the ordered delivery of a subscription
+type DeliveryOptions struct {
+	// QueueSize is the max number of events waiting for the handler
+	QueueSize int
+	Overflow  OverflowPolicy
+}
+
+// DefaultDeliveryOptions return the default DeliveryOptions
+func DefaultDeliveryOptions() DeliveryOptions {
+	return DeliveryOptions{
+		QueueSize: DefaultWsQueueSize,
+		Overflow:  BlockOnOverflow,
+	}
+}
+
+// WsErrorHandler can be implemented by a WsEventHandler to be notified once the subscription fails,
+// e.g. it's closed by CloseOnOverflow
+type WsErrorHandler interface {
+	OnError(err error)
+}
+
+// kinds of dispatchItem, the terminal ones stop the dispatcher
+const (
+	dispatchMessage = iota
+	dispatchSubscribe
+	dispatchReconnect
+	dispatchError
+	dispatchUnSubscribe // terminal
+	dispatchClose       // terminal
+	dispatchStop        // terminal, the handler is not notified
+)
+
+type dispatchItem struct {
+	kind      int
+	data      []byte
+	nodeIndex int
+	err       error
+}
+
+// dispatcher is registered as the handler of an ordered subscription, it queues the events
+// and calls the methods of the user handler one by one in a single goroutine
+type dispatcher struct {
+	wscli   *WebSocketClient
+	handler WsEventHandler
+	options DeliveryOptions
+
+	mutex    sync.Mutex
+	cond     *sync.Cond
+	id       SubscriptionID
+	queue    []dispatchItem
+	messages int
+	// finished is set once a terminal item is queued, the following events are ignored
+	finished bool
+}
+
+func newDispatcher(wscli *WebSocketClient, handler WsEventHandler, options DeliveryOptions) *dispatcher {
+	if options.QueueSize <= 0 {
+		options.QueueSize = DefaultWsQueueSize
+	}
+	d := &dispatcher{
+		wscli:   wscli,
+		handler: handler,
+		options: options,
+	}
+	d.cond = sync.NewCond(&d.mutex)
+	go d.loop()
+	return d
+}
+
+func (d *dispatcher) setID(id SubscriptionID) {
+	d.mutex.Lock()
+	d.id = id
+	d.mutex.Unlock()
+}
+
+// push queue an event, it's called by the listening goroutine
+func (d *dispatcher) push(data []byte) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for !d.finished && d.messages >= d.options.QueueSize {
+		switch d.options.Overflow {
+		case DropOldestOnOverflow:
+			// messages are always in front of the control items
+			for i := range d.queue {
+				if d.queue[i].kind == dispatchMessage {
+					d.queue = append(d.queue[:i], d.queue[i+1:]...)
+					d.messages--
+					break
+				}
+			}
+		case CloseOnOverflow:
+			d.queue = d.queue[:0]
+			d.messages = 0
+			d.queue = append(d.queue, dispatchItem{kind: dispatchError, err: ErrSubscriptionOverflow})
+			d.finish(dispatchUnSubscribe)
+			if d.id != "" {
+				//nolint
+				go d.wscli.UnSubscribe(d.id)
+			}
+			return
+		default:
+			d.cond.Wait()
+		}
+	}
+	if d.finished {
+		return
+	}
+	d.queue = append(d.queue, dispatchItem{kind: dispatchMessage, data: data})
+	d.messages++
+	d.cond.Broadcast()
+}
+
+// control queue a control item, the pending events are dropped if discard is set
+func (d *dispatcher) control(item dispatchItem, discard bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.finished {
+		return
+	}
+	if discard {
+		d.queue = d.queue[:0]
+		d.messages = 0
+	}
+	if item.kind >= dispatchUnSubscribe {
+		d.finish(item.kind)
+		return
+	}
+	d.queue = append(d.queue, item)
+	d.cond.Broadcast()
+}
+
+// finish queue the terminal item, the caller should hold mutex
+func (d *dispatcher) finish(kind int) {
+	d.finished = true
+	d.queue = append(d.queue, dispatchItem{kind: kind})
+	d.cond.Broadcast()
+}
+
+func (d *dispatcher) loop() {
+	for {
+		d.mutex.Lock()
+		for len(d.queue) == 0 {
+			d.cond.Wait()
+		}
+		item := d.queue[0]
+		d.queue = d.queue[1:]
+		if item.kind == dispatchMessage {
+			d.messages--
+		}
+		// wake up the blocked reader
+		d.cond.Broadcast()
+		d.mutex.Unlock()
+
+		switch item.kind {
+		case dispatchMessage:
+			d.handler.OnMessage(item.data)
+		case dispatchSubscribe:
+			d.handler.OnSubscribe()
+		case dispatchReconnect:
+			if h, ok := d.handler.(WsReconnectHandler); ok {
+				h.OnReconnect(item.nodeIndex)
+			}
+		case dispatchError:
+			if h, ok := d.handler.(WsErrorHandler); ok {
+				h.OnError(item.err)
+			}
+		case dispatchUnSubscribe:
+			d.handler.OnUnSubscribe()
+			return
+		case dispatchClose:
+			d.handler.OnClose()
+			return
+		case dispatchStop:
+			return
+		}
+	}
+}
+
+// OnSubscribe implements WsEventHandler
+func (d *dispatcher) OnSubscribe() {
+	d.control(dispatchItem{kind: dispatchSubscribe}, false)
+}
+
+// OnUnSubscribe implements WsEventHandler, the pending events are dropped
+func (d *dispatcher) OnUnSubscribe() {
+	d.control(dispatchItem{kind: dispatchUnSubscribe}, true)
+}
+
+// OnMessage implements WsEventHandler
+func (d *dispatcher) OnMessage(data []byte) {
+	d.push(data)
+}
+
+// OnClose implements WsEventHandler, the pending events are delivered before OnClose
+func (d *dispatcher) OnClose() {
+	d.control(dispatchItem{kind: dispatchClose}, false)
+}
+
+// OnReconnect implements WsReconnectHandler
+func (d *dispatcher) OnReconnect(nodeIndex int) {
+	d.control(dispatchItem{kind: dispatchReconnect, nodeIndex: nodeIndex}, false)
+}
+
+// SubscribeOrdered is used to subscribe event(s) of the specific node like Subscribe, but the events are
+// delivered to the handler one by one in order through a bounded queue, the options decide what to do
+// once the queue is full. OnClose of the handler is called after CloseConn as well.
+// note: nodeIndex start from 1
+func (wscli *WebSocketClient) SubscribeOrdered(nodeIndex int, filter EventFilter, eventHandler WsEventHandler, options DeliveryOptions) (SubscriptionID, StdError) {
+	return wscli.subscribeOrdered(eventHandler, options, func(d *dispatcher) (SubscriptionID, StdError) {
+		return wscli.Subscribe(nodeIndex, filter, d)
+	})
+}
+
+// subscribeOrdered register the dispatcher of handler by subscribe
+func (wscli *WebSocketClient) subscribeOrdered(eventHandler WsEventHandler, options DeliveryOptions, subscribe func(d *dispatcher) (SubscriptionID, StdError)) (SubscriptionID, StdError) {
+	d := newDispatcher(wscli, eventHandler, options)
+	subID, err := subscribe(d)
+	if err != nil {
+		// the handler is never subscribed
+		d.control(dispatchItem{kind: dispatchStop}, true)
+		return "", err
+	}
+	d.setID(subID)
+	return subID, nil
+}
+
+// EventSubscription is a subscription whose events are received from a channel
+type EventSubscription struct {
+	ID SubscriptionID
+
+	wscli    *WebSocketClient
+	events   chan []byte
+	quit     chan struct{}
+	quitOnce sync.Once
+
+	// err is read by the caller of Err while the dispatcher goroutine writes it
+	mutex  sync.Mutex
+	closed bool
+	err    error
+}
+
+// SubscribeChan is used to subscribe event(s) of the specific node, the events are received from
+// the channel of the returned EventSubscription in order, the options decide what to do once the
+// channel is not received in time and the queue is full.
+// note: nodeIndex start from 1
+func (wscli *WebSocketClient) SubscribeChan(nodeIndex int, filter EventFilter, options DeliveryOptions) (*EventSubscription, StdError) {
+	es := &EventSubscription{
+		wscli:  wscli,
+		events: make(chan []byte),
+		quit:   make(chan struct{}),
+	}
+	subID, err := wscli.SubscribeOrdered(nodeIndex, filter, es, options)
+	if err != nil {
+		return nil, err
+	}
+	es.ID = subID
+	return es, nil
+}
+
+// Events return the channel of events, it's closed once the subscription is unsubscribed or failed
+func (es *EventSubscription) Events() <-chan []byte {
+	return es.events
+}
+
+// Err return the error which closes the channel, e.g. ErrSubscriptionOverflow or ErrSubscriptionClosed,
+// it's nil if the subscription is closed by Close or UnSubscribe
+func (es *EventSubscription) Err() error {
+	select {
+	case <-es.quit:
+		return nil
+	default:
+	}
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+	return es.err
+}
+
+// Close unsubscribe the subscription, the events not received are dropped
+func (es *EventSubscription) Close() StdError {
+	es.quitOnce.Do(func() {
+		close(es.quit)
+	})
+	return es.wscli.UnSubscribe(es.ID)
+}
+
+// the following methods are only called by the dispatcher goroutine
+
+// OnSubscribe implements WsEventHandler
+func (es *EventSubscription) OnSubscribe() {}
+
+// OnUnSubscribe implements WsEventHandler
+func (es *EventSubscription) OnUnSubscribe() {
+	es.close(nil)
+}
+
+// OnMessage implements WsEventHandler
+func (es *EventSubscription) OnMessage(data []byte) {
+	select {
+	case es.events <- data:
+	case <-es.quit:
+	}
+}
+
+// OnClose implements WsEventHandler
+func (es *EventSubscription) OnClose() {
+	es.close(ErrSubscriptionClosed)
+}
+
+// OnError implements WsErrorHandler
+func (es *EventSubscription) OnError(err error) {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+	es.err = err
+}
+
+func (es *EventSubscription) close(err error) {
+	es.mutex.Lock()
+	defer es.mutex.Unlock()
+	if es.closed {
+		return
+	}
+	if es.err == nil {
+		es.err = err
+	}
+	es.closed = true
+	close(es.events)
+}
@@ -0,0 +1,199 @@
+package rpc
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// orderedTestHandler record the messages, the first message is blocked until gate is closed
+type orderedTestHandler struct {
+	gate     chan struct{}
+	mutex    sync.Mutex
+	messages []string
+	closed   chan struct{}
+	once     sync.Once
+}
+
+func newOrderedTestHandler() *orderedTestHandler {
+	return &orderedTestHandler{gate: make(chan struct{}), closed: make(chan struct{})}
+}
+
+func (h *orderedTestHandler) OnSubscribe()   {}
+func (h *orderedTestHandler) OnUnSubscribe() {}
+func (h *orderedTestHandler) OnClose()       { close(h.closed) }
+func (h *orderedTestHandler) OnMessage(data []byte) {
+	h.once.Do(func() { <-h.gate })
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.messages = append(h.messages, string(data))
+}
+
+func (h *orderedTestHandler) received() []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return append([]string(nil), h.messages...)
+}
+
+func newDeliveryTestClient(t *testing.T) (*WebSocketClient, *wsTestServer) {
+	s := newWsTestServer(t)
+	rpc := DefaultRPC(s.node(t))
+	return &WebSocketClient{
+		conns: make(map[int]*connectionWrapper, len(rpc.hrm.nodes)),
+		hrm:   &rpc.hrm,
+	}, s
+}
+
+// waitDispatched wait until the events pushed are taken by the handler
+func waitDispatched(t *testing.T, wsCli *WebSocketClient, subID SubscriptionID) {
+	// wait for the events to be read
+	time.Sleep(50 * time.Millisecond)
+	wsCli.rwMutex.RLock()
+	wrapper := wsCli.conns[0]
+	wsCli.rwMutex.RUnlock()
+	wrapper.hubMutex.RLock()
+	d := wrapper.eventHub[subID].(*dispatcher)
+	wrapper.hubMutex.RUnlock()
+	assert.Eventually(t, func() bool {
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+		return len(d.queue) == 0
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestWebSocketClient_SubscribeOrdered(t *testing.T) {
+	wsCli, s := newDeliveryTestClient(t)
+	h := newOrderedTestHandler()
+	close(h.gate)
+	_, err := wsCli.SubscribeOrdered(1, NewBlockEventFilter(), h, DefaultDeliveryOptions())
+	assert.Nil(t, err)
+
+	var expect []string
+	for i := 0; i < 50; i++ {
+		s.push("0xsub1", strconv.Itoa(i))
+		expect = append(expect, strconv.Itoa(i))
+	}
+	assert.Eventually(t, func() bool { return len(h.received()) == 50 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, expect, h.received())
+
+	// OnClose is called after CloseConn
+	assert.Nil(t, wsCli.CloseConn(1))
+	select {
+	case <-h.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func TestWebSocketClient_DropOldestOnOverflow(t *testing.T) {
+	wsCli, s := newDeliveryTestClient(t)
+	defer wsCli.CloseConn(1)
+	h := newOrderedTestHandler()
+	_, err := wsCli.SubscribeOrdered(1, NewBlockEventFilter(), h, DeliveryOptions{QueueSize: 2, Overflow: DropOldestOnOverflow})
+	assert.Nil(t, err)
+
+	// 0 is being handled, 1 and 2 are dropped
+	s.push("0xsub1", "0")
+	waitDispatched(t, wsCli, "0xsub1")
+	for i := 1; i < 5; i++ {
+		s.push("0xsub1", strconv.Itoa(i))
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(h.gate)
+	assert.Eventually(t, func() bool { return len(h.received()) == 3 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"0", "3", "4"}, h.received())
+}
+
+func TestWebSocketClient_SubscribeChan(t *testing.T) {
+	wsCli, s := newDeliveryTestClient(t)
+	defer wsCli.CloseConn(1)
+	es, err := wsCli.SubscribeChan(1, NewBlockEventFilter(), DefaultDeliveryOptions())
+	assert.Nil(t, err)
+	assert.Equal(t, SubscriptionID("0xsub1"), es.ID)
+
+	s.push("0xsub1", "1")
+	s.push("0xsub1", "2")
+	for _, expect := range []string{"1", "2"} {
+		select {
+		case data := <-es.Events():
+			assert.Equal(t, expect, string(data))
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+	}
+
+	assert.Nil(t, es.Close())
+	for range es.Events() {
+	}
+	assert.Nil(t, es.Err())
+	assert.Eventually(t, func() bool {
+		_, unsubs := s.state()
+		return len(unsubs) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestWebSocketClient_CloseOnOverflow(t *testing.T) {
+	wsCli, s := newDeliveryTestClient(t)
+	defer wsCli.CloseConn(1)
+	es, err := wsCli.SubscribeChan(1, NewBlockEventFilter(), DeliveryOptions{QueueSize: 1, Overflow: CloseOnOverflow})
+	assert.Nil(t, err)
+
+	// 0 is being sent to the channel, 1 is queued and 2 overflows
+	s.push("0xsub1", "0")
+	waitDispatched(t, wsCli, "0xsub1")
+	for i := 1; i < 3; i++ {
+		s.push("0xsub1", strconv.Itoa(i))
+	}
+	time.Sleep(100 * time.Millisecond)
+	var received []string
+	for data := range es.Events() {
+		received = append(received, string(data))
+	}
+	assert.Equal(t, []string{"0"}, received)
+	assert.Equal(t, ErrSubscriptionOverflow, es.Err())
+	assert.Eventually(t, func() bool {
+		_, unsubs := s.state()
+		return len(unsubs) == 1 && unsubs[0] == "0xsub1"
+	}, 5*time.Second, 10*time.Millisecond)
+	subs, _ := wsCli.GetAllSubscription(1)
+	assert.Len(t, subs, 0)
+}
+
+func TestEventSubscription_Err(t *testing.T) {
+	es := &EventSubscription{events: make(chan []byte), quit: make(chan struct{})}
+	// Err is polled by the caller while the dispatcher goroutine fails the subscription
+	go func() {
+		es.OnError(ErrSubscriptionOverflow)
+		es.OnClose()
+	}()
+	assert.Eventually(t, func() bool { return es.Err() != nil }, 5*time.Second, time.Millisecond)
+	_, ok := <-es.Events()
+	assert.False(t, ok)
+	assert.Equal(t, ErrSubscriptionOverflow, es.Err())
+}
+
+// unSubscribeTestHandler record whether OnUnSubscribe is called
+type unSubscribeTestHandler struct {
+	orderedTestHandler
+	unSubscribed chan struct{}
+}
+
+func (h *unSubscribeTestHandler) OnUnSubscribe() { close(h.unSubscribed) }
+
+func TestWebSocketClient_SubscribeOrdered_Failed(t *testing.T) {
+	wsCli := &WebSocketClient{conns: make(map[int]*connectionWrapper)}
+	h := &unSubscribeTestHandler{unSubscribed: make(chan struct{})}
+	_, err := wsCli.subscribeOrdered(h, DefaultDeliveryOptions(), func(d *dispatcher) (SubscriptionID, StdError) {
+		return "", NewSystemError(errors.New("subscribe failed"))
+	})
+	assert.NotNil(t, err)
+	select {
+	case <-h.unSubscribed:
+		t.Fatal("OnUnSubscribe is called without subscribing")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,182 @@
+package rpc
+
+import (
+	"time"
+)
+
+// ReconnectPolicy is the policy of redialing a broken web socket connection, the durations are in milliseconds
+type ReconnectPolicy struct {
+	// MinBackoff and MaxBackoff bound the exponential backoff between redials
+	MinBackoff int64
+	MaxBackoff int64
+	// FailoverAfter is the number of failed redials before trying the wsURL of the other nodes,
+	// zero means never fail over
+	FailoverAfter int
+	// MaxAttempts is the number of failed redials before giving up, zero means never give up
+	MaxAttempts int
+}
+
+// DefaultReconnectPolicy return the default ReconnectPolicy
+func DefaultReconnectPolicy() ReconnectPolicy {
+	return ReconnectPolicy{
+		MinBackoff:    DefaultWsMinBackoff,
+		MaxBackoff:    DefaultWsMaxBackoff,
+		FailoverAfter: DefaultWsFailoverAfter,
+	}
+}
+
+// WsReconnectHandler can be implemented by a WsEventHandler to be notified once its subscription
+// is replayed on a new connection, the events during the reconnection are lost
+type WsReconnectHandler interface {
+	// nodeIndex is the node connected now, it starts from 1
+	OnReconnect(nodeIndex int)
+}
+
+// EnableReconnect let the client redial a broken connection with backoff and replay its subscriptions,
+// the SubscriptionID returned by Subscribe keeps valid after that. OnClose of the handlers is only
+// called once it gives up.
+func (wscli *WebSocketClient) EnableReconnect(policy ReconnectPolicy) *WebSocketClient {
+	if policy.MinBackoff <= 0 {
+		policy.MinBackoff = DefaultWsMinBackoff
+	}
+	if policy.MaxBackoff < policy.MinBackoff {
+		policy.MaxBackoff = policy.MinBackoff
+	}
+	wscli.rwMutex.Lock()
+	wscli.reconnect = &policy
+	wscli.rwMutex.Unlock()
+	return wscli
+}
+
+// DisableReconnect let the broken connections be cleared and the handlers be notified by OnClose
+func (wscli *WebSocketClient) DisableReconnect() *WebSocketClient {
+	wscli.rwMutex.Lock()
+	wscli.reconnect = nil
+	wscli.rwMutex.Unlock()
+	return wscli
+}
+
+// redial connect again until the subscriptions of the broken connection are replayed or it gives up
+func (wscli *WebSocketClient) redial(broken *connectionWrapper, policy ReconnectPolicy) {
+	// wait for the subscription request in flight
+	broken.subMutex.Lock()
+	broken.hubMutex.RLock()
+	records := make([]*subscriptionRecord, 0, len(broken.subscriptions))
+	for _, sub := range broken.subscriptions {
+		if record, ok := broken.records[sub.SubscriptionID]; ok {
+			records = append(records, record)
+		}
+	}
+	broken.hubMutex.RUnlock()
+	broken.subMutex.Unlock()
+
+	backoff := policy.MinBackoff
+	for attempt := 1; ; attempt++ {
+		time.Sleep(time.Millisecond * time.Duration(backoff))
+		if backoff *= 2; backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+		if !wscli.isCurrent(broken) {
+			// closed by user
+			return
+		}
+
+		node := broken.node
+		if policy.FailoverAfter > 0 && attempt > policy.FailoverAfter {
+			node = (broken.id + attempt - policy.FailoverAfter) % len(wscli.hrm.nodes)
+		}
+		wrapper, serverIDs, err := wscli.replay(broken.id, node, records)
+		if err == nil && wscli.install(broken, wrapper, records, serverIDs) {
+			logger.Infof("web socket of node%d reconnected to node%d, %d subscriptions are replayed", broken.id+1, node+1, len(records))
+			for _, record := range records {
+				if h, ok := record.handler.(WsReconnectHandler); ok {
+					go h.OnReconnect(node + 1)
+				}
+			}
+			return
+		}
+		if err != nil {
+			logger.Debugf("web socket of node%d reconnect to node%d failed: %v", broken.id+1, node+1, err)
+		}
+		if wrapper != nil {
+			wscli.rwMutex.Lock()
+			wrapper.close()
+			wscli.rwMutex.Unlock()
+		}
+
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			logger.Errorf("web socket of node%d gives up reconnecting after %d attempts", broken.id+1, attempt)
+			wscli.rwMutex.Lock()
+			if wscli.conns[broken.id] != broken {
+				wscli.rwMutex.Unlock()
+				return
+			}
+			handlers := broken.handlers()
+			wscli.clearConn(broken.id)
+			wscli.rwMutex.Unlock()
+			for _, h := range handlers {
+				h.OnClose()
+			}
+			return
+		}
+	}
+}
+
+// isCurrent report whether the connection is not replaced or cleared
+func (wscli *WebSocketClient) isCurrent(wrapper *connectionWrapper) bool {
+	wscli.rwMutex.RLock()
+	defer wscli.rwMutex.RUnlock()
+	return wscli.conns[wrapper.id] == wrapper
+}
+
+// replay dial the node and subscribe the records again, the new subscription ids are returned in order
+func (wscli *WebSocketClient) replay(id, node int, records []*subscriptionRecord) (*connectionWrapper, []SubscriptionID, StdError) {
+	wrapper, err := wscli.dial(id, node)
+	if err != nil {
+		return nil, nil, err
+	}
+	wrapper.subMutex.Lock()
+	defer wrapper.subMutex.Unlock()
+	serverIDs := make([]SubscriptionID, 0, len(records))
+	for _, record := range records {
+		serverID, err := wscli.request(wrapper, record.handler, record.method, record.params)
+		if err != nil {
+			return wrapper, nil, err
+		}
+		serverIDs = append(serverIDs, serverID)
+	}
+	return wrapper, serverIDs, nil
+}
+
+// install replace the broken connection by the new one, the records unsubscribed meanwhile are dropped
+func (wscli *WebSocketClient) install(broken, wrapper *connectionWrapper, records []*subscriptionRecord, serverIDs []SubscriptionID) bool {
+	wscli.rwMutex.Lock()
+	defer wscli.rwMutex.Unlock()
+	if wscli.conns[broken.id] != broken || wrapper.broken {
+		return false
+	}
+
+	broken.hubMutex.RLock()
+	wrapper.hubMutex.Lock()
+	var dropped []SubscriptionID
+	for i, record := range records {
+		if _, ok := broken.records[record.id]; !ok {
+			delete(wrapper.eventHub, serverIDs[i])
+			dropped = append(dropped, serverIDs[i])
+			continue
+		}
+		// the callers keep the id returned by the first subscription
+		record.setServerID(serverIDs[i])
+		wrapper.records[record.id] = record
+		wrapper.subscriptions = append(wrapper.subscriptions, Subscription{Event: record.event, SubscriptionID: record.id})
+	}
+	wrapper.hubMutex.Unlock()
+	broken.hubMutex.RUnlock()
+
+	for _, serverID := range dropped {
+		//nolint
+		wscli.unsubscribe(wrapper, serverID)
+	}
+	wscli.conns[broken.id] = wrapper
+	return true
+}
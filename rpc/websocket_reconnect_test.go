@@ -0,0 +1,250 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// wsTestServer is a web socket server which gives the subscription ids 0xsub1, 0xsub2... in order
+type wsTestServer struct {
+	server *httptest.Server
+	mutex  sync.Mutex
+	conn   *websocket.Conn
+	subIDs []string
+	unsubs []string
+}
+
+func newWsTestServer(t *testing.T) *wsTestServer {
+	s := &wsTestServer{}
+	upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.mutex.Lock()
+		s.conn = conn
+		s.mutex.Unlock()
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var req JSONRequest
+			_ = json.Unmarshal(data, &req)
+			s.mutex.Lock()
+			switch req.Method {
+			case "sub_subscribe":
+				subID := fmt.Sprintf("0xsub%d", len(s.subIDs)+1)
+				s.subIDs = append(s.subIDs, subID)
+				_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","code":0,"message":"SUCCESS","result":"`+subID+`"}`))
+			case "sub_unsubscribe":
+				s.unsubs = append(s.unsubs, req.Params[0].(string))
+				_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","code":0,"message":"SUCCESS","result":true}`))
+			}
+			s.mutex.Unlock()
+		}
+	}))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func (s *wsTestServer) node(t *testing.T) *Node {
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(s.server.URL, "http://"))
+	assert.Nil(t, err)
+	return NewNode(host, port, port)
+}
+
+// push send a block event of the subscription to the current connection
+func (s *wsTestServer) push(subID string, data string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_ = s.conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","code":0,"result":{"event":"block","subscription":"`+subID+`","data":`+data+`}}`))
+}
+
+// drop break the current connection without closing handshake
+func (s *wsTestServer) drop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_ = s.conn.Close()
+}
+
+func (s *wsTestServer) state() ([]string, []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.subIDs...), append([]string(nil), s.unsubs...)
+}
+
+type wsTestHandler struct {
+	messages   chan string
+	reconnects chan int
+	closed     chan struct{}
+}
+
+func newWsTestHandler() *wsTestHandler {
+	return &wsTestHandler{
+		messages:   make(chan string, 10),
+		reconnects: make(chan int, 10),
+		closed:     make(chan struct{}, 10),
+	}
+}
+
+func (h *wsTestHandler) OnSubscribe()              {}
+func (h *wsTestHandler) OnUnSubscribe()            {}
+func (h *wsTestHandler) OnMessage(data []byte)     { h.messages <- string(data) }
+func (h *wsTestHandler) OnClose()                  { h.closed <- struct{}{} }
+func (h *wsTestHandler) OnReconnect(nodeIndex int) { h.reconnects <- nodeIndex }
+
+func newReconnectTestClient(rpc *RPC, policy ReconnectPolicy) *WebSocketClient {
+	wsCli := &WebSocketClient{
+		conns: make(map[int]*connectionWrapper, len(rpc.hrm.nodes)),
+		hrm:   &rpc.hrm,
+	}
+	return wsCli.EnableReconnect(policy)
+}
+
+func waitReconnect(t *testing.T, h *wsTestHandler) int {
+	select {
+	case nodeIndex := <-h.reconnects:
+		return nodeIndex
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+	return 0
+}
+
+func TestWebSocketClient_Reconnect(t *testing.T) {
+	s := newWsTestServer(t)
+	rpc := DefaultRPC(s.node(t))
+	wsCli := newReconnectTestClient(rpc, ReconnectPolicy{MinBackoff: 10, MaxBackoff: 20})
+	defer wsCli.CloseConn(1)
+
+	h := newWsTestHandler()
+	subID, err := wsCli.Subscribe(1, NewBlockEventFilter(), h)
+	assert.Nil(t, err)
+	assert.Equal(t, SubscriptionID("0xsub1"), subID)
+
+	s.drop()
+	assert.Equal(t, 1, waitReconnect(t, h))
+	subIDs, _ := s.state()
+	assert.Equal(t, []string{"0xsub1", "0xsub2"}, subIDs)
+
+	// the events of the new subscription are delivered to the same handler
+	s.push("0xsub2", `{"number":"0x1"}`)
+	select {
+	case msg := <-h.messages:
+		assert.Equal(t, `{"number":"0x1"}`, msg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+
+	// the callers keep the first id
+	subs, err := wsCli.GetAllSubscription(1)
+	assert.Nil(t, err)
+	assert.Equal(t, []Subscription{{Event: BLOCKEVENT, SubscriptionID: subID}}, subs)
+
+	assert.Nil(t, wsCli.UnSubscribe(subID))
+	assert.Eventually(t, func() bool {
+		_, unsubs := s.state()
+		return len(unsubs) == 1 && unsubs[0] == "0xsub2"
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Len(t, h.closed, 0)
+}
+
+func TestWebSocketClient_ReconnectFailover(t *testing.T) {
+	s1 := newWsTestServer(t)
+	s2 := newWsTestServer(t)
+	rpc := DefaultRPC(s1.node(t), s2.node(t))
+	wsCli := newReconnectTestClient(rpc, ReconnectPolicy{MinBackoff: 10, MaxBackoff: 20, FailoverAfter: 1})
+	defer wsCli.CloseConn(1)
+
+	h := newWsTestHandler()
+	subID, err := wsCli.Subscribe(1, NewBlockEventFilter(), h)
+	assert.Nil(t, err)
+
+	// node1 stays down
+	s1.server.Close()
+	s1.drop()
+	assert.Equal(t, 2, waitReconnect(t, h))
+	subIDs, _ := s2.state()
+	assert.Equal(t, []string{"0xsub1"}, subIDs)
+
+	s2.push("0xsub1", `{"number":"0x2"}`)
+	select {
+	case msg := <-h.messages:
+		assert.Equal(t, `{"number":"0x2"}`, msg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+	subs, _ := wsCli.GetAllSubscription(1)
+	assert.Equal(t, subID, subs[0].SubscriptionID)
+}
+
+func TestWebSocketClient_ReconnectGiveUp(t *testing.T) {
+	s := newWsTestServer(t)
+	rpc := DefaultRPC(s.node(t))
+	wsCli := newReconnectTestClient(rpc, ReconnectPolicy{MinBackoff: 10, MaxBackoff: 20, MaxAttempts: 2})
+
+	h := newWsTestHandler()
+	_, err := wsCli.Subscribe(1, NewBlockEventFilter(), h)
+	assert.Nil(t, err)
+
+	s.server.Close()
+	s.drop()
+	select {
+	case <-h.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+	subs, _ := wsCli.GetAllSubscription(1)
+	assert.Len(t, subs, 0)
+}
+
+func TestWebSocketClient_TLSHandshake(t *testing.T) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+	headers := make(chan string, 1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Get("Authorization")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		_ = conn.Close()
+	}))
+	defer server.Close()
+
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(server.URL, "https://"))
+	assert.Nil(t, err)
+	rpc := DefaultRPC(NewNode(host, port, port))
+	// the web socket dialer trusts the same certificates as the http client
+	rpc.hrm.client = server.Client()
+	rpc.hrm.nodes[0].wsURL = "wss://" + host + ":" + port
+	rpc.Middlewares(func(next RoundTripper) RoundTripper {
+		return RoundTripperFunc(func(ctx context.Context, call *Call) ([]byte, StdError) {
+			call.Header["Authorization"] = "Bearer token"
+			return next.RoundTrip(ctx, call)
+		})
+	})
+
+	wsCli := newReconnectTestClient(rpc, ReconnectPolicy{})
+	conn, stdErr := wsCli.getConn(0)
+	assert.Nil(t, stdErr)
+	_ = conn.Close()
+	assert.Equal(t, "Bearer token", <-headers)
+}
+
+func TestNewNode_WebSocketScheme(t *testing.T) {
+	assert.Equal(t, "ws://localhost:11001", newNode("localhost", "8081", "11001", false).wsURL)
+	assert.Equal(t, "wss://localhost:11001", newNode("localhost", "8081", "11001", true).wsURL)
+}
@@ -0,0 +1,286 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/meshplus/gosdk/abi"
+	"github.com/meshplus/gosdk/abi2"
+	"github.com/meshplus/gosdk/common"
+)
+
+// SystemStatusEvent is the notification of the system status subscription
+type SystemStatusEvent struct {
+	Module    string `json:"module"`
+	Status    bool   `json:"status"`
+	Subtype   string `json:"subType"`
+	ErrorCode int    `json:"errorCode"`
+	Message   string `json:"message"`
+	Date      string `json:"date"`
+}
+
+// DecodedLog is a log decoded by a LogDecoder, Args hold the event arguments by name
+type DecodedLog struct {
+	Name string
+	Args map[string]interface{}
+}
+
+// LogDecoder decode the topics and data of a log into the named event arguments
+type LogDecoder interface {
+	DecodeLog(log TxLog) (*DecodedLog, error)
+}
+
+// typedHandler is the WsEventHandler of the typed subscriptions, the notifications are decoded
+// and delivered to onMessage in order, the ones failed to decode are logged and skipped
+type typedHandler struct {
+	name      string
+	onMessage func(data []byte) error
+}
+
+// OnSubscribe implements WsEventHandler
+func (h *typedHandler) OnSubscribe() {}
+
+// OnUnSubscribe implements WsEventHandler
+func (h *typedHandler) OnUnSubscribe() {}
+
+// OnClose implements WsEventHandler
+func (h *typedHandler) OnClose() {}
+
+// OnMessage implements WsEventHandler
+func (h *typedHandler) OnMessage(data []byte) {
+	if err := h.onMessage(data); err != nil {
+		logger.Errorf("decode %s event error: %v", h.name, err)
+	}
+}
+
+func (wscli *WebSocketClient) subscribeTyped(nodeIndex int, filter EventFilter, handler *typedHandler) (SubscriptionID, StdError) {
+	return wscli.SubscribeOrdered(nodeIndex, filter, handler, DefaultDeliveryOptions())
+}
+
+// SubscribeBlocks is used to subscribe the blocks of the specific node, the block events are decoded
+// and delivered to handler in order, a default filter is used if filter is nil.
+// note: nodeIndex start from 1
+func (wscli *WebSocketClient) SubscribeBlocks(nodeIndex int, filter *BlockEventFilter, handler func(block *Block)) (SubscriptionID, StdError) {
+	if filter == nil {
+		filter = NewBlockEventFilter()
+	}
+	return wscli.subscribeTyped(nodeIndex, filter, &typedHandler{
+		name: string(BLOCKEVENT),
+		onMessage: func(data []byte) error {
+			var blockRaw BlockRaw
+			if err := json.Unmarshal(data, &blockRaw); err != nil {
+				return err
+			}
+			block, err := blockRaw.ToBlock()
+			if err != nil {
+				return err
+			}
+			handler(block)
+			return nil
+		},
+	})
+}
+
+// SubscribeLogs is used to subscribe the logs of the specific node, the logs of a notification are
+// delivered to handler one by one in order.
+// note: nodeIndex start from 1
+func (wscli *WebSocketClient) SubscribeLogs(nodeIndex int, filter *LogsFilter, handler func(log TxLog)) (SubscriptionID, StdError) {
+	if filter == nil {
+		filter = NewLogsFilter()
+	}
+	return wscli.subscribeTyped(nodeIndex, filter, &typedHandler{
+		name: string(LOGSEVENT),
+		onMessage: func(data []byte) error {
+			logs, err := decodeLogs(data)
+			if err != nil {
+				return err
+			}
+			for _, log := range logs {
+				handler(log)
+			}
+			return nil
+		},
+	})
+}
+
+// SubscribeDecodedLogs is used to subscribe the logs of the specific node like SubscribeLogs, the logs are
+// decoded by decoder as well, event is nil if the log can't be decoded and the reason is logged.
+// note: nodeIndex start from 1
+func (wscli *WebSocketClient) SubscribeDecodedLogs(nodeIndex int, filter *LogsFilter, decoder LogDecoder, handler func(log TxLog, event *DecodedLog)) (SubscriptionID, StdError) {
+	return wscli.SubscribeLogs(nodeIndex, filter, func(log TxLog) {
+		event, err := decoder.DecodeLog(log)
+		if err != nil {
+			logger.Errorf("decode log %s:%d error: %v", log.TxHash, log.Index, err)
+		}
+		handler(log, event)
+	})
+}
+
+// SubscribeSystemStatus is used to subscribe the system status of the specific node, the notifications
+// are decoded and delivered to handler in order.
+// note: nodeIndex start from 1
+func (wscli *WebSocketClient) SubscribeSystemStatus(nodeIndex int, filter *SystemStatusFilter, handler func(event *SystemStatusEvent)) (SubscriptionID, StdError) {
+	if filter == nil {
+		filter = NewSystemStatusFilter()
+	}
+	return wscli.subscribeTyped(nodeIndex, filter, &typedHandler{
+		name: string(SYSTEMSTATUSEVENT),
+		onMessage: func(data []byte) error {
+			var event SystemStatusEvent
+			if err := json.Unmarshal(data, &event); err != nil {
+				return err
+			}
+			handler(&event)
+			return nil
+		},
+	})
+}
+
+// SubscribeProposals is used to subscribe the proposal of the specific node like SubscribeForProposal,
+// the proposal is decoded and delivered to handler in order once its status is changed.
+// note: nodeIndex start from 1
+func (wscli *WebSocketClient) SubscribeProposals(nodeIndex int, handler func(proposal *ProposalRaw)) (SubscriptionID, StdError) {
+	h := &typedHandler{
+		name: "proposal",
+		onMessage: func(data []byte) error {
+			var proposal ProposalRaw
+			if err := json.Unmarshal(data, &proposal); err != nil {
+				return err
+			}
+			handler(&proposal)
+			return nil
+		},
+	}
+	return wscli.subscribeOrdered(h, DefaultDeliveryOptions(), func(d *dispatcher) (SubscriptionID, StdError) {
+		return wscli.SubscribeForProposal(nodeIndex, d)
+	})
+}
+
+// decodeLogs decode the logs of a notification, it's a list of logs or a single one
+func decodeLogs(data []byte) ([]TxLog, error) {
+	var logs []TxLog
+	if err := json.Unmarshal(data, &logs); err != nil {
+		var log TxLog
+		if json.Unmarshal(data, &log) != nil {
+			return nil, err
+		}
+		logs = []TxLog{log}
+	}
+	return logs, nil
+}
+
+type abiLogDecoder struct {
+	abi abi.ABI
+}
+
+// NewABILogDecoder return a LogDecoder of the solidity events of the abi
+func NewABILogDecoder(contractABI abi.ABI) LogDecoder {
+	return &abiLogDecoder{abi: contractABI}
+}
+
+// DecodeLog implements LogDecoder, the indexed arguments of dynamic types are kept as common.Hash
+func (d *abiLogDecoder) DecodeLog(log TxLog) (*DecodedLog, error) {
+	if len(log.Topics) == 0 {
+		return nil, errors.New("anonymous event is not supported")
+	}
+	id := common.HexToHash(log.Topics[0])
+	for _, event := range d.abi.Events {
+		if event.Anonymous || event.Id() != id {
+			continue
+		}
+		args := make(map[string]interface{}, len(event.Inputs))
+		values, err := event.Inputs.NonIndexed().UnpackValues(common.FromHex(log.Data))
+		if err != nil {
+			return nil, err
+		}
+		for i, arg := range event.Inputs.NonIndexed() {
+			args[arg.Name] = values[i]
+		}
+		topics := log.Topics[1:]
+		for _, arg := range event.Inputs {
+			if !arg.Indexed {
+				continue
+			}
+			if len(topics) == 0 {
+				return nil, fmt.Errorf("topic of argument %s is missing", arg.Name)
+			}
+			topic := common.HexToHash(topics[0])
+			topics = topics[1:]
+			switch arg.Type.T {
+			case abi.IntTy, abi.UintTy, abi.BoolTy, abi.AddressTy, abi.FixedBytesTy, abi.HashTy:
+				value, err := abi.Arguments{{Name: arg.Name, Type: arg.Type}}.UnpackValues(topic.Bytes())
+				if err != nil {
+					return nil, err
+				}
+				args[arg.Name] = value[0]
+			default:
+				// the hash of the value is the topic
+				args[arg.Name] = topic
+			}
+		}
+		return &DecodedLog{Name: event.Name, Args: args}, nil
+	}
+	return nil, fmt.Errorf("no event with id: %s", id.Hex())
+}
+
+type abi2LogDecoder struct {
+	abi abi2.ABI
+}
+
+// NewABI2LogDecoder return a LogDecoder of the solidity events of the abi
+func NewABI2LogDecoder(contractABI abi2.ABI) LogDecoder {
+	return &abi2LogDecoder{abi: contractABI}
+}
+
+// DecodeLog implements LogDecoder, the indexed arguments of dynamic types are kept as common.Hash
+func (d *abi2LogDecoder) DecodeLog(log TxLog) (*DecodedLog, error) {
+	if len(log.Topics) == 0 {
+		return nil, errors.New("anonymous event is not supported")
+	}
+	event, err := d.abi.EventByID(common.HexToHash(log.Topics[0]))
+	if err != nil {
+		return nil, err
+	}
+	args := make(map[string]interface{}, len(event.Inputs))
+	if err := event.Inputs.NonIndexed().UnpackIntoMap(args, common.FromHex(log.Data)); err != nil {
+		return nil, err
+	}
+	var indexed abi2.Arguments
+	for _, arg := range event.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	topics := make([]common.Hash, 0, len(log.Topics)-1)
+	for _, topic := range log.Topics[1:] {
+		topics = append(topics, common.HexToHash(topic))
+	}
+	if err := abi2.ParseTopicsIntoMap(args, indexed, topics); err != nil {
+		return nil, err
+	}
+	return &DecodedLog{Name: event.Name, Args: args}, nil
+}
+
+// HVMEventDecoder decode the data of a hvm log, it's implemented by hvm.Abi
+type HVMEventDecoder interface {
+	DecodeEvent(data []byte) (string, map[string]interface{}, error)
+}
+
+type hvmLogDecoder struct {
+	abi HVMEventDecoder
+}
+
+// NewHVMLogDecoder return a LogDecoder of the hvm events, e.g. NewHVMLogDecoder(hvmAbi)
+func NewHVMLogDecoder(contractABI HVMEventDecoder) LogDecoder {
+	return &hvmLogDecoder{abi: contractABI}
+}
+
+// DecodeLog implements LogDecoder
+func (d *hvmLogDecoder) DecodeLog(log TxLog) (*DecodedLog, error) {
+	name, args, err := d.abi.DecodeEvent(common.FromHex(log.Data))
+	if err != nil {
+		return nil, err
+	}
+	return &DecodedLog{Name: name, Args: args}, nil
+}
@@ -0,0 +1,144 @@
+package rpc
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/meshplus/gosdk/abi"
+	"github.com/meshplus/gosdk/abi2"
+	"github.com/meshplus/gosdk/common"
+	"github.com/meshplus/gosdk/hvm"
+	"github.com/stretchr/testify/assert"
+)
+
+const transferABI = `[{"type":"event","name":"Transfer","anonymous":false,"inputs":[
+{"name":"from","type":"address","indexed":true},
+{"name":"memo","type":"string","indexed":true},
+{"name":"value","type":"uint256","indexed":false}]}]`
+
+func TestWebSocketClient_SubscribeBlocks(t *testing.T) {
+	wsCli, s := newDeliveryTestClient(t)
+	defer wsCli.CloseConn(1)
+	blocks := make(chan *Block, 10)
+	_, err := wsCli.SubscribeBlocks(1, nil, func(block *Block) { blocks <- block })
+	assert.Nil(t, err)
+
+	// the event failed to decode is skipped
+	s.push("0xsub1", `"bad"`)
+	s.push("0xsub1", testBlock(1))
+	s.push("0xsub1", testBlock(2))
+	for _, expect := range []uint64{1, 2} {
+		select {
+		case block := <-blocks:
+			assert.Equal(t, expect, block.Number)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+	}
+}
+
+func TestWebSocketClient_SubscribeSystemStatus(t *testing.T) {
+	wsCli, s := newDeliveryTestClient(t)
+	defer wsCli.CloseConn(1)
+	events := make(chan *SystemStatusEvent, 10)
+	_, err := wsCli.SubscribeSystemStatus(1, NewSystemStatusFilter().AddModules("p2p"), func(event *SystemStatusEvent) { events <- event })
+	assert.Nil(t, err)
+
+	s.push("0xsub1", `{"module":"p2p","status":false,"subType":"viewchange","errorCode":-1,"message":"timeout"}`)
+	select {
+	case event := <-events:
+		assert.Equal(t, &SystemStatusEvent{Module: "p2p", Subtype: "viewchange", ErrorCode: -1, Message: "timeout"}, event)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+}
+
+func transferLog(t *testing.T, contract abi2.ABI) TxLog {
+	event := contract.Events["Transfer"]
+	data, err := event.Inputs.NonIndexed().Pack(big.NewInt(10))
+	assert.Nil(t, err)
+	return TxLog{
+		Topics: []string{
+			event.ID.Hex(),
+			common.BytesToHash(common.HexToAddress("0x0000000000000000000000000000000000000001").Bytes()).Hex(),
+			common.BytesToHash([]byte("memo")).Hex(),
+		},
+		Data:   common.ToHex(data),
+		TxHash: "0xa",
+	}
+}
+
+func TestWebSocketClient_SubscribeDecodedLogs(t *testing.T) {
+	contract, err := abi2.JSON(strings.NewReader(transferABI))
+	assert.Nil(t, err)
+	log := transferLog(t, contract)
+	wsCli, s := newDeliveryTestClient(t)
+	defer wsCli.CloseConn(1)
+
+	events := make(chan *DecodedLog, 10)
+	_, stdErr := wsCli.SubscribeDecodedLogs(1, NewLogsFilter(), NewABI2LogDecoder(contract), func(log TxLog, event *DecodedLog) { events <- event })
+	assert.Nil(t, stdErr)
+
+	s.push("0xsub1", `[{"address":"0x01","topics":["`+strings.Join(log.Topics, `","`)+`"],"data":"`+log.Data+`"},{"address":"0x01","topics":["0x01"]}]`)
+	for _, expect := range []string{"Transfer", ""} {
+		select {
+		case event := <-events:
+			if expect == "" {
+				assert.Nil(t, event)
+				continue
+			}
+			assert.Equal(t, expect, event.Name)
+			assert.Equal(t, big.NewInt(10), event.Args["value"])
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+		}
+	}
+}
+
+func TestLogDecoder(t *testing.T) {
+	contract, err := abi2.JSON(strings.NewReader(transferABI))
+	assert.Nil(t, err)
+	log := transferLog(t, contract)
+	expect := map[string]interface{}{
+		"from":  common.HexToAddress("0x0000000000000000000000000000000000000001"),
+		"memo":  common.BytesToHash([]byte("memo")),
+		"value": big.NewInt(10),
+	}
+
+	event, err := NewABI2LogDecoder(contract).DecodeLog(log)
+	assert.Nil(t, err)
+	assert.Equal(t, "Transfer", event.Name)
+	assert.Equal(t, expect, event.Args)
+
+	contractV1, err := abi.JSON(strings.NewReader(transferABI))
+	assert.Nil(t, err)
+	event, err = NewABILogDecoder(contractV1).DecodeLog(log)
+	assert.Nil(t, err)
+	assert.Equal(t, "Transfer", event.Name)
+	assert.Equal(t, expect, event.Args)
+
+	_, err = NewABILogDecoder(contractV1).DecodeLog(TxLog{Topics: []string{"0x01"}})
+	assert.NotNil(t, err)
+}
+
+func TestHVMLogDecoder(t *testing.T) {
+	contract := hvm.Abi{{
+		BeanName: "cn.test.Bean",
+		Structs: []hvm.Entry{{
+			Name: "Transfer",
+			Properties: []hvm.Entry{
+				{Name: "amount", EntryType: hvm.Long},
+				{Name: "to", EntryType: hvm.String},
+			},
+		}},
+	}}
+	data := `{"name":"cn.test.Transfer","properties":{"amount":"100","to":"bob","extra":{"a":1}}}`
+	event, err := NewHVMLogDecoder(contract).DecodeLog(TxLog{Data: common.ToHex([]byte(data))})
+	assert.Nil(t, err)
+	assert.Equal(t, "cn.test.Transfer", event.Name)
+	assert.Equal(t, int64(100), event.Args["amount"])
+	assert.Equal(t, "bob", event.Args["to"])
+	assert.NotNil(t, event.Args["extra"])
+}